@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types/ethtypes"
+	"github.com/glifio/glif/v2/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// normalizeContactAddress returns the form addresses are saved in the address
+// book: EVM addresses, including the f4 addresses of EVM accounts, as
+// checksummed 0x addresses and any other f1, f2, f3 or f4 address as is
+func normalizeContactAddress(addr string) (string, error) {
+	if strings.HasPrefix(addr, "0x") {
+		if !common.IsHexAddress(addr) {
+			return "", fmt.Errorf("invalid address %s", addr)
+		}
+		return common.HexToAddress(addr).Hex(), nil
+	}
+
+	filAddr, err := address.NewFromString(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %s: %w", addr, err)
+	}
+	if filAddr.Protocol() == address.ID {
+		return "", fmt.Errorf("ID address %s can not be saved, use the account's f1, f2, f3, f4 or 0x address", addr)
+	}
+	if filAddr.Protocol() == address.Delegated {
+		if ethAddr, err := ethtypes.EthAddressFromFilecoinAddress(filAddr); err == nil {
+			return common.HexToAddress(ethAddr.String()).Hex(), nil
+		}
+	}
+	return filAddr.String(), nil
+}
+
+// validContactName checks a name can be saved in the address book, names
+// must not look like addresses or clash with wallet accounts
+func validContactName(name string) error {
+	if name == "" {
+		return errors.New("contact name must not be empty")
+	}
+	if strings.HasPrefix(name, "0x") || filAddressRe.MatchString(name) {
+		return errors.New("contact name must not look like an address")
+	}
+	if as := util.AccountsStore(); as != nil {
+		if _, _, err := as.GetAddrs(name); err == nil {
+			return fmt.Errorf("%s is a wallet account", name)
+		}
+	}
+	return nil
+}
+
+// lookupContact returns the contact saved under name
+func lookupContact(name string) (util.Contact, bool) {
+	ab := util.AddressBookStore()
+	if ab == nil {
+		return util.Contact{}, false
+	}
+	contact, err := ab.Get(name)
+	return contact, err == nil
+}
+
+// knownRecipient reports whether a recipient, as passed on the command line,
+// is a wallet account or in the address book, by name or by address
+func knownRecipient(recipient string) bool {
+	as := util.AccountsStore()
+	if _, _, err := as.GetAddrs(recipient); err == nil {
+		return true
+	}
+	if _, ok := lookupContact(recipient); ok {
+		return true
+	}
+
+	addr, err := normalizeContactAddress(recipient)
+	if err != nil {
+		return false
+	}
+	if ab := util.AddressBookStore(); ab != nil {
+		if _, ok := ab.FindByAddress(addr); ok {
+			return true
+		}
+	}
+	for _, name := range as.AccountNames() {
+		evm, _, err := as.GetAddrs(name)
+		if err == nil && strings.EqualFold(evm.Hex(), addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// warnUnknownRecipient warns before sending funds to an address that is not
+// a wallet account or in the address book
+func warnUnknownRecipient(recipient string) {
+	if knownRecipient(recipient) {
+		return
+	}
+	log.Printf("Warning: %s is not in your wallet or address book, double check the recipient. Save it with: glif address-book add <name> %s\n", recipient, recipient)
+}
+
+var addressBookCmd = &cobra.Command{
+	Use:     "address-book",
+	Aliases: []string{"contacts"},
+	Short:   "Manage named external addresses",
+	Long: `Manage the address book of named external addresses. Contact names can be used
+anywhere an account name is accepted, and sending funds to an address that is not a
+wallet account or a contact prints a warning.`,
+}
+
+var addressBookAddCmd = &cobra.Command{
+	Use:   "add <name> <address>",
+	Short: "Add a contact to the address book",
+	Long:  "Add an f1, f2, f3, f4 or 0x address to the address book under name. f4 addresses of EVM accounts are saved as their 0x address.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ab := util.AddressBookStore()
+
+		name := args[0]
+		if err := validContactName(name); err != nil {
+			logFatal(err)
+		}
+		if _, err := ab.Get(name); err == nil {
+			logFatalf("Contact %s already exists, change it with: glif address-book update %s", name, name)
+		}
+
+		addr, err := normalizeContactAddress(args[1])
+		if err != nil {
+			logFatal(err)
+		}
+		if existing, ok := ab.FindByAddress(addr); ok {
+			log.Printf("Warning: %s is already saved as %s\n", addr, existing)
+		}
+
+		note := cmd.Flag("note").Value.String()
+		tags, err := cmd.Flags().GetString("tags")
+		if err != nil {
+			logFatal(err)
+		}
+
+		if err := ab.Set(name, util.Contact{Address: addr, Note: note, Tags: util.ParseTags(tags)}); err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Added %s as %s\n", addr, name)
+	},
+}
+
+var addressBookUpdateCmd = &cobra.Command{
+	Use:   "update <name>",
+	Short: "Change the address, note or tags of a contact",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ab := util.AddressBookStore()
+
+		name := args[0]
+		contact, err := ab.Get(name)
+		if err != nil {
+			logFatalf("Contact %s not found", name)
+		}
+
+		if cmd.Flags().Changed("address") {
+			contact.Address, err = normalizeContactAddress(cmd.Flag("address").Value.String())
+			if err != nil {
+				logFatal(err)
+			}
+		}
+		if cmd.Flags().Changed("note") {
+			contact.Note = cmd.Flag("note").Value.String()
+		}
+		if cmd.Flags().Changed("tags") {
+			contact.Tags = util.ParseTags(cmd.Flag("tags").Value.String())
+		}
+
+		if err := ab.Set(name, contact); err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Updated %s\n", name)
+	},
+}
+
+var addressBookRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a contact from the address book",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := util.AddressBookStore().Delete(args[0]); err != nil {
+			logFatal(err)
+		}
+		fmt.Printf("Removed %s\n", args[0])
+	},
+}
+
+var addressBookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the contacts in the address book",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ab := util.AddressBookStore()
+		tag := cmd.Flag("tag").Value.String()
+
+		tbl := table.New("Name", "Address", "Tags", "Note")
+		var rows int
+		for _, name := range ab.Names() {
+			c, _ := ab.Get(name)
+			if tag != "" && !c.HasTag(tag) {
+				continue
+			}
+			tbl.AddRow(name, c.Address, strings.Join(c.Tags, ", "), c.Note)
+			rows++
+		}
+
+		if rows == 0 {
+			fmt.Println("No contacts found, add one with: glif address-book add <name> <address>")
+			return
+		}
+		tbl.Print()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(addressBookCmd)
+
+	addressBookCmd.AddCommand(addressBookAddCmd)
+	addressBookAddCmd.Flags().String("note", "", "note about the contact")
+	addressBookAddCmd.Flags().String("tags", "", "tags separated by commas")
+
+	addressBookCmd.AddCommand(addressBookUpdateCmd)
+	addressBookUpdateCmd.Flags().String("address", "", "new address of the contact")
+	addressBookUpdateCmd.Flags().String("note", "", "new note, replacing the current one")
+	addressBookUpdateCmd.Flags().String("tags", "", "new tags separated by commas, replacing the current ones")
+
+	addressBookCmd.AddCommand(addressBookRemoveCmd)
+
+	addressBookCmd.AddCommand(addressBookListCmd)
+	addressBookListCmd.Flags().String("tag", "", "only list contacts with this tag")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+// importContacts validates contacts and saves them to the address book.
+// Existing contacts are skipped unless overwrite is set
+func importContacts(ab *util.AddressBookStorage, contacts map[string]util.Contact, overwrite bool) (added []string, skipped []string, err error) {
+	names := make([]string, 0, len(contacts))
+	for name, c := range contacts {
+		if err := validContactName(name); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		addr, err := normalizeContactAddress(c.Address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		c.Address = addr
+		contacts[name] = c
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	// nothing is saved unless every contact is valid
+	for _, name := range names {
+		if _, err := ab.Get(name); err == nil && !overwrite {
+			skipped = append(skipped, name)
+			continue
+		}
+		if err := ab.Set(name, contacts[name]); err != nil {
+			return added, skipped, err
+		}
+		added = append(added, name)
+	}
+
+	return added, skipped, nil
+}
+
+var addressBookExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Export the address book as CSV",
+	Long:  "Export the address book as CSV with the columns name, address, note and tags, to file or to stdout. Tags are separated by semicolons.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var out io.Writer = os.Stdout
+		if len(args) == 1 {
+			f, err := os.Create(args[0])
+			if err != nil {
+				logFatal(err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		if err := util.AddressBookStore().WriteCSV(out); err != nil {
+			logFatal(err)
+		}
+
+		if len(args) == 1 {
+			fmt.Printf("Address book written to %s\n", args[0])
+		}
+	},
+}
+
+var addressBookImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import contacts from a CSV file",
+	Long:  "Import contacts from a CSV file with the columns name, address, note and tags, as written by glif address-book export. Contacts that already exist are skipped unless --overwrite is passed. Nothing is imported if any row is invalid.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		overwrite, err := cmd.Flags().GetBool("overwrite")
+		if err != nil {
+			logFatal(err)
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			logFatal(err)
+		}
+		defer f.Close()
+
+		contacts, err := util.ReadContactsCSV(f)
+		if err != nil {
+			logFatalf("Failed to read %s: %s", args[0], err)
+		}
+
+		added, skipped, err := importContacts(util.AddressBookStore(), contacts, overwrite)
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Imported %d contacts\n", len(added))
+		if len(skipped) > 0 {
+			fmt.Printf("Skipped %d existing contacts, pass --overwrite to replace them: %v\n", len(skipped), skipped)
+		}
+	},
+}
+
+func init() {
+	addressBookCmd.AddCommand(addressBookExportCmd)
+	addressBookCmd.AddCommand(addressBookImportCmd)
+	addressBookImportCmd.Flags().Bool("overwrite", false, "replace contacts that already exist")
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/filecoin-project/go-address"
+	"github.com/glifio/glif/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAddressBookTest(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, util.NewAccountsStore(filepath.Join(dir, "accounts.toml")))
+	assert.NoError(t, util.NewAddressBookStore(filepath.Join(dir, "addressbook.toml")))
+}
+
+func TestNormalizeContactAddress(t *testing.T) {
+	evm := common.HexToAddress("0x00000000000000000000000000000000000000ab")
+	f4, err := util.DelegatedFromEthAddr(evm)
+	assert.NoError(t, err)
+	f1, err := address.NewSecp256k1Address([]byte("public key"))
+	assert.NoError(t, err)
+	id, err := address.NewIDAddress(1234)
+	assert.NoError(t, err)
+
+	addr, err := normalizeContactAddress("0x00000000000000000000000000000000000000AB")
+	assert.NoError(t, err)
+	assert.Equal(t, evm.Hex(), addr)
+
+	// f4 addresses of EVM accounts are saved as 0x addresses
+	addr, err = normalizeContactAddress(f4.String())
+	assert.NoError(t, err)
+	assert.Equal(t, evm.Hex(), addr)
+
+	addr, err = normalizeContactAddress(f1.String())
+	assert.NoError(t, err)
+	assert.Equal(t, f1.String(), addr)
+
+	_, err = normalizeContactAddress(id.String())
+	assert.Error(t, err)
+	_, err = normalizeContactAddress("0x1234")
+	assert.Error(t, err)
+	_, err = normalizeContactAddress("not an address")
+	assert.Error(t, err)
+}
+
+func TestValidContactName(t *testing.T) {
+	setupAddressBookTest(t)
+	util.AccountsStore().Set("owner", "0x00000000000000000000000000000000000000aa")
+
+	assert.NoError(t, validContactName("exchange"))
+	assert.Error(t, validContactName(""))
+	assert.Error(t, validContactName("0xexchange"))
+	assert.Error(t, validContactName("f1exchange"))
+	assert.Error(t, validContactName("t3exchange"))
+	assert.Error(t, validContactName("owner"))
+}
+
+func TestKnownRecipient(t *testing.T) {
+	setupAddressBookTest(t)
+	util.AccountsStore().Set("owner", "0x00000000000000000000000000000000000000aa")
+	f1, err := address.NewSecp256k1Address([]byte("public key"))
+	assert.NoError(t, err)
+	assert.NoError(t, util.AddressBookStore().Set("exchange", util.Contact{Address: f1.String()}))
+	assert.NoError(t, util.AddressBookStore().Set("friend", util.Contact{Address: "0x00000000000000000000000000000000000000Bb"}))
+
+	assert.True(t, knownRecipient("owner"))
+	assert.True(t, knownRecipient("exchange"))
+	assert.True(t, knownRecipient(f1.String()))
+	assert.True(t, knownRecipient("0x00000000000000000000000000000000000000AA"))
+	assert.True(t, knownRecipient("0x00000000000000000000000000000000000000bb"))
+
+	// the f4 form of a contact's 0x address
+	f4, err := util.DelegatedFromEthAddr(common.HexToAddress("0x00000000000000000000000000000000000000bb"))
+	assert.NoError(t, err)
+	assert.True(t, knownRecipient(f4.String()))
+
+	assert.False(t, knownRecipient("0x00000000000000000000000000000000000000cc"))
+	assert.False(t, knownRecipient("stranger"))
+}
+
+func TestImportContacts(t *testing.T) {
+	setupAddressBookTest(t)
+	ab := util.AddressBookStore()
+	assert.NoError(t, ab.Set("friend", util.Contact{Address: "0x00000000000000000000000000000000000000Bb", Note: "old"}))
+
+	added, skipped, err := importContacts(ab, map[string]util.Contact{
+		"friend":   {Address: "0x00000000000000000000000000000000000000cc", Note: "new"},
+		"exchange": {Address: "0x00000000000000000000000000000000000000dd", Tags: []string{"cex"}},
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"exchange"}, added)
+	assert.Equal(t, []string{"friend"}, skipped)
+
+	friend, _ := ab.Get("friend")
+	assert.Equal(t, "old", friend.Note)
+	exchange, _ := ab.Get("exchange")
+	assert.Equal(t, common.HexToAddress("0xdd").Hex(), exchange.Address)
+
+	added, skipped, err = importContacts(ab, map[string]util.Contact{
+		"friend": {Address: "0x00000000000000000000000000000000000000cc", Note: "new"},
+	}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"friend"}, added)
+	assert.Empty(t, skipped)
+	friend, _ = ab.Get("friend")
+	assert.Equal(t, "new", friend.Note)
+
+	// nothing is imported when a contact is invalid
+	_, _, err = importContacts(ab, map[string]util.Contact{
+		"another": {Address: "0x00000000000000000000000000000000000000ee"},
+		"broken":  {Address: "nope"},
+	}, false)
+	assert.Error(t, err)
+	_, err = ab.Get("another")
+	assert.Error(t, err)
+}
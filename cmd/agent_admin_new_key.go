@@ -65,6 +65,9 @@ var newRequestKeyCmd = &cobra.Command{
 
 		ks := util.KeyStore()
 
+		if err := recordKeyStoreNetwork(); err != nil {
+			logFatal(err)
+		}
 		account, err := ks.NewAccount(passphrase)
 		if err != nil {
 			logFatal(err)
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"log"
 	"math/big"
@@ -10,6 +12,8 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/filecoin-project/go-address"
 	"github.com/glifio/glif/v2/events"
 	"github.com/glifio/glif/v2/journal/fsjournal"
@@ -159,6 +163,18 @@ var agentAutopilotCmd = &cobra.Command{
 					}
 
 				}
+
+				if err = autopilotTierChange(cmd); err != nil {
+					log.Println(err)
+				}
+
+				if err = autopilotCashBack(cmd, agent); err != nil {
+					log.Println(err)
+				}
+
+				if err = autopilotRedeemPlans(cmd); err != nil {
+					log.Println(err)
+				}
 			SLEEP:
 				sleepTime := 30 * time.Minute
 				if debugSetup {
@@ -209,6 +225,13 @@ func pullFundsFromMiner(cmd *cobra.Command, miner address.Address, amount *big.I
 	if err != nil {
 		return err
 	}
+
+	return pullFundsWithAuth(cmd.Context(), auth, agentAddr, requesterKey, miner, amount)
+}
+
+// pullFundsWithAuth pulls amount from miner into the agent using an already
+// unlocked transactor, recording the pull in the journal
+func pullFundsWithAuth(ctx context.Context, auth *bind.TransactOpts, agentAddr common.Address, requesterKey *ecdsa.PrivateKey, miner address.Address, amount *big.Int) error {
 	pullevt := journal.RegisterEventType("agent", "pull")
 	evt := &events.AgentMinerPull{
 		AgentID: agentAddr.String(),
@@ -217,7 +240,7 @@ func pullFundsFromMiner(cmd *cobra.Command, miner address.Address, amount *big.I
 	}
 	defer journal.RecordEvent(pullevt, func() interface{} { return evt })
 
-	tx, err := PoolsSDK.Act().AgentPullFunds(cmd.Context(), auth, agentAddr, amount, miner, requesterKey)
+	tx, err := PoolsSDK.Act().AgentPullFunds(ctx, auth, agentAddr, amount, miner, requesterKey)
 	if err != nil {
 		evt.Error = err.Error()
 		return err
@@ -225,7 +248,7 @@ func pullFundsFromMiner(cmd *cobra.Command, miner address.Address, amount *big.I
 	evt.Tx = tx.Hash().String()
 
 	// transaction landed on chain or errored
-	_, err = PoolsSDK.Query().StateWaitReceipt(cmd.Context(), tx.Hash())
+	_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
 	if err != nil {
 		evt.Error = err.Error()
 		return err
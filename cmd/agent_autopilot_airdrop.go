@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// plansToRedeem groups the plans whose redeemable balance reaches threshold
+// by the account holding them
+func plansToRedeem(plans []vestingPlan, threshold *big.Int) map[common.Address][]vestingPlan {
+	redeem := map[common.Address][]vestingPlan{}
+	for _, p := range plans {
+		if p.Redeemable.Sign() > 0 && p.Redeemable.Cmp(threshold) >= 0 {
+			redeem[p.Owner] = append(redeem[p.Owner], p)
+		}
+	}
+	return redeem
+}
+
+// autopilotRedeemPlans redeems airdrop plans held by wallet accounts once
+// their redeemable balance exceeds the configured threshold
+func autopilotRedeemPlans(cmd *cobra.Command) error {
+	if !viper.GetBool("autopilot.airdrop.enabled") {
+		return nil
+	}
+
+	threshold := big.NewInt(0)
+	if v := viper.GetString("autopilot.airdrop.redeem-threshold"); v != "" && v != "0" {
+		var err error
+		threshold, err = parseFILAmount(v)
+		if err != nil {
+			return fmt.Errorf("invalid autopilot.airdrop.redeem-threshold: %w", err)
+		}
+	}
+
+	plans, err := listVestingPlans(cmd.Context(), walletAccounts(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	for owner, ps := range plansToRedeem(plans, threshold) {
+		auth, _, err := commonGenericAccountSetup(cmd, owner.Hex())
+		if err != nil {
+			log.Printf("unable to redeem airdrop plans of %s: %s", ps[0].Account, err)
+			continue
+		}
+
+		if err := redeemPlansWithAuth(cmd.Context(), auth, ps); err != nil {
+			log.Printf("failed to redeem airdrop plans of %s: %s", ps[0].Account, err)
+		}
+	}
+
+	return nil
+}
+
+func redeemPlansWithAuth(ctx context.Context, auth *bind.TransactOpts, plans []vestingPlan) error {
+	ids := make([]*big.Int, 0, len(plans))
+	idStrs := make([]string, 0, len(plans))
+	amount := big.NewInt(0)
+	for _, p := range plans {
+		ids = append(ids, p.ID)
+		idStrs = append(idStrs, p.ID.String())
+		amount.Add(amount, p.Redeemable)
+	}
+
+	redeemevt := journal.RegisterEventType("airdrop", "redeem")
+	evt := &events.AirdropRedeem{
+		Account: plans[0].Owner.Hex(),
+		PlanIDs: idStrs,
+		Amount:  amount.String(),
+	}
+	defer journal.RecordEvent(redeemevt, func() interface{} { return evt })
+
+	ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	defer ethClient.Close()
+
+	txor, err := abigen.NewIHedgeyVoteTokenLockupPlanTransactor(PoolsSDK.Query().TokenNFTWrapper(), ethClient)
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+
+	log.Printf("Redeeming %0.06f GLF from airdrop plans %v of %s", util.ToFIL(amount), idStrs, plans[0].Account)
+
+	tx, err := txor.RedeemPlans(auth, ids)
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	evt.Tx = tx.Hash().String()
+
+	_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
+	poolstypes "github.com/glifio/go-pools/types"
+	"github.com/glifio/go-pools/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// cashBackConfig holds the [autopilot.cashback] config section
+type cashBackConfig struct {
+	Enabled bool
+	// RunwayDays is the number of days of cash back the GLF vault must cover
+	// before autopilot tops it up
+	RunwayDays int64
+	// TargetDays is the number of days of cash back a top up covers
+	TargetDays int64
+	// MaxTopUp caps a single top up in GLF, nil for no limit
+	MaxTopUp *big.Int
+	// ClaimThreshold is the earned FIL cash back that triggers a claim, nil disables claiming
+	ClaimThreshold *big.Int
+	Receiver       string
+	// MinProgramFILVault is the program FIL vault balance below which autopilot warns
+	MinProgramFILVault *big.Int
+}
+
+// cashBackState is the on chain state a cash back plan is made from
+type cashBackState struct {
+	Principal *big.Int
+	Rate      *big.Int
+	// GLFPerFIL is the tier conversion rate, including the tier premium
+	GLFPerFIL       *big.Int
+	CashBackPercent *big.Int
+	GLFVault        *big.Int
+	FILEarned       *big.Int
+	ProgramFILVault *big.Int
+}
+
+// cashBackPlan is what autopilot should do with the Card's cash back this cycle
+type cashBackPlan struct {
+	DailyFIL *big.Int
+	DailyGLF *big.Int
+	// TopUp is the GLF to deposit into the vault, nil when no top up is needed
+	TopUp *big.Int
+	// Claim is the FIL cash back to claim, nil when below the threshold
+	Claim    *big.Int
+	Warnings []string
+}
+
+func loadCashBackConfig() (*cashBackConfig, error) {
+	c := &cashBackConfig{
+		Enabled:    viper.GetBool("autopilot.cashback.enabled"),
+		RunwayDays: viper.GetInt64("autopilot.cashback.runway-days"),
+		TargetDays: viper.GetInt64("autopilot.cashback.target-days"),
+		Receiver:   viper.GetString("autopilot.cashback.receiver"),
+	}
+
+	if c.RunwayDays < 0 {
+		return nil, fmt.Errorf("invalid autopilot.cashback.runway-days %d", c.RunwayDays)
+	}
+	if c.TargetDays < c.RunwayDays {
+		c.TargetDays = c.RunwayDays
+	}
+
+	amounts := []struct {
+		key string
+		dst **big.Int
+	}{
+		{"autopilot.cashback.max-top-up", &c.MaxTopUp},
+		{"autopilot.cashback.claim-threshold", &c.ClaimThreshold},
+		{"autopilot.cashback.min-program-fil-vault", &c.MinProgramFILVault},
+	}
+	for _, a := range amounts {
+		v := viper.GetString(a.key)
+		if v == "" || v == "0" {
+			continue
+		}
+		amt, err := parseFILAmount(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", a.key, err)
+		}
+		*a.dst = amt
+	}
+
+	return c, nil
+}
+
+// planCashBack sizes the GLF vault top up from the Agent's daily interest,
+// decides whether earned cash back should be claimed and checks that the
+// program FIL vault can honour the cash back over the runway
+func planCashBack(c *cashBackConfig, s cashBackState) cashBackPlan {
+	dailyInterest := interestForEpochs(s.Principal, s.Rate, big.NewInt(constants.EpochsInDay))
+	plan := cashBackPlan{
+		DailyFIL: cashBackFor(dailyInterest, s.CashBackPercent),
+	}
+	plan.DailyGLF = util.MulWad(plan.DailyFIL, s.GLFPerFIL)
+
+	if plan.DailyGLF.Sign() > 0 && c.RunwayDays > 0 {
+		runway := new(big.Int).Mul(plan.DailyGLF, big.NewInt(c.RunwayDays))
+		if s.GLFVault.Cmp(runway) < 0 {
+			target := new(big.Int).Mul(plan.DailyGLF, big.NewInt(c.TargetDays))
+			topUp := new(big.Int).Sub(target, s.GLFVault)
+			if c.MaxTopUp != nil {
+				topUp = bigMin(topUp, c.MaxTopUp)
+			}
+			if topUp.Sign() > 0 {
+				plan.TopUp = topUp
+			}
+		}
+	}
+
+	if c.ClaimThreshold != nil && s.FILEarned.Cmp(c.ClaimThreshold) >= 0 && s.FILEarned.Sign() > 0 {
+		plan.Claim = new(big.Int).Set(s.FILEarned)
+	}
+
+	minVault := new(big.Int).Mul(plan.DailyFIL, big.NewInt(c.RunwayDays))
+	if c.MinProgramFILVault != nil {
+		minVault = bigMax(minVault, c.MinProgramFILVault)
+	}
+	if minVault.Sign() > 0 && s.ProgramFILVault.Cmp(minVault) < 0 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"cash back program FIL vault holds %0.09f FIL, below the %0.09f FIL needed to honour cash back, payments will be cashed back in GLF instead",
+			util.ToFIL(s.ProgramFILVault), util.ToFIL(minVault)))
+	}
+
+	return plan
+}
+
+// autopilotCashBack runs one cycle of GLIF Card cash back management for agent
+func autopilotCashBack(cmd *cobra.Command, agent common.Address) error {
+	ctx := cmd.Context()
+
+	cfg, err := loadCashBackConfig()
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+
+	tokenID, err := getPlusTokenID()
+	if err != nil {
+		return fmt.Errorf("cash back management enabled but no GLIF Card found: %w", err)
+	}
+
+	query := PoolsSDK.Query()
+	tasks := []util.TaskFunc{
+		func() (interface{}, error) {
+			return query.InfPoolGetAccount(ctx, agent, nil)
+		},
+		func() (interface{}, error) {
+			return query.InfPoolGetRate(ctx)
+		},
+		func() (interface{}, error) {
+			return query.SPPlusInfo(ctx, big.NewInt(tokenID), nil)
+		},
+		func() (interface{}, error) {
+			return query.SPPlusTierInfo(ctx, nil)
+		},
+		func() (interface{}, error) {
+			return query.SPPlusFILVaultBalance(ctx, nil)
+		},
+	}
+	results, err := util.Multiread(tasks)
+	if err != nil {
+		return err
+	}
+
+	account := results[0].(abigen.Account)
+	info := results[2].(*poolstypes.SPPlusInfo)
+	tierInfos := results[3].([]abigen.TierInfo)
+
+	if info.Tier == 0 {
+		log.Println("GLIF Card is not active, skipping cash back management")
+		return nil
+	}
+
+	glfPerFIL := info.BaseConversionRateFILtoGLF
+	if int(info.Tier) < len(tierInfos) {
+		glfPerFIL = util.MulWad(info.BaseConversionRateFILtoGLF, tierInfos[info.Tier].CashBackPremium)
+	}
+
+	plan := planCashBack(cfg, cashBackState{
+		Principal:       account.Principal,
+		Rate:            results[1].(*big.Int),
+		GLFPerFIL:       glfPerFIL,
+		CashBackPercent: info.PersonalCashBackPercent,
+		GLFVault:        info.GLFVaultBalance,
+		FILEarned:       info.FilCashbackEarned,
+		ProgramFILVault: results[4].(*big.Int),
+	})
+
+	for _, w := range plan.Warnings {
+		log.Println("WARNING:", w)
+	}
+
+	log.Printf("GLF vault: %0.09f GLF, projected cash back %0.09f GLF per day", util.ToFIL(info.GLFVaultBalance), util.ToFIL(plan.DailyGLF))
+
+	if plan.TopUp != nil {
+		if err := checkGlfPlusBalanceAndAllowance(plan.TopUp); err != nil {
+			log.Printf("WARNING: unable to top up GLF vault with %0.09f GLF: %s", util.ToFIL(plan.TopUp), err)
+			plan.TopUp = nil
+		}
+	}
+
+	var receiver common.Address
+	if plan.Claim != nil {
+		r := cfg.Receiver
+		if r == "" {
+			r = "owner"
+		}
+		receiver, err = AddressOrAccountNameToEVM(ctx, r)
+		if err != nil {
+			return fmt.Errorf("invalid autopilot.cashback.receiver %s: %w", r, err)
+		}
+	}
+
+	if plan.TopUp == nil && plan.Claim == nil {
+		return nil
+	}
+
+	// funding the vault and claiming cash back are owner only calls
+	_, auth, _, _, err := commonSetupOwnerCall(cmd)
+	if err != nil {
+		return err
+	}
+
+	if plan.TopUp != nil {
+		log.Printf("Topping up GLF vault with %0.09f GLF", util.ToFIL(plan.TopUp))
+		if err := fundGLFVaultWithAuth(ctx, auth, tokenID, plan.TopUp); err != nil {
+			return fmt.Errorf("failed to fund GLF vault: %w", err)
+		}
+		// the nonce was consumed by the top up
+		auth.Nonce = nil
+	}
+
+	if plan.Claim != nil {
+		log.Printf("Claiming %0.09f FIL cash back to %s", util.ToFIL(plan.Claim), receiver)
+		if err := claimCashBackWithAuth(ctx, auth, tokenID, plan.Claim, receiver); err != nil {
+			return fmt.Errorf("failed to claim cash back: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func fundGLFVaultWithAuth(ctx context.Context, auth *bind.TransactOpts, tokenID int64, amount *big.Int) error {
+	fundevt := journal.RegisterEventType("plus", "fund")
+	evt := &events.PlusFundGLFVault{
+		TokenID: fmt.Sprintf("%d", tokenID),
+		Amount:  amount.String(),
+	}
+	defer journal.RecordEvent(fundevt, func() interface{} { return evt })
+
+	tx, err := PoolsSDK.Act().SPPlusFundGLFVault(ctx, auth, big.NewInt(tokenID), amount, nil)
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	evt.Tx = tx.Hash().String()
+
+	_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	return nil
+}
+
+func claimCashBackWithAuth(ctx context.Context, auth *bind.TransactOpts, tokenID int64, amount *big.Int, receiver common.Address) error {
+	claimevt := journal.RegisterEventType("plus", "claim-rewards")
+	evt := &events.PlusClaimCashBack{
+		TokenID: fmt.Sprintf("%d", tokenID),
+		Amount:  amount.String(),
+		To:      receiver.String(),
+	}
+	defer journal.RecordEvent(claimevt, func() interface{} { return evt })
+
+	tx, err := PoolsSDK.Act().SPPlusClaimCashBack(ctx, auth, big.NewInt(tokenID), receiver)
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	evt.Tx = tx.Hash().String()
+
+	_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/glifio/go-pools/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCashBackState returns a state earning 1 FIL of interest per day,
+// 10% of which is cashed back at 100 GLF per FIL
+func testCashBackState() cashBackState {
+	// rate * principal * EpochsInDay / WAD / WAD = 1 FIL
+	rate := new(big.Int).Div(new(big.Int).Mul(constants.WAD, constants.WAD), big.NewInt(constants.EpochsInDay))
+	return cashBackState{
+		Principal:       fil(1),
+		Rate:            rate,
+		GLFPerFIL:       fil(100),
+		CashBackPercent: big.NewInt(1000),
+		GLFVault:        fil(1000),
+		FILEarned:       big.NewInt(0),
+		ProgramFILVault: fil(5000),
+	}
+}
+
+func TestPlanCashBackTopUp(t *testing.T) {
+	c := &cashBackConfig{RunwayDays: 14, TargetDays: 30}
+	s := testCashBackState()
+
+	plan := planCashBack(c, s)
+	assert.InDelta(t, 0.1, toFloat(plan.DailyFIL), 1e-9)
+	assert.InDelta(t, 10.0, toFloat(plan.DailyGLF), 1e-9)
+	// 1000 GLF covers 100 days of cash back
+	assert.Nil(t, plan.TopUp)
+
+	s.GLFVault = fil(100)
+	plan = planCashBack(c, s)
+	// refill to 30 days of cash back, 300 GLF
+	assert.InDelta(t, 200.0, toFloat(plan.TopUp), 1e-6)
+
+	c.MaxTopUp = fil(50)
+	plan = planCashBack(c, s)
+	assert.Equal(t, 0, plan.TopUp.Cmp(fil(50)))
+
+	s.Principal = big.NewInt(0)
+	plan = planCashBack(c, s)
+	assert.Nil(t, plan.TopUp)
+}
+
+func TestPlanCashBackClaim(t *testing.T) {
+	c := &cashBackConfig{RunwayDays: 14, TargetDays: 30}
+	s := testCashBackState()
+	s.FILEarned = fil(2)
+
+	plan := planCashBack(c, s)
+	assert.Nil(t, plan.Claim)
+
+	c.ClaimThreshold = fil(3)
+	plan = planCashBack(c, s)
+	assert.Nil(t, plan.Claim)
+
+	c.ClaimThreshold = fil(2)
+	plan = planCashBack(c, s)
+	assert.Equal(t, 0, plan.Claim.Cmp(fil(2)))
+}
+
+func TestPlanCashBackProgramVaultWarning(t *testing.T) {
+	c := &cashBackConfig{RunwayDays: 14, TargetDays: 30}
+	s := testCashBackState()
+
+	plan := planCashBack(c, s)
+	assert.Empty(t, plan.Warnings)
+
+	// 14 days of cash back needs 1.4 FIL in the program vault
+	s.ProgramFILVault = fil(1)
+	plan = planCashBack(c, s)
+	assert.Len(t, plan.Warnings, 1)
+
+	s.ProgramFILVault = fil(5000)
+	c.MinProgramFILVault = fil(10000)
+	plan = planCashBack(c, s)
+	assert.Len(t, plan.Warnings, 1)
+}
+
+func toFloat(n *big.Int) float64 {
+	f, _ := new(big.Float).Quo(new(big.Float).SetInt(n), new(big.Float).SetInt(constants.WAD)).Float64()
+	return f
+}
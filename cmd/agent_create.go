@@ -10,7 +10,6 @@ import (
 	"os"
 	"time"
 
-	"github.com/AlecAivazis/survey/v2"
 	"github.com/briandowns/spinner"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/glifio/glif/v2/util"
@@ -53,12 +52,13 @@ var createCmd = &cobra.Command{
 		checkExists(err)
 
 		account := accounts.Account{Address: ownerAddr}
+		auth, inKeyring := keyringTransactor(ownerAddr)
 		passphrase, envSet := os.LookupEnv("GLIF_OWNER_PASSPHRASE")
-		if !envSet {
-			prompt := &survey.Password{
-				Message: "Owner key passphrase",
+		if !envSet && !inKeyring {
+			passphrase, err = askPassphrase("Owner key passphrase", ownerAddr)
+			if err != nil {
+				logFatal(err)
 			}
-			survey.AskOne(prompt, &passphrase)
 		}
 		wallet, err := manager.Find(account)
 		if err != nil {
@@ -75,9 +75,11 @@ var createCmd = &cobra.Command{
 		s.Start()
 		defer s.Stop()
 
-		auth, err := walletutils.NewEthWalletTransactor(wallet, &account, passphrase, big.NewInt(chainID))
-		if err != nil {
-			logFatal(err)
+		if !inKeyring {
+			auth, err = walletutils.NewEthWalletTransactor(wallet, &account, passphrase, big.NewInt(chainID))
+			if err != nil {
+				logFatal(err)
+			}
 		}
 
 		// submit the agent create transaction
@@ -4,22 +4,66 @@ Copyright © 2023 Glif LTD
 package cmd
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
 	"log"
 	"math/big"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/util"
 	"github.com/spf13/cobra"
 )
 
 var exitCmd = &cobra.Command{
 	Use:   "exit",
 	Short: "Exits from the Infinity Pool",
-	Args:  cobra.NoArgs,
+	Long: `Exits from the Infinity Pool by paying back all principal and the interest owed at the
+epoch the payment is expected to land. Any overpayment is refunded to the Agent.`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := cmd.Context()
+
+		inclusionEpochs, err := cmd.Flags().GetInt64("inclusion-epochs")
+		if err != nil {
+			logFatal(err)
+		}
+		if inclusionEpochs < 0 {
+			logFatal("inclusion-epochs must not be negative")
+		}
+
+		pullFromMiners, err := cmd.Flags().GetBool("pull-from-miners")
+		if err != nil {
+			logFatal(err)
+		}
+
+		var newMinerOwner address.Address
+		offboardTo := cmd.Flag("offboard-miners").Value.String()
+		if offboardTo != "" {
+			newMinerOwner, err = address.NewFromString(offboardTo)
+			if err != nil {
+				logFatal(err)
+			}
+			// IMPORTANT: an ethereum address can not be an owner of a miner, this must be a filecoin address owner
+			if newMinerOwner.Protocol() == address.Delegated {
+				logFatal("New miner owner address must be a filecoin address, not a delegated address")
+			}
+		}
+
+		// removing miners requires the owner key, so use it for the whole flow
 		from := cmd.Flag("from").Value.String()
+		if offboardTo != "" {
+			from = "owner"
+		}
 		agentAddr, auth, _, requesterKey, err := commonOwnerOrOperatorSetup(cmd, from)
 		if err != nil {
 			logFatal(err)
@@ -32,69 +76,244 @@ var exitCmd = &cobra.Command{
 			logFatal(err)
 		}
 
+		lapi, closer, err := PoolsSDK.Extern().ConnectLotusClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer closer()
+
 		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 		s.Start()
 		defer s.Stop()
 
-		account, err := PoolsSDK.Query().InfPoolGetAccount(ctx, agentAddr, nil)
+		query := PoolsSDK.Query()
+
+		tasks := []util.TaskFunc{
+			func() (interface{}, error) {
+				return query.InfPoolGetAccount(ctx, agentAddr, nil)
+			},
+			func() (interface{}, error) {
+				return query.InfPoolGetRate(ctx)
+			},
+			func() (interface{}, error) {
+				return query.ChainHeight(ctx)
+			},
+			func() (interface{}, error) {
+				return query.AgentLiquidAssets(ctx, agentAddr, nil)
+			},
+		}
+		results, err := util.Multiread(tasks)
 		if err != nil {
-			logFatalf("Failed to get iFIL balance %s", err)
+			logFatalf("Failed to get infinity pool account %s", err)
 		}
 
-		amountOwed, err := PoolsSDK.Query().AgentInterestOwed(ctx, agentAddr, nil)
-		if err != nil {
-			logFatal(err)
+		account := results[0].(abigen.Account)
+		principal := account.Principal
+		epochsPaid := account.EpochsPaid
+		rate := results[1].(*big.Int)
+		chainHead := results[2].(*big.Int)
+		liquidAssets := results[3].(*big.Int)
+
+		s.Stop()
+
+		if principal.Sign() == 0 {
+			fmt.Println("Agent has no outstanding principal in the Infinity Pool")
+		} else {
+			targetEpoch := new(big.Int).Add(chainHead, big.NewInt(inclusionEpochs))
+			payAmount := exitPayoff(principal, rate, epochsPaid, targetEpoch)
+			interest := new(big.Int).Sub(payAmount, principal)
+
+			fmt.Printf("Principal: %0.09f FIL\n", util.ToFIL(principal))
+			fmt.Printf("Interest owed at epoch %s: %0.09f FIL\n", targetEpoch, util.ToFIL(interest))
+			fmt.Printf("Payoff amount: %0.09f FIL\n", util.ToFIL(payAmount))
+
+			if liquidAssets.Cmp(payAmount) < 0 {
+				shortfall := new(big.Int).Sub(payAmount, liquidAssets)
+				if !pullFromMiners {
+					logFatalf("Agent liquid assets %0.09f FIL are not enough to exit, %0.09f FIL short. Re-run with --pull-from-miners or pull funds manually", util.ToFIL(liquidAssets), util.ToFIL(shortfall))
+				}
+
+				fmt.Printf("Pulling %0.09f FIL from the Agent's miners...\n", util.ToFIL(shortfall))
+				s.Start()
+				err = pullShortfallFromMiners(ctx, lapi, auth, agentAddr, requesterKey, shortfall)
+				if err != nil {
+					logFatal(err)
+				}
+
+				liquidAssets, err = query.AgentLiquidAssets(ctx, agentAddr, nil)
+				if err != nil {
+					logFatal(err)
+				}
+				s.Stop()
+			}
+
+			s.Start()
+
+			exitevt := journal.RegisterEventType("agent", "exit")
+			evt := &events.AgentExit{
+				AgentID:     agentAddr.String(),
+				PoolID:      poolID.String(),
+				Amount:      payAmount.String(),
+				TargetEpoch: targetEpoch.String(),
+			}
+			defer journal.Close()
+			defer journal.RecordEvent(exitevt, func() interface{} { return evt })
+
+			tx, err := PoolsSDK.Act().AgentPay(ctx, auth, agentAddr, poolID, payAmount, requesterKey)
+			if err != nil {
+				evt.Error = err.Error()
+				logFatal(err)
+			}
+			evt.Tx = tx.Hash().String()
+
+			// transaction landed on chain or errored
+			_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+			if err != nil {
+				evt.Error = err.Error()
+				logFatal(err)
+			}
+
+			account, err = query.InfPoolGetAccount(ctx, agentAddr, nil)
+			if err != nil {
+				logFatal(err)
+			}
+
+			liquidAfter, err := query.AgentLiquidAssets(ctx, agentAddr, nil)
+			if err != nil {
+				logFatal(err)
+			}
+
+			s.Stop()
+
+			spent := new(big.Int).Sub(liquidAssets, liquidAfter)
+			refund := new(big.Int).Sub(payAmount, spent)
+			if refund.Sign() > 0 {
+				evt.Refund = refund.String()
+				fmt.Printf("Overpayment refunded to Agent: %s FIL\n", formatFILAmount(refund))
+			}
+
+			if account.Principal.Sign() != 0 {
+				evt.Residual = account.Principal.String()
+				logFatalf("Exit incomplete, %s FIL of principal remains. Run `glif agent exit` again", formatFILAmount(account.Principal))
+			}
+
+			log.Println("Successfully exited from the Infinity Pool")
 		}
 
-		payAmount := new(big.Int).Add(amountOwed, account.Principal)
-		payAmount = addOnePercent(payAmount)
+		if offboardTo != "" {
+			err = offboardMiners(ctx, auth, agentAddr, requesterKey, newMinerOwner)
+			if err != nil {
+				logFatal(err)
+			}
+		}
+	},
+}
+
+// exitPayoff returns the amount that pays off principal and all interest
+// accrued up to targetEpoch, rounding the interest up so that no dust
+// remains borrowed
+func exitPayoff(principal, rate, epochsPaid, targetEpoch *big.Int) *big.Int {
+	epochs := new(big.Int).Sub(targetEpoch, epochsPaid)
+	if epochs.Sign() < 0 {
+		epochs = big.NewInt(0)
+	}
+
+	interest := new(big.Int).Mul(principal, rate)
+	interest.Mul(interest, epochs)
+
+	precision := new(big.Int).Mul(constants.WAD, constants.WAD)
+	interest.Add(interest, new(big.Int).Sub(precision, big.NewInt(1)))
+	interest.Div(interest, precision)
+
+	return interest.Add(interest, principal)
+}
+
+// pullShortfallFromMiners pulls amount from the agent's miners, draining
+// each miner's available balance in turn until the amount is covered
+func pullShortfallFromMiners(ctx context.Context, lapi api.FullNode, auth *bind.TransactOpts, agentAddr common.Address, requesterKey *ecdsa.PrivateKey, amount *big.Int) error {
+	miners, err := PoolsSDK.Query().AgentMiners(ctx, agentAddr, nil)
+	if err != nil {
+		return err
+	}
 
-		exitevt := journal.RegisterEventType("agent", "exit")
-		evt := &events.AgentExit{
-			AgentID: agentAddr.String(),
-			PoolID:  poolID.String(),
-			Amount:  payAmount.String(),
+	remaining := new(big.Int).Set(amount)
+	for _, miner := range miners {
+		if remaining.Sign() <= 0 {
+			break
 		}
-		defer journal.Close()
-		defer journal.RecordEvent(exitevt, func() interface{} { return evt })
 
-		tx, err := PoolsSDK.Act().AgentPay(ctx, auth, agentAddr, poolID, payAmount, requesterKey)
+		avail, err := lapi.StateMinerAvailableBalance(ctx, miner, types.EmptyTSK)
 		if err != nil {
-			evt.Error = err.Error()
-			logFatal(err)
+			return err
+		}
+		if avail.Sign() <= 0 {
+			continue
 		}
-		evt.Tx = tx.Hash().String()
 
-		// transaction landed on chain or errored
-		_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
-		if err != nil {
-			evt.Error = err.Error()
-			logFatal(err)
+		pull := bigMin(avail.Int, remaining)
+		log.Printf("Pulling %0.09f FIL from miner %s", util.ToFIL(pull), miner)
+		if err := pullFundsWithAuth(ctx, auth, agentAddr, requesterKey, miner, pull); err != nil {
+			return err
 		}
+		remaining.Sub(remaining, pull)
+	}
 
-		s.Stop()
+	if remaining.Sign() > 0 {
+		return fmt.Errorf("miners do not have enough available balance, %0.09f FIL short", util.ToFIL(remaining))
+	}
 
-		log.Println("Successfully exited from the Infinity Pool")
-	},
+	return nil
 }
 
-func addOnePercent(amount *big.Int) *big.Int {
-	// Convert the amount to big.Float
-	amountFloat := new(big.Float).SetInt(amount)
+// offboardMiners proposes newOwner as the owner of every miner on the agent
+func offboardMiners(ctx context.Context, auth *bind.TransactOpts, agentAddr common.Address, requesterKey *ecdsa.PrivateKey, newOwner address.Address) error {
+	miners, err := PoolsSDK.Query().AgentMiners(ctx, agentAddr, nil)
+	if err != nil {
+		return err
+	}
 
-	// Add 1%
-	onePercent := new(big.Float).SetFloat64(1.01)
-	amountFloat.Mul(amountFloat, onePercent)
+	if len(miners) == 0 {
+		fmt.Println("Agent has no miners to offboard")
+		return nil
+	}
 
-	// Convert back to big.Int
-	newAmount := new(big.Int)
-	amountFloat.Int(newAmount)
+	removeevt := journal.RegisterEventType("agent", "removeminer")
+	for _, miner := range miners {
+		fmt.Printf("Removing miner %s from agent %s by changing its owner address to %s\n", miner, agentAddr, newOwner)
+
+		evt := &events.AgentMinerRemove{
+			AgentID:  agentAddr.String(),
+			MinerID:  miner.String(),
+			NewOwner: newOwner.String(),
+		}
+
+		tx, err := PoolsSDK.Act().AgentRemoveMiner(ctx, auth, agentAddr, miner, newOwner, requesterKey)
+		if err != nil {
+			evt.Error = err.Error()
+			journal.RecordEvent(removeevt, func() interface{} { return evt })
+			return err
+		}
+		evt.Tx = tx.Hash().String()
+
+		_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+		if err != nil {
+			evt.Error = err.Error()
+		}
+		journal.RecordEvent(removeevt, func() interface{} { return evt })
+		if err != nil {
+			return err
+		}
+	}
 
-	return newAmount
+	fmt.Printf("Successfully proposed an ownership change to %d miners, passing %s as the new owner\n", len(miners), newOwner)
+	return nil
 }
 
 func init() {
 	agentCmd.AddCommand(exitCmd)
 	exitCmd.Flags().String("pool-name", "infinity-pool", "name of the pool to make a payment")
 	exitCmd.Flags().String("from", "", "address to send the transaction from")
+	exitCmd.Flags().Int64("inclusion-epochs", 10, "number of epochs expected to elapse before the payment is included on chain")
+	exitCmd.Flags().Bool("pull-from-miners", false, "pull any shortfall from the Agent's miners before exiting")
+	exitCmd.Flags().String("offboard-miners", "", "after exiting, remove all miners from the Agent by proposing this filecoin address as their new owner")
 }
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/glifio/go-pools/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitPayoff(t *testing.T) {
+	principal := new(big.Int).Mul(big.NewInt(100), constants.WAD)
+
+	// no epochs owed pays principal only
+	payoff := exitPayoff(principal, testRate, big.NewInt(1000), big.NewInt(1000))
+	assert.Equal(t, 0, payoff.Cmp(principal))
+
+	// epochs paid ahead of the target never reduce the payoff
+	payoff = exitPayoff(principal, testRate, big.NewInt(2000), big.NewInt(1000))
+	assert.Equal(t, 0, payoff.Cmp(principal))
+
+	// interest over whole epochs matches the schedule projection exactly
+	epochs := big.NewInt(constants.EpochsInDay)
+	payoff = exitPayoff(principal, testRate, big.NewInt(0), epochs)
+	expected := new(big.Int).Add(principal, interestForEpochs(principal, testRate, epochs))
+	assert.Equal(t, 0, payoff.Cmp(expected))
+
+	// fractional interest is rounded up so no dust remains borrowed
+	payoff = exitPayoff(big.NewInt(1), testRate, big.NewInt(0), big.NewInt(1))
+	assert.Equal(t, 0, payoff.Cmp(big.NewInt(2)))
+}
@@ -0,0 +1,641 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/glif/v2/util"
+	"github.com/glifio/go-pools/abigen"
+	denoms "github.com/glifio/go-pools/util"
+	walletutils "github.com/glifio/go-wallet-utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// rotationRoles are the agent roles in the order their keys are rotated. The
+// operator and requester go first, while the old owner can still sign for them
+var rotationRoles = []util.KeyType{util.OperatorKey, util.RequestKey, util.OwnerKey}
+
+// parseRotationRoles parses the --roles flag into rotationRoles order
+func parseRotationRoles(roles []string) ([]util.KeyType, error) {
+	selected := map[util.KeyType]bool{}
+	for _, role := range roles {
+		switch strings.ToLower(strings.TrimSpace(role)) {
+		case "owner":
+			selected[util.OwnerKey] = true
+		case "operator":
+			selected[util.OperatorKey] = true
+		case "requester", "request":
+			selected[util.RequestKey] = true
+		default:
+			return nil, fmt.Errorf("invalid role %s, roles must be owner, operator or requester", role)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no roles to rotate")
+	}
+
+	var parsed []util.KeyType
+	for _, role := range rotationRoles {
+		if selected[role] {
+			parsed = append(parsed, role)
+		}
+	}
+	return parsed, nil
+}
+
+// planRotation returns the steps of a rotation in the order they run
+func planRotation(roles []util.KeyType, fund bool, hasCard bool) []string {
+	var steps []string
+	if fund {
+		for _, role := range []util.KeyType{util.OwnerKey, util.OperatorKey} {
+			if containsRole(roles, role) {
+				steps = append(steps, "fund-"+string(role))
+			}
+		}
+	}
+	for _, role := range roles {
+		switch role {
+		case util.OperatorKey:
+			steps = append(steps, "transfer-operator", "accept-operator")
+		case util.RequestKey:
+			steps = append(steps, "change-requester")
+		case util.OwnerKey:
+			steps = append(steps, "transfer-ownership", "accept-ownership")
+			if hasCard {
+				steps = append(steps, "transfer-card-owner")
+			}
+		}
+	}
+	return steps
+}
+
+func containsRole(roles []util.KeyType, role util.KeyType) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// rotationStep is one transaction of a key rotation
+type rotationStep struct {
+	name string
+	// role is the role whose new key the step acts on
+	role util.KeyType
+	// confirmed reports whether the step already took effect on chain
+	confirmed func() (bool, error)
+	send      func() (*types.Transaction, error)
+	// finish runs once the step is confirmed, it must be safe to run twice
+	finish func() error
+}
+
+// runRotationSteps runs the steps that are not completed yet, in order.
+// Transactions sent before an interruption are waited for instead of sent
+// again, and steps that already took effect on chain are not sent at all
+func runRotationSteps(r *keyRotation, steps []rotationStep, wait func(common.Hash) error, sent func(step rotationStep, tx common.Hash, err error)) error {
+	for _, step := range steps {
+		if r.isCompleted(step.name) {
+			continue
+		}
+
+		if hash, ok := r.Pending[step.name]; ok {
+			log.Printf("Waiting for %s transaction %s sent before\n", step.name, hash)
+			if err := wait(common.HexToHash(hash)); err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+		} else {
+			done := false
+			if step.confirmed != nil {
+				var err error
+				if done, err = step.confirmed(); err != nil {
+					return fmt.Errorf("%s: %w", step.name, err)
+				}
+			}
+
+			if !done {
+				log.Printf("Sending %s\n", step.name)
+				tx, err := step.send()
+				if err != nil {
+					sent(step, common.Hash{}, err)
+					return fmt.Errorf("%s: %w", step.name, err)
+				}
+				if err := r.setPending(step.name, tx.Hash().Hex()); err != nil {
+					return err
+				}
+				err = wait(tx.Hash())
+				sent(step, tx.Hash(), err)
+				if err != nil {
+					return fmt.Errorf("%s: %w", step.name, err)
+				}
+			}
+		}
+
+		if step.finish != nil {
+			if err := step.finish(); err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+		}
+		if err := r.complete(step.name); err != nil {
+			return err
+		}
+		log.Printf("Completed %s\n", step.name)
+	}
+	return nil
+}
+
+// swapRotatedKey saves the new key of role under the role's name once the
+// agent confirmed it. The old key is kept, renamed with the rotation's start time
+func swapRotatedKey(as *util.AccountsStorage, r *keyRotation, role util.KeyType) error {
+	name := string(role)
+	newAddr := r.NewKeys[name]
+
+	current, err := as.Get(name)
+	if err == nil && strings.EqualFold(current, newAddr) {
+		return nil
+	}
+
+	if current != "" {
+		oldName := fmt.Sprintf("%s-%s", name, r.StartedAt.Format(time.RFC3339))
+		if err := as.Set(oldName, current); err != nil {
+			return err
+		}
+		if path, ok := as.DerivationPath(name); ok {
+			if err := as.SetDerivationPath(oldName, path); err != nil {
+				return err
+			}
+		}
+		log.Printf("Renamed the old %s key to %s\n", name, oldName)
+	}
+
+	if err := as.Set(name, newAddr); err != nil {
+		return err
+	}
+	if path, ok := r.Paths[name]; ok {
+		return as.SetDerivationPath(name, path)
+	}
+	return as.DeleteDerivationPath(name)
+}
+
+// keyTransactor returns a transactor for the keystore key of addr, unlocking
+// it with passphrase or prompting for the passphrase with message
+func keyTransactor(cmd *cobra.Command, addr common.Address, passphrase string, message string) (*bind.TransactOpts, error) {
+	if auth, ok := keyringTransactor(addr); ok {
+		setGasTipCapAndNonce(cmd, auth)
+		return auth, nil
+	}
+
+	ks := util.KeyStore()
+	account := accounts.Account{Address: addr}
+
+	if err := ks.Unlock(account, passphrase); err != nil {
+		passphrase, err = askPassphrase(message, addr)
+		if err != nil {
+			return nil, err
+		}
+		if err := ks.Unlock(account, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	if err := ks.Lock(addr); err != nil {
+		return nil, err
+	}
+
+	manager := accounts.NewManager(&accounts.Config{InsecureUnlockAllowed: false}, ks)
+	wallet, err := manager.Find(account)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := walletutils.NewEthWalletTransactor(wallet, &account, passphrase, big.NewInt(chainID))
+	if err != nil {
+		return nil, err
+	}
+	setGasTipCapAndNonce(cmd, auth)
+
+	return auth, nil
+}
+
+// createRotationKeys creates the new keys of a rotation. Keys are derived
+// from the recovery phrase when the wallet has one
+func createRotationKeys(r *keyRotation, roles []util.KeyType, passphrases map[util.KeyType]string) error {
+	ks := util.KeyStore()
+
+	var w *hdWallet
+	var next uint32
+	if util.HasHDWallet(keyStoreDir()) {
+		var err error
+		if w, err = unlockHDWallet(); err != nil {
+			return err
+		}
+		next = nextHDIndex(util.AccountsStore().DerivationPaths())
+	}
+
+	if err := recordKeyStoreNetwork(); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if w != nil {
+			account, path, err := w.importAccount(ks, next, passphrases[role])
+			if err != nil {
+				return err
+			}
+			r.NewKeys[string(role)] = account.Address.Hex()
+			r.Paths[string(role)] = path.String()
+			next++
+		} else {
+			account, err := ks.NewAccount(passphrases[role])
+			if err != nil {
+				return err
+			}
+			r.NewKeys[string(role)] = account.Address.Hex()
+		}
+		log.Printf("New %s key: %s\n", role, r.NewKeys[string(role)])
+	}
+
+	return nil
+}
+
+var rotateKeysCmd = &cobra.Command{
+	Use:   "rotate-keys",
+	Short: "Replace the Agent's owner, operator and requester keys",
+	Long: `Replace the keys of the Agent's roles in one guided workflow. New keys are created, the owner
+and operator keys are funded through the FilForwarder, and the operator, requester and owner
+changes are proposed by the current owner and accepted by the new keys. If the Agent has a
+GLIF Card, it is transferred to the new owner. Each role's key in accounts.toml is replaced
+only once the Agent confirmed the change on chain, the old keys are kept under a new name.
+
+Progress is saved after every step, run the command again to resume an interrupted
+rotation. New keys are derived from the recovery phrase when the wallet has one.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		as := util.AccountsStore()
+
+		// steps are signed by the old and new keys one after the other, a
+		// single nonce can't apply to them. Pending transactions are resumed instead
+		if cmd.Flags().Changed("nonce") {
+			logFatal("--nonce can't be used with rotate-keys, it sends several transactions from different keys")
+		}
+
+		hasCard, err := hasPlusCard()
+		if err != nil {
+			logFatal(err)
+		}
+
+		discard, err := cmd.Flags().GetBool("discard")
+		if err != nil {
+			logFatal(err)
+		}
+
+		r, err := loadKeyRotation(keyRotationPath())
+		if err != nil {
+			logFatal(err)
+		}
+
+		if discard {
+			if r == nil {
+				logFatal("No key rotation in progress")
+			}
+			if err := r.remove(); err != nil {
+				logFatal(err)
+			}
+			log.Printf("Discarded the key rotation started at %s, the new keys remain in the keystore: %v\n", r.StartedAt.Format(time.RFC3339), r.NewKeys)
+			return
+		}
+
+		fundAmount, err := parseFILAmount(cmd.Flag("fund").Value.String())
+		if err != nil {
+			logFatal(err)
+		}
+		fundFrom := cmd.Flag("fund-from").Value.String()
+
+		passphrases := map[util.KeyType]string{}
+
+		if r != nil {
+			if cmd.Flags().Changed("roles") {
+				logFatalf("A rotation of %s is in progress, run again without --roles to resume it or pass --discard", strings.Join(r.Roles, ","))
+			}
+			log.Printf("Resuming the key rotation of %s started at %s\n", strings.Join(r.Roles, ","), r.StartedAt.Format(time.RFC3339))
+		} else {
+			roleFlags, err := cmd.Flags().GetStringSlice("roles")
+			if err != nil {
+				logFatal(err)
+			}
+			roles, err := parseRotationRoles(roleFlags)
+			if err != nil {
+				logFatal(err)
+			}
+
+			agentAddr, err := getAgentAddress()
+			if err != nil {
+				logFatal(err)
+			}
+
+			r = &keyRotation{
+				path:      keyRotationPath(),
+				Agent:     agentAddr.Hex(),
+				StartedAt: time.Now().UTC().Truncate(time.Second),
+				NewKeys:   map[string]string{},
+				Paths:     map[string]string{},
+				Pending:   map[string]string{},
+			}
+			for _, role := range roles {
+				r.Roles = append(r.Roles, string(role))
+			}
+
+			fmt.Printf("Rotating the %s keys of agent %s\n", strings.Join(r.Roles, ", "), r.Agent)
+			for _, step := range planRotation(roles, fundAmount.Sign() > 0, hasCard) {
+				fmt.Printf("  - %s\n", step)
+			}
+
+			yes, err := cmd.Flags().GetBool("yes")
+			if err != nil {
+				logFatal(err)
+			}
+			if !yes {
+				var proceed bool
+				survey.AskOne(&survey.Confirm{Message: "Proceed?"}, &proceed)
+				if !proceed {
+					logFatal("Aborted")
+				}
+			}
+
+			if containsRole(roles, util.OwnerKey) {
+				passphrases[util.OwnerKey], err = getAccountPassphrase("GLIF_NEW_OWNER_PASSPHRASE", "Please type a passphrase to encrypt your new owner key")
+				if err != nil {
+					logFatal(err)
+				}
+			}
+			passphrases[util.OperatorKey] = os.Getenv("GLIF_NEW_OPERATOR_PASSPHRASE")
+
+			if err := createRotationKeys(r, roles, passphrases); err != nil {
+				logFatal(err)
+			}
+			if err := r.save(); err != nil {
+				logFatal(err)
+			}
+
+			util.BackupsStore().Invalidate()
+			log.Println("Back up the new keys before continuing, losing the new owner key means losing your Agent")
+		}
+
+		var roles []util.KeyType
+		for _, role := range r.Roles {
+			roles = append(roles, util.KeyType(role))
+		}
+		agentAddr := common.HexToAddress(r.Agent)
+		newKey := func(role util.KeyType) common.Address {
+			return common.HexToAddress(r.NewKeys[string(role)])
+		}
+
+		ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer ethClient.Close()
+
+		agent, err := abigen.NewAgentCaller(agentAddr, ethClient)
+		if err != nil {
+			logFatal(err)
+		}
+		callOpts := &bind.CallOpts{Context: ctx}
+
+		// signers are unlocked the first time a step needs them
+		var ownerAuth *bind.TransactOpts
+		oldOwner := func() (*bind.TransactOpts, error) {
+			if ownerAuth == nil {
+				_, auth, _, _, err := commonSetupOwnerCall(cmd)
+				if err != nil {
+					return nil, err
+				}
+				ownerAuth = auth
+			}
+			return ownerAuth, nil
+		}
+		newAuths := map[util.KeyType]*bind.TransactOpts{}
+		newSigner := func(role util.KeyType) (*bind.TransactOpts, error) {
+			if newAuths[role] == nil {
+				auth, err := keyTransactor(cmd, newKey(role), passphrases[role], fmt.Sprintf("New %s key passphrase", role))
+				if err != nil {
+					return nil, err
+				}
+				newAuths[role] = auth
+			}
+			return newAuths[role], nil
+		}
+		funder := func() (*bind.TransactOpts, error) {
+			if fundFrom == string(util.OwnerKey) {
+				return oldOwner()
+			}
+			auth, _, err := commonGenericAccountSetup(cmd, fundFrom)
+			return auth, err
+		}
+		swap := func(role util.KeyType) func() error {
+			return func() error {
+				if err := swapRotatedKey(as, r, role); err != nil {
+					return err
+				}
+				return viper.WriteConfig()
+			}
+		}
+		fund := func(role util.KeyType) rotationStep {
+			return rotationStep{
+				name: "fund-" + string(role),
+				role: role,
+				confirmed: func() (bool, error) {
+					delegated, err := util.DelegatedFromEthAddr(newKey(role))
+					if err != nil {
+						return false, err
+					}
+					return isFunded(ctx, delegated)
+				},
+				send: func() (*types.Transaction, error) {
+					auth, err := funder()
+					if err != nil {
+						return nil, err
+					}
+					to, err := util.DelegatedFromEthAddr(newKey(role))
+					if err != nil {
+						return nil, err
+					}
+					log.Printf("Forwarding %0.09f FIL to the new %s key\n", denoms.ToFIL(fundAmount), role)
+					return forwardFILTx(ctx, auth, to, fundAmount)
+				},
+			}
+		}
+
+		steps := map[string]rotationStep{
+			"fund-owner":    fund(util.OwnerKey),
+			"fund-operator": fund(util.OperatorKey),
+			"transfer-operator": {
+				name: "transfer-operator",
+				role: util.OperatorKey,
+				confirmed: func() (bool, error) {
+					return agentRoleIs(callOpts, newKey(util.OperatorKey), agent.PendingOperator, agent.Operator)
+				},
+				send: func() (*types.Transaction, error) {
+					auth, err := oldOwner()
+					if err != nil {
+						return nil, err
+					}
+					return PoolsSDK.Act().AgentTransferOperator(ctx, auth, agentAddr, newKey(util.OperatorKey))
+				},
+			},
+			"accept-operator": {
+				name: "accept-operator",
+				role: util.OperatorKey,
+				confirmed: func() (bool, error) {
+					return agentRoleIs(callOpts, newKey(util.OperatorKey), agent.Operator)
+				},
+				send: func() (*types.Transaction, error) {
+					auth, err := newSigner(util.OperatorKey)
+					if err != nil {
+						return nil, err
+					}
+					return PoolsSDK.Act().AgentAcceptOperator(ctx, auth, agentAddr)
+				},
+				finish: swap(util.OperatorKey),
+			},
+			"change-requester": {
+				name: "change-requester",
+				role: util.RequestKey,
+				confirmed: func() (bool, error) {
+					return agentRoleIs(callOpts, newKey(util.RequestKey), agent.AdoRequestKey)
+				},
+				send: func() (*types.Transaction, error) {
+					auth, err := oldOwner()
+					if err != nil {
+						return nil, err
+					}
+					return PoolsSDK.Act().AgentChangeRequester(ctx, auth, agentAddr, newKey(util.RequestKey))
+				},
+				finish: swap(util.RequestKey),
+			},
+			"transfer-ownership": {
+				name: "transfer-ownership",
+				role: util.OwnerKey,
+				confirmed: func() (bool, error) {
+					return agentRoleIs(callOpts, newKey(util.OwnerKey), agent.PendingOwner, agent.Owner)
+				},
+				send: func() (*types.Transaction, error) {
+					auth, err := oldOwner()
+					if err != nil {
+						return nil, err
+					}
+					return PoolsSDK.Act().AgentTransferOwnership(ctx, auth, agentAddr, newKey(util.OwnerKey))
+				},
+			},
+			"accept-ownership": {
+				name: "accept-ownership",
+				role: util.OwnerKey,
+				confirmed: func() (bool, error) {
+					return agentRoleIs(callOpts, newKey(util.OwnerKey), agent.Owner)
+				},
+				send: func() (*types.Transaction, error) {
+					auth, err := newSigner(util.OwnerKey)
+					if err != nil {
+						return nil, err
+					}
+					return PoolsSDK.Act().AgentAcceptOwnership(ctx, auth, agentAddr)
+				},
+				finish: swap(util.OwnerKey),
+			},
+			"transfer-card-owner": {
+				name: "transfer-card-owner",
+				role: util.OwnerKey,
+				send: func() (*types.Transaction, error) {
+					auth, err := newSigner(util.OwnerKey)
+					if err != nil {
+						return nil, err
+					}
+					return PoolsSDK.Act().SPPlusChangeOwnerForAgent(ctx, auth, agentAddr)
+				},
+			},
+		}
+
+		var plan []rotationStep
+		for _, name := range planRotation(roles, fundAmount.Sign() > 0, hasCard) {
+			plan = append(plan, steps[name])
+		}
+
+		adminevt := journal.RegisterEventType("agent", "admin")
+		defer journal.Close()
+		record := func(step rotationStep, tx common.Hash, err error) {
+			evt := &events.AgentAdmin{
+				Action:          step.name,
+				AgentID:         agentAddr.String(),
+				NewAdminAddress: newKey(step.role).Hex(),
+			}
+			if tx != (common.Hash{}) {
+				evt.Tx = tx.Hex()
+			}
+			if err != nil {
+				evt.Error = err.Error()
+			}
+			journal.RecordEvent(adminevt, func() interface{} { return evt })
+		}
+		wait := func(hash common.Hash) error {
+			_, err := PoolsSDK.Query().StateWaitReceipt(ctx, hash)
+			return err
+		}
+
+		if err := runRotationSteps(r, plan, wait, record); err != nil {
+			logFatalf("Key rotation interrupted: %s. Run glif agent rotate-keys again to resume", err)
+		}
+
+		if err := r.remove(); err != nil {
+			logFatal(err)
+		}
+		util.BackupsStore().Invalidate()
+
+		log.Printf("Rotated the %s keys of agent %s\n", strings.Join(r.Roles, ", "), r.Agent)
+	},
+}
+
+// agentRoleGetter reads the address of one of the agent's roles
+type agentRoleGetter func(opts *bind.CallOpts) (common.Address, error)
+
+// agentRoleIs reports whether any of getters returns addr
+func agentRoleIs(opts *bind.CallOpts, addr common.Address, getters ...agentRoleGetter) (bool, error) {
+	for _, get := range getters {
+		current, err := get(opts)
+		if err != nil {
+			return false, err
+		}
+		if current == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasPlusCard reports whether the agent has minted a GLIF Card
+func hasPlusCard() (bool, error) {
+	_, err := getPlusTokenID()
+	if errors.Is(err, errPlusCardNotMinted) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func init() {
+	agentCmd.AddCommand(rotateKeysCmd)
+	rotateKeysCmd.Flags().StringSlice("roles", []string{"owner", "operator", "requester"}, "roles to rotate the keys of, separated by commas")
+	rotateKeysCmd.Flags().String("fund", "0.1", "FIL to forward to each new owner and operator key for gas, 0 to skip funding")
+	rotateKeysCmd.Flags().String("fund-from", "owner", "account the new keys are funded from")
+	rotateKeysCmd.Flags().Bool("yes", false, "skip the confirmation prompt")
+	rotateKeysCmd.Flags().Bool("discard", false, "discard the rotation in progress, the new keys remain in the keystore")
+}
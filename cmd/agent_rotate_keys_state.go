@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// keyRotation is the progress of agent rotate-keys. It is saved after every
+// step so an interrupted rotation resumes where it stopped
+type keyRotation struct {
+	path string
+
+	Agent     string            `json:"agent"`
+	Roles     []string          `json:"roles"`
+	StartedAt time.Time         `json:"started_at"`
+	NewKeys   map[string]string `json:"new_keys"`
+	Paths     map[string]string `json:"derivation_paths,omitempty"`
+	Pending   map[string]string `json:"pending,omitempty"`
+	Completed []string          `json:"completed"`
+}
+
+func keyRotationPath() string {
+	return filepath.Join(cfgDir, "key-rotation.json")
+}
+
+// loadKeyRotation reads the rotation in progress, returning nil when there is none
+func loadKeyRotation(path string) (*keyRotation, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &keyRotation{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to read key rotation from %s: %w", path, err)
+	}
+	r.path = path
+	if r.Pending == nil {
+		r.Pending = map[string]string{}
+	}
+
+	return r, nil
+}
+
+func (r *keyRotation) save() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0600)
+}
+
+// remove deletes the saved rotation once it is finished or discarded
+func (r *keyRotation) remove() error {
+	err := os.Remove(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (r *keyRotation) isCompleted(step string) bool {
+	for _, s := range r.Completed {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// setPending records the transaction sent for step before waiting for it
+func (r *keyRotation) setPending(step string, tx string) error {
+	r.Pending[step] = tx
+	return r.save()
+}
+
+func (r *keyRotation) complete(step string) error {
+	delete(r.Pending, step)
+	if !r.isCompleted(step) {
+		r.Completed = append(r.Completed, step)
+	}
+	return r.save()
+}
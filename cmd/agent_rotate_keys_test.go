@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glifio/glif/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRotationRoles(t *testing.T) {
+	roles, err := parseRotationRoles([]string{"owner", "Requester", "operator"})
+	assert.NoError(t, err)
+	assert.Equal(t, []util.KeyType{util.OperatorKey, util.RequestKey, util.OwnerKey}, roles)
+
+	roles, err = parseRotationRoles([]string{"request", "owner", "owner"})
+	assert.NoError(t, err)
+	assert.Equal(t, []util.KeyType{util.RequestKey, util.OwnerKey}, roles)
+
+	_, err = parseRotationRoles([]string{"miner"})
+	assert.Error(t, err)
+	_, err = parseRotationRoles(nil)
+	assert.Error(t, err)
+}
+
+func TestPlanRotation(t *testing.T) {
+	all := []util.KeyType{util.OperatorKey, util.RequestKey, util.OwnerKey}
+	assert.Equal(t, []string{
+		"fund-owner",
+		"fund-operator",
+		"transfer-operator",
+		"accept-operator",
+		"change-requester",
+		"transfer-ownership",
+		"accept-ownership",
+		"transfer-card-owner",
+	}, planRotation(all, true, true))
+
+	assert.Equal(t, []string{"change-requester"}, planRotation([]util.KeyType{util.RequestKey}, true, true))
+	assert.Equal(t, []string{"transfer-ownership", "accept-ownership"}, planRotation([]util.KeyType{util.OwnerKey}, false, false))
+}
+
+func TestKeyRotationState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key-rotation.json")
+
+	r, err := loadKeyRotation(path)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	r = &keyRotation{
+		path:      path,
+		Agent:     "0x00000000000000000000000000000000000000aa",
+		Roles:     []string{"owner"},
+		StartedAt: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		NewKeys:   map[string]string{"owner": "0x00000000000000000000000000000000000000bb"},
+		Pending:   map[string]string{},
+	}
+	assert.NoError(t, r.setPending("transfer-ownership", "0x01"))
+	assert.NoError(t, r.complete("fund-owner"))
+
+	loaded, err := loadKeyRotation(path)
+	assert.NoError(t, err)
+	assert.Equal(t, r.Agent, loaded.Agent)
+	assert.True(t, r.StartedAt.Equal(loaded.StartedAt))
+	assert.Equal(t, r.NewKeys, loaded.NewKeys)
+	assert.Equal(t, map[string]string{"transfer-ownership": "0x01"}, loaded.Pending)
+	assert.True(t, loaded.isCompleted("fund-owner"))
+	assert.False(t, loaded.isCompleted("transfer-ownership"))
+
+	assert.NoError(t, loaded.remove())
+	r, err = loadKeyRotation(path)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+}
+
+func TestRunRotationSteps(t *testing.T) {
+	r := &keyRotation{
+		path:    filepath.Join(t.TempDir(), "key-rotation.json"),
+		Pending: map[string]string{},
+	}
+
+	var sentTxs, waited, finished []string
+	tx := func(nonce uint64) *types.Transaction {
+		return types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(1)})
+	}
+	step := func(name string, onChain bool, nonce uint64, fail bool) rotationStep {
+		return rotationStep{
+			name:      name,
+			confirmed: func() (bool, error) { return onChain, nil },
+			send: func() (*types.Transaction, error) {
+				if fail {
+					return nil, errors.New("rejected")
+				}
+				sentTxs = append(sentTxs, name)
+				return tx(nonce), nil
+			},
+			finish: func() error {
+				finished = append(finished, name)
+				return nil
+			},
+		}
+	}
+	wait := func(hash common.Hash) error {
+		waited = append(waited, hash.Hex())
+		return nil
+	}
+	var recorded []string
+	record := func(step rotationStep, _ common.Hash, err error) {
+		recorded = append(recorded, step.name)
+	}
+
+	// interrupted after the transfer was sent but before it confirmed
+	assert.NoError(t, r.complete("fund-owner"))
+	assert.NoError(t, r.setPending("transfer-ownership", tx(1).Hash().Hex()))
+
+	steps := []rotationStep{
+		step("fund-owner", false, 0, false),
+		step("transfer-ownership", false, 1, false),
+		step("accept-ownership", true, 2, false),
+		step("transfer-card-owner", false, 3, true),
+	}
+	err := runRotationSteps(r, steps, wait, record)
+	assert.ErrorContains(t, err, "transfer-card-owner: rejected")
+
+	// the pending transfer was waited for, not sent again, and the accept
+	// already on chain was not sent but still finished
+	assert.Empty(t, sentTxs)
+	assert.Equal(t, []string{tx(1).Hash().Hex()}, waited)
+	assert.Equal(t, []string{"transfer-ownership", "accept-ownership"}, finished)
+	assert.Equal(t, []string{"transfer-card-owner"}, recorded)
+	assert.Equal(t, []string{"fund-owner", "transfer-ownership", "accept-ownership"}, r.Completed)
+	assert.Empty(t, r.Pending)
+
+	// resuming only runs the failed step
+	steps[3] = step("transfer-card-owner", false, 3, false)
+	assert.NoError(t, runRotationSteps(r, steps, wait, record))
+	assert.Equal(t, []string{"transfer-card-owner"}, sentTxs)
+	assert.Equal(t, []string{tx(1).Hash().Hex(), tx(3).Hash().Hex()}, waited)
+	assert.True(t, r.isCompleted("transfer-card-owner"))
+}
+
+func TestSwapRotatedKey(t *testing.T) {
+	assert.NoError(t, util.NewAccountsStore(filepath.Join(t.TempDir(), "accounts.toml")))
+	as := util.AccountsStore()
+	assert.NoError(t, as.Set("owner", "0x00000000000000000000000000000000000000aa"))
+	assert.NoError(t, as.SetDerivationPath("owner", "m/44'/60'/0'/0/0"))
+	assert.NoError(t, as.Set("operator", "0x00000000000000000000000000000000000000cc"))
+	assert.NoError(t, as.SetDerivationPath("operator", "m/44'/60'/0'/0/1"))
+
+	r := &keyRotation{
+		StartedAt: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		NewKeys: map[string]string{
+			"owner":    "0x00000000000000000000000000000000000000bb",
+			"operator": "0x00000000000000000000000000000000000000dd",
+		},
+		Paths: map[string]string{"owner": "m/44'/60'/0'/0/3"},
+	}
+
+	assert.NoError(t, swapRotatedKey(as, r, util.OwnerKey))
+	// swapping twice keeps the renamed old key
+	assert.NoError(t, swapRotatedKey(as, r, util.OwnerKey))
+
+	owner, _ := as.Get("owner")
+	assert.Equal(t, r.NewKeys["owner"], owner)
+	path, _ := as.DerivationPath("owner")
+	assert.Equal(t, "m/44'/60'/0'/0/3", path)
+	old, _ := as.Get("owner-2026-10-19T12:00:00Z")
+	assert.Equal(t, "0x00000000000000000000000000000000000000aa", old)
+	path, _ = as.DerivationPath("owner-2026-10-19T12:00:00Z")
+	assert.Equal(t, "m/44'/60'/0'/0/0", path)
+
+	// a random new key drops the old key's derivation path
+	assert.NoError(t, swapRotatedKey(as, r, util.OperatorKey))
+	_, ok := as.DerivationPath("operator")
+	assert.False(t, ok)
+}
@@ -0,0 +1,517 @@
+/*
+Copyright © 2025 Glif LTD
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// autopilot sleeps 30 minutes between checks, so a payment can never be
+// made more often than this
+const autopilotSleepEpochs = 30 * constants.EpochsInMinute
+
+type scheduleEntryKind string
+
+const (
+	scheduleAccrual scheduleEntryKind = "accrual"
+	schedulePayment scheduleEntryKind = "payment"
+)
+
+type scheduleParams struct {
+	principal   *big.Int
+	rate        *big.Int
+	epochsPaid  *big.Int
+	chainHead   *big.Int
+	frequency   float64
+	paymentType PaymentType
+	// amount is the autopilot amount in attoFIL, used by principal and custom payments
+	amount *big.Int
+	// cashBackPercent is the GLIF Card personal cash back percent in basis points
+	cashBackPercent *big.Int
+	days            int
+}
+
+type scheduleEntry struct {
+	Kind               scheduleEntryKind
+	Epoch              *big.Int
+	Time               time.Time
+	Interest           *big.Int
+	Principal          *big.Int
+	Total              *big.Int
+	CashBack           *big.Int
+	PrincipalRemaining *big.Int
+}
+
+// interestForEpochs returns the interest accrued on principal over epochs at
+// the per-epoch rate returned by the infinity pool (1e36 precision)
+func interestForEpochs(principal, rate, epochs *big.Int) *big.Int {
+	interest := new(big.Int).Mul(principal, rate)
+	interest.Mul(interest, epochs)
+	interest.Div(interest, constants.WAD)
+	interest.Div(interest, constants.WAD)
+	return interest
+}
+
+func cashBackFor(interest, cashBackPercent *big.Int) *big.Int {
+	if cashBackPercent == nil {
+		return big.NewInt(0)
+	}
+	cashBack := new(big.Int).Mul(interest, cashBackPercent)
+	return cashBack.Div(cashBack, big.NewInt(10000))
+}
+
+func bigMin(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) > 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
+
+// projectSchedule simulates the autopilot payment loop over the next
+// p.days days, returning one accrual entry per day and one payment entry
+// for every payment autopilot would make, in chronological order
+func projectSchedule(p scheduleParams) []scheduleEntry {
+	entries := []scheduleEntry{}
+
+	principal := new(big.Int).Set(p.principal)
+	if principal.Sign() == 0 {
+		return entries
+	}
+
+	epochsInDay := big.NewInt(constants.EpochsInDay)
+	freqEpochs := big.NewInt(int64(p.frequency * constants.EpochsInDay))
+	minGap := big.NewInt(autopilotSleepEpochs)
+
+	paid := new(big.Int).Set(p.epochsPaid)
+	end := new(big.Int).Add(p.chainHead, new(big.Int).Mul(big.NewInt(int64(p.days)), epochsInDay))
+
+	mark := new(big.Int).Set(p.chainHead)
+	nextDay := new(big.Int).Add(p.chainHead, epochsInDay)
+	nextPay := bigMax(new(big.Int).Add(paid, freqEpochs), p.chainHead)
+	dayAccrued := big.NewInt(0)
+
+	for nextDay.Cmp(end) <= 0 {
+		if nextPay.Cmp(nextDay) <= 0 && nextPay.Cmp(end) <= 0 {
+			dayAccrued.Add(dayAccrued, interestForEpochs(principal, p.rate, new(big.Int).Sub(nextPay, mark)))
+			mark.Set(nextPay)
+
+			owed := interestForEpochs(principal, p.rate, new(big.Int).Sub(nextPay, paid))
+			interest := owed
+			principalPaid := big.NewInt(0)
+
+			switch p.paymentType {
+			case ToCurrent:
+				paid.Set(nextPay)
+			case Principal:
+				principalPaid = bigMin(p.amount, principal)
+				paid.Set(nextPay)
+			case Custom:
+				if p.amount.Cmp(owed) >= 0 {
+					principalPaid = bigMin(new(big.Int).Sub(p.amount, owed), principal)
+					paid.Set(nextPay)
+				} else {
+					// a partial payment only pays for part of the epochs owed
+					interest = new(big.Int).Set(p.amount)
+					perEpoch := new(big.Int).Mul(principal, p.rate)
+					if perEpoch.Sign() == 0 {
+						paid.Set(nextPay)
+					} else {
+						covered := new(big.Int).Mul(p.amount, constants.WAD)
+						covered.Mul(covered, constants.WAD)
+						covered.Div(covered, perEpoch)
+						paid.Add(paid, covered)
+					}
+				}
+			}
+
+			principal.Sub(principal, principalPaid)
+
+			entries = append(entries, scheduleEntry{
+				Kind:               schedulePayment,
+				Epoch:              new(big.Int).Set(nextPay),
+				Interest:           interest,
+				Principal:          principalPaid,
+				Total:              new(big.Int).Add(interest, principalPaid),
+				CashBack:           cashBackFor(interest, p.cashBackPercent),
+				PrincipalRemaining: new(big.Int).Set(principal),
+			})
+
+			if principal.Sign() == 0 {
+				break
+			}
+
+			nextPay = bigMax(new(big.Int).Add(paid, freqEpochs), new(big.Int).Add(nextPay, minGap))
+			continue
+		}
+
+		dayAccrued.Add(dayAccrued, interestForEpochs(principal, p.rate, new(big.Int).Sub(nextDay, mark)))
+		mark.Set(nextDay)
+
+		entries = append(entries, scheduleEntry{
+			Kind:               scheduleAccrual,
+			Epoch:              new(big.Int).Set(nextDay),
+			Interest:           dayAccrued,
+			Principal:          big.NewInt(0),
+			Total:              big.NewInt(0),
+			CashBack:           big.NewInt(0),
+			PrincipalRemaining: new(big.Int).Set(principal),
+		})
+
+		dayAccrued = big.NewInt(0)
+		nextDay = new(big.Int).Add(nextDay, epochsInDay)
+	}
+
+	return entries
+}
+
+func writeScheduleCSV(w io.Writer, entries []scheduleEntry) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"date", "epoch", "type", "interest_fil", "principal_fil", "total_fil", "cash_back_fil", "principal_remaining_fil"})
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		err := cw.Write([]string{
+			e.Time.UTC().Format(time.RFC3339),
+			e.Epoch.String(),
+			string(e.Kind),
+			formatFILAmount(e.Interest),
+			formatFILAmount(e.Principal),
+			formatFILAmount(e.Total),
+			formatFILAmount(e.CashBack),
+			formatFILAmount(e.PrincipalRemaining),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeScheduleICal writes one calendar event per payment, following RFC 5545
+func writeScheduleICal(w io.Writer, agent string, entries []scheduleEntry, now time.Time) error {
+	const icalTime = "20060102T150405Z"
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Glif LTD//glif cli//EN",
+		"CALSCALE:GREGORIAN",
+	}
+	for _, e := range entries {
+		if e.Kind != schedulePayment {
+			continue
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%s-%s@glif.io", strings.ToLower(agent), e.Epoch),
+			fmt.Sprintf("DTSTAMP:%s", now.UTC().Format(icalTime)),
+			fmt.Sprintf("DTSTART:%s", e.Time.UTC().Format(icalTime)),
+			fmt.Sprintf("DTEND:%s", e.Time.UTC().Add(30*time.Minute).Format(icalTime)),
+			fmt.Sprintf("SUMMARY:GLIF Agent payment %.06f FIL", util.ToFIL(e.Total)),
+			fmt.Sprintf("DESCRIPTION:Epoch %s\\nInterest: %.09f FIL\\nPrincipal: %.09f FIL\\nCash back: %.09f FIL\\nPrincipal remaining: %.09f FIL",
+				e.Epoch, util.ToFIL(e.Interest), util.ToFIL(e.Principal), util.ToFIL(e.CashBack), util.ToFIL(e.PrincipalRemaining)),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
+
+var agentScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Project interest accrual and upcoming autopilot payments",
+	Long: `Project interest accrual per day based on the current rate and principal, and the
+payments autopilot would make under the configured payment-type, amount and frequency.
+If the Agent has an active GLIF Card, the projected cash back is included.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		days, err := cmd.Flags().GetInt("days")
+		if err != nil {
+			logFatal(err)
+		}
+		if days <= 0 {
+			logFatal("days must be greater than 0")
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			logFatal(err)
+		}
+		if format != "table" && format != "csv" && format != "ical" {
+			logFatalf("invalid format %s, must be one of: table, csv, ical", format)
+		}
+
+		paymentType, err := ParsePaymentType(viper.GetString("autopilot.payment-type"))
+		if err != nil {
+			logFatal(err)
+		}
+		frequency := viper.GetFloat64("autopilot.frequency")
+		if frequency <= 0 {
+			logFatal("autopilot.frequency must be greater than 0")
+		}
+		amount := new(big.Int).Mul(big.NewInt(viper.GetInt64("autopilot.amount")), constants.WAD)
+
+		agentAddr, err := getAgentAddressWithFlags(cmd)
+		if err != nil {
+			logFatal(err)
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		query := PoolsSDK.Query()
+
+		agentID, err := query.AgentID(ctx, agentAddr)
+		if err != nil {
+			logFatal(err)
+		}
+
+		tasks := []util.TaskFunc{
+			func() (interface{}, error) {
+				return query.InfPoolGetAccount(ctx, agentAddr, nil)
+			},
+			func() (interface{}, error) {
+				return query.InfPoolGetRate(ctx)
+			},
+			func() (interface{}, error) {
+				return query.ChainHeight(ctx)
+			},
+			func() (interface{}, error) {
+				return query.SPPlusAgentIdToTokenId(ctx, agentID, nil)
+			},
+		}
+		results, err := util.Multiread(tasks)
+		if err != nil {
+			logFatal(err)
+		}
+
+		account := results[0].(abigen.Account)
+		rate := results[1].(*big.Int)
+		chainHead := results[2].(*big.Int)
+		tokenID := results[3].(*big.Int)
+
+		cashBackPercent := big.NewInt(0)
+		var tier uint8
+		var glfPerFIL, glfVault *big.Int
+		if tokenID.Sign() > 0 {
+			info, err := query.SPPlusInfo(ctx, tokenID, nil)
+			if err != nil {
+				logFatal(err)
+			}
+			tier = info.Tier
+			if tier > 0 {
+				tierInfos, err := query.SPPlusTierInfo(ctx, nil)
+				if err != nil {
+					logFatal(err)
+				}
+				cashBackPercent = info.PersonalCashBackPercent
+				glfVault = info.GLFVaultBalance
+				glfPerFIL = info.BaseConversionRateFILtoGLF
+				if int(tier) < len(tierInfos) {
+					glfPerFIL = util.MulWad(info.BaseConversionRateFILtoGLF, tierInfos[tier].CashBackPremium)
+				}
+			}
+		}
+
+		entries := projectSchedule(scheduleParams{
+			principal:       account.Principal,
+			rate:            rate,
+			epochsPaid:      account.EpochsPaid,
+			chainHead:       chainHead,
+			frequency:       frequency,
+			paymentType:     paymentType,
+			amount:          amount,
+			cashBackPercent: cashBackPercent,
+			days:            days,
+		})
+		for i := range entries {
+			entries[i].Time = util.EpochHeightToTimestamp(entries[i].Epoch, query.ChainID())
+		}
+
+		s.Stop()
+
+		var out io.Writer = os.Stdout
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logFatal(err)
+		}
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				logFatal(err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		switch format {
+		case "csv":
+			if err := writeScheduleCSV(out, entries); err != nil {
+				logFatal(err)
+			}
+		case "ical":
+			if err := writeScheduleICal(out, agentAddr.String(), entries, time.Now()); err != nil {
+				logFatal(err)
+			}
+		default:
+			printSchedule(account.Principal, rate, paymentType, frequency, tier, cashBackPercent, glfPerFIL, glfVault, entries)
+		}
+
+		if output != "" {
+			fmt.Printf("Schedule written to %s\n", output)
+		}
+	},
+}
+
+func printSchedule(
+	principal *big.Int,
+	rate *big.Int,
+	paymentType PaymentType,
+	frequency float64,
+	tier uint8,
+	cashBackPercent *big.Int,
+	glfPerFIL *big.Int,
+	glfVault *big.Int,
+	entries []scheduleEntry,
+) {
+	apr := new(big.Float).Mul(new(big.Float).SetInt(rate), big.NewFloat(constants.EpochsInYear))
+	apr.Quo(apr, big.NewFloat(1e34))
+
+	generateHeader("SCHEDULE INPUTS")
+	printTable([]string{
+		"Principal",
+		"APR",
+		"Daily interest",
+		"Payment type",
+		"Payment frequency",
+	}, []string{
+		fmt.Sprintf("%0.09f FIL", util.ToFIL(principal)),
+		fmt.Sprintf("%.02f%%", apr),
+		fmt.Sprintf("%0.09f FIL", util.ToFIL(interestForEpochs(principal, rate, big.NewInt(constants.EpochsInDay)))),
+		paymentType.String(),
+		fmt.Sprintf("%v days", frequency),
+	})
+
+	if len(entries) == 0 {
+		fmt.Println()
+		fmt.Println("Agent has no outstanding principal, nothing to project")
+		return
+	}
+
+	generateHeader("INTEREST ACCRUAL")
+	tbl := table.New("Date", "Epoch", "Accrued", "Principal")
+	for _, e := range entries {
+		if e.Kind != scheduleAccrual {
+			continue
+		}
+		tbl.AddRow(
+			e.Time.UTC().Format("2006-01-02"),
+			e.Epoch,
+			fmt.Sprintf("%0.09f FIL", util.ToFIL(e.Interest)),
+			fmt.Sprintf("%0.09f FIL", util.ToFIL(e.PrincipalRemaining)),
+		)
+	}
+	tbl.Print()
+
+	totalInterest := big.NewInt(0)
+	totalPaid := big.NewInt(0)
+	totalCashBack := big.NewInt(0)
+
+	generateHeader("PAYMENTS")
+	tbl = table.New("Date", "Epoch", "Interest", "Principal", "Total", "Cash back")
+	for _, e := range entries {
+		if e.Kind != schedulePayment {
+			continue
+		}
+		totalInterest.Add(totalInterest, e.Interest)
+		totalPaid.Add(totalPaid, e.Total)
+		totalCashBack.Add(totalCashBack, e.CashBack)
+		tbl.AddRow(
+			e.Time.UTC().Format("2006-01-02 15:04"),
+			e.Epoch,
+			fmt.Sprintf("%0.09f FIL", util.ToFIL(e.Interest)),
+			fmt.Sprintf("%0.09f FIL", util.ToFIL(e.Principal)),
+			fmt.Sprintf("%0.09f FIL", util.ToFIL(e.Total)),
+			fmt.Sprintf("%0.09f FIL", util.ToFIL(e.CashBack)),
+		)
+	}
+	tbl.Print()
+
+	generateHeader("TOTALS")
+	printTable([]string{
+		"Total paid",
+		"Interest paid",
+		"Cash back",
+		"Net interest cost",
+	}, []string{
+		fmt.Sprintf("%0.09f FIL", util.ToFIL(totalPaid)),
+		fmt.Sprintf("%0.09f FIL", util.ToFIL(totalInterest)),
+		fmt.Sprintf("%0.09f FIL", util.ToFIL(totalCashBack)),
+		fmt.Sprintf("%0.09f FIL", util.ToFIL(new(big.Int).Sub(totalInterest, totalCashBack))),
+	})
+
+	if tier == 0 {
+		fmt.Println()
+		fmt.Println("No active GLIF Card, cash back not included")
+		return
+	}
+
+	glfRequired := util.MulWad(totalCashBack, glfPerFIL)
+	cashBackBasis, _ := cashBackPercent.Float64()
+
+	printTable([]string{
+		"GLIF Card",
+		"Tier",
+		"Cash back percentage",
+		"GLF required for cash back",
+		"GLF vault balance",
+	}, []string{
+		"",
+		tierName(tier),
+		fmt.Sprintf("%.02f%%", cashBackBasis/100.00),
+		fmt.Sprintf("%0.09f GLF", util.ToFIL(glfRequired)),
+		fmt.Sprintf("%0.09f GLF", util.ToFIL(glfVault)),
+	})
+
+	if glfVault.Cmp(glfRequired) < 0 {
+		fmt.Println()
+		fmt.Println("GLF vault balance does not cover the projected cash back, fund it with: glif plus cashback fund <amount>")
+	}
+}
+
+func init() {
+	agentCmd.AddCommand(agentScheduleCmd)
+	agentScheduleCmd.Flags().String("agent-addr", "", "Agent address")
+	agentScheduleCmd.Flags().Int("days", 30, "Number of days to project")
+	agentScheduleCmd.Flags().String("format", "table", "Output format: table, csv or ical")
+	agentScheduleCmd.Flags().String("output", "", "Write the schedule to a file instead of stdout")
+}
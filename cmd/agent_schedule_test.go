@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glifio/go-pools/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+// 1e34 per epoch is ~10.5% APR
+var testRate = new(big.Int).Mul(big.NewInt(1e17), big.NewInt(1e17))
+
+func countKind(entries []scheduleEntry, kind scheduleEntryKind) int {
+	n := 0
+	for _, e := range entries {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func TestProjectScheduleToCurrent(t *testing.T) {
+	principal := new(big.Int).Mul(big.NewInt(100), constants.WAD)
+	head := big.NewInt(1000000)
+
+	entries := projectSchedule(scheduleParams{
+		principal:   principal,
+		rate:        testRate,
+		epochsPaid:  head,
+		chainHead:   head,
+		frequency:   5,
+		paymentType: ToCurrent,
+		amount:      big.NewInt(0),
+		days:        30,
+	})
+
+	assert.Equal(t, 30, countKind(entries, scheduleAccrual))
+	assert.Equal(t, 6, countKind(entries, schedulePayment))
+
+	daily := interestForEpochs(principal, testRate, big.NewInt(constants.EpochsInDay))
+	fiveDays := interestForEpochs(principal, testRate, big.NewInt(5*constants.EpochsInDay))
+	for _, e := range entries {
+		assert.Equal(t, 0, e.PrincipalRemaining.Cmp(principal))
+		switch e.Kind {
+		case scheduleAccrual:
+			assert.Equal(t, 0, e.Interest.Cmp(daily), "accrual %s != %s", e.Interest, daily)
+		case schedulePayment:
+			assert.Equal(t, 0, e.Interest.Cmp(fiveDays), "payment %s != %s", e.Interest, fiveDays)
+			assert.Equal(t, 0, e.Principal.Sign())
+		}
+	}
+}
+
+func TestProjectSchedulePaymentDueNow(t *testing.T) {
+	principal := new(big.Int).Mul(big.NewInt(100), constants.WAD)
+	head := big.NewInt(1000000)
+	paid := new(big.Int).Sub(head, big.NewInt(10*constants.EpochsInDay))
+
+	entries := projectSchedule(scheduleParams{
+		principal:   principal,
+		rate:        testRate,
+		epochsPaid:  paid,
+		chainHead:   head,
+		frequency:   5,
+		paymentType: ToCurrent,
+		amount:      big.NewInt(0),
+		days:        1,
+	})
+
+	assert.Equal(t, schedulePayment, entries[0].Kind)
+	assert.Equal(t, 0, entries[0].Epoch.Cmp(head))
+	owed := interestForEpochs(principal, testRate, big.NewInt(10*constants.EpochsInDay))
+	assert.Equal(t, 0, entries[0].Interest.Cmp(owed))
+}
+
+func TestProjectSchedulePrincipalPaysOff(t *testing.T) {
+	principal := new(big.Int).Mul(big.NewInt(10), constants.WAD)
+	amount := new(big.Int).Mul(big.NewInt(4), constants.WAD)
+	head := big.NewInt(1000000)
+
+	entries := projectSchedule(scheduleParams{
+		principal:   principal,
+		rate:        testRate,
+		epochsPaid:  head,
+		chainHead:   head,
+		frequency:   1,
+		paymentType: Principal,
+		amount:      amount,
+		days:        30,
+	})
+
+	assert.Equal(t, 3, countKind(entries, schedulePayment))
+	last := entries[len(entries)-1]
+	assert.Equal(t, schedulePayment, last.Kind)
+	assert.Equal(t, 0, last.PrincipalRemaining.Sign())
+	assert.Equal(t, 0, last.Principal.Cmp(new(big.Int).Mul(big.NewInt(2), constants.WAD)))
+}
+
+func TestProjectScheduleCustomPartialPayment(t *testing.T) {
+	principal := new(big.Int).Mul(big.NewInt(100), constants.WAD)
+	head := big.NewInt(1000000)
+	daily := interestForEpochs(principal, testRate, big.NewInt(constants.EpochsInDay))
+
+	// paying half a day of interest every day never catches up, but every
+	// payment must be exactly the custom amount
+	amount := new(big.Int).Div(daily, big.NewInt(2))
+
+	entries := projectSchedule(scheduleParams{
+		principal:   principal,
+		rate:        testRate,
+		epochsPaid:  head,
+		chainHead:   head,
+		frequency:   1,
+		paymentType: Custom,
+		amount:      amount,
+		days:        3,
+	})
+
+	assert.Greater(t, countKind(entries, schedulePayment), 0)
+	for _, e := range entries {
+		if e.Kind == schedulePayment {
+			assert.Equal(t, 0, e.Total.Cmp(amount))
+			assert.Equal(t, 0, e.Principal.Sign())
+		}
+	}
+}
+
+func TestProjectScheduleCashBack(t *testing.T) {
+	principal := new(big.Int).Mul(big.NewInt(100), constants.WAD)
+	head := big.NewInt(1000000)
+
+	entries := projectSchedule(scheduleParams{
+		principal:       principal,
+		rate:            testRate,
+		epochsPaid:      head,
+		chainHead:       head,
+		frequency:       5,
+		paymentType:     ToCurrent,
+		amount:          big.NewInt(0),
+		cashBackPercent: big.NewInt(1000),
+		days:            5,
+	})
+
+	for _, e := range entries {
+		if e.Kind == schedulePayment {
+			expected := new(big.Int).Div(e.Interest, big.NewInt(10))
+			assert.Equal(t, 0, e.CashBack.Cmp(expected))
+		}
+	}
+}
+
+func TestProjectScheduleNoPrincipal(t *testing.T) {
+	entries := projectSchedule(scheduleParams{
+		principal:   big.NewInt(0),
+		rate:        testRate,
+		epochsPaid:  big.NewInt(0),
+		chainHead:   big.NewInt(100),
+		frequency:   5,
+		paymentType: ToCurrent,
+		amount:      big.NewInt(0),
+		days:        30,
+	})
+
+	assert.Empty(t, entries)
+}
+
+func TestWriteScheduleICal(t *testing.T) {
+	entries := []scheduleEntry{
+		{
+			Kind:               scheduleAccrual,
+			Epoch:              big.NewInt(100),
+			Time:               time.Unix(0, 0),
+			Interest:           big.NewInt(1),
+			Principal:          big.NewInt(0),
+			Total:              big.NewInt(0),
+			CashBack:           big.NewInt(0),
+			PrincipalRemaining: big.NewInt(0),
+		},
+		{
+			Kind:               schedulePayment,
+			Epoch:              big.NewInt(200),
+			Time:               time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Interest:           big.NewInt(1e18),
+			Principal:          big.NewInt(0),
+			Total:              big.NewInt(1e18),
+			CashBack:           big.NewInt(0),
+			PrincipalRemaining: big.NewInt(0),
+		},
+	}
+
+	var buf bytes.Buffer
+	err := writeScheduleICal(&buf, "0xABC", entries, time.Unix(0, 0))
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 1, strings.Count(out, "BEGIN:VEVENT"))
+	assert.Contains(t, out, "DTSTART:20250102T030405Z")
+	assert.Contains(t, out, "UID:0xabc-200@glif.io")
+}
+
+func TestFormatFILAmount(t *testing.T) {
+	assert.Equal(t, "1.000000000000000000", formatFILAmount(big.NewInt(1e18)))
+	assert.Equal(t, "0.000000000000000001", formatFILAmount(big.NewInt(1)))
+	assert.Equal(t, "-1.500000000000000000", formatFILAmount(big.NewInt(-15e17)))
+}
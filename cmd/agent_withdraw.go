@@ -4,19 +4,27 @@ Copyright © 2023 Glif LTD
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/go-pools/econ"
 	"github.com/spf13/cobra"
 )
 
 var withdrawCmd = &cobra.Command{
 	Use:   "withdraw <amount> <receiver>",
 	Short: "Withdraw FIL from your Agent.",
-	Long:  "",
-	Args:  cobra.ExactArgs(2),
+	Long: `Withdraw FIL from your Agent.
+
+Withdrawals are checked against the rules in the [withdraw.policy] section of the config
+before they are signed. A withdrawal that violates the policy is blocked unless
+--override-policy is passed. Every policy decision is recorded in the journal.`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		agentAddr, auth, _, requesterKey, err := commonSetupOwnerCall(cmd)
 		if err != nil {
@@ -27,23 +35,35 @@ var withdrawCmd = &cobra.Command{
 		if err != nil {
 			logFatal(err)
 		}
+		warnUnknownRecipient(args[1])
 
 		amount, err := parseFILAmount(args[0])
 		if err != nil {
 			logFatal(err)
 		}
 
+		override, err := cmd.Flags().GetBool("override-policy")
+		if err != nil {
+			logFatal(err)
+		}
+
 		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 		s.Start()
 		defer s.Stop()
 
+		defer journal.Close()
+
+		err = enforceWithdrawPolicy(cmd.Context(), agentAddr, receiver, amount, override, s)
+		if err != nil {
+			logFatal(err)
+		}
+
 		withdrawevt := journal.RegisterEventType("agent", "withdraw")
 		evt := &events.AgentWithdraw{
 			AgentID: agentAddr.String(),
 			Amount:  amount.String(),
 			To:      receiver.String(),
 		}
-		defer journal.Close()
 		defer journal.RecordEvent(withdrawevt, func() interface{} { return evt })
 
 		fmt.Printf("Withdrawing %s FIL from your Agent", args[0])
@@ -67,6 +87,80 @@ var withdrawCmd = &cobra.Command{
 	},
 }
 
+// enforceWithdrawPolicy checks the withdrawal against the configured policy
+// and records the decision in the journal. It returns an error when the
+// withdrawal is blocked
+func enforceWithdrawPolicy(ctx context.Context, agentAddr common.Address, receiver common.Address, amount *big.Int, override bool, s *spinner.Spinner) error {
+	policy, err := loadWithdrawPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if policy.Empty() {
+		return nil
+	}
+
+	req := withdrawRequest{
+		Amount:   amount,
+		Receiver: receiver,
+	}
+
+	if policy.MaxAmount24h != nil {
+		evts, err := withdrawalHistory(journal)
+		if err != nil {
+			return err
+		}
+		req.Withdrawn24h = withdrawnSince(evts, agentAddr, time.Now().Add(-24*time.Hour))
+	}
+
+	req.LiquidAssets, err = PoolsSDK.Query().AgentLiquidAssets(ctx, agentAddr, nil)
+	if err != nil {
+		return err
+	}
+
+	if policy.MaxDTL != nil {
+		afi, err := econ.GetAgentFiFromAPI(agentAddr, PoolsSDK.Extern().GetEventsURL())
+		if err != nil {
+			return err
+		}
+		req.Debt = afi.Debt()
+		req.LiquidationValue = afi.LiquidationValue()
+	}
+
+	violations := policy.Check(req)
+
+	policyevt := journal.RegisterEventType("agent", "withdraw-policy")
+	evt := &events.AgentWithdrawPolicy{
+		AgentID:    agentAddr.String(),
+		Amount:     amount.String(),
+		To:         receiver.String(),
+		Decision:   withdrawAllowed,
+		Violations: violations,
+	}
+	defer journal.RecordEvent(policyevt, func() interface{} { return evt })
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	s.Stop()
+
+	fmt.Println("Withdrawal violates the withdraw policy:")
+	for _, v := range violations {
+		fmt.Printf("  - %s\n", v)
+	}
+
+	if !override {
+		evt.Decision = withdrawBlocked
+		return fmt.Errorf("withdrawal blocked by policy, re-run with --override-policy to withdraw anyway")
+	}
+
+	evt.Decision = withdrawOverridden
+	fmt.Println("Overriding the withdraw policy, signing with the owner key")
+	s.Start()
+	return nil
+}
+
 func init() {
 	agentCmd.AddCommand(withdrawCmd)
+	withdrawCmd.Flags().Bool("override-policy", false, "withdraw even when the withdraw policy is violated, signed by the owner key")
 }
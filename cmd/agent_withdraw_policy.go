@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	jnal "github.com/glifio/glif/v2/journal"
+	"github.com/glifio/go-pools/util"
+	"github.com/spf13/viper"
+)
+
+const (
+	withdrawAllowed    = "allowed"
+	withdrawBlocked    = "blocked"
+	withdrawOverridden = "overridden"
+)
+
+// withdrawPolicy holds the rules from the [withdraw.policy] config section
+// that every withdrawal is checked against before it is signed. Nil or empty
+// fields are not enforced
+type withdrawPolicy struct {
+	AllowedReceivers []common.Address
+	MaxAmount        *big.Int
+	MaxAmount24h     *big.Int
+	MinLiquidBalance *big.Int
+	// MaxDTL is the maximum debt-to-liquidation value after the withdrawal, in wad
+	MaxDTL *big.Int
+}
+
+// withdrawRequest is the state a withdrawal is evaluated against
+type withdrawRequest struct {
+	Amount           *big.Int
+	Receiver         common.Address
+	Withdrawn24h     *big.Int
+	LiquidAssets     *big.Int
+	Debt             *big.Int
+	LiquidationValue *big.Int
+}
+
+func loadWithdrawPolicy(ctx context.Context) (*withdrawPolicy, error) {
+	p := &withdrawPolicy{}
+
+	for _, r := range viper.GetStringSlice("withdraw.policy.allowed-receivers") {
+		addr, err := AddressOrAccountNameToEVM(ctx, r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed receiver %s: %w", r, err)
+		}
+		p.AllowedReceivers = append(p.AllowedReceivers, addr)
+	}
+
+	amounts := []struct {
+		key string
+		dst **big.Int
+	}{
+		{"withdraw.policy.max-amount", &p.MaxAmount},
+		{"withdraw.policy.max-amount-24h", &p.MaxAmount24h},
+		{"withdraw.policy.min-liquid-balance", &p.MinLiquidBalance},
+	}
+	for _, a := range amounts {
+		v := viper.GetString(a.key)
+		if v == "" || v == "0" {
+			continue
+		}
+		amt, err := parseFILAmount(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", a.key, err)
+		}
+		*a.dst = amt
+	}
+
+	if maxDTL := viper.GetFloat64("withdraw.policy.max-dtl"); maxDTL > 0 {
+		if maxDTL >= 1 {
+			return nil, fmt.Errorf("invalid withdraw.policy.max-dtl %v, must be between 0 and 1", maxDTL)
+		}
+		p.MaxDTL, _ = new(big.Float).Mul(big.NewFloat(maxDTL), big.NewFloat(1e18)).Int(nil)
+	}
+
+	return p, nil
+}
+
+// Empty returns true when the policy has no rules configured
+func (p *withdrawPolicy) Empty() bool {
+	return len(p.AllowedReceivers) == 0 &&
+		p.MaxAmount == nil &&
+		p.MaxAmount24h == nil &&
+		p.MinLiquidBalance == nil &&
+		p.MaxDTL == nil
+}
+
+// Check returns a description of every rule the request violates
+func (p *withdrawPolicy) Check(req withdrawRequest) []string {
+	var violations []string
+
+	if len(p.AllowedReceivers) > 0 {
+		allowed := false
+		for _, r := range p.AllowedReceivers {
+			if r == req.Receiver {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, fmt.Sprintf("receiver %s is not in the allowed receivers list", req.Receiver))
+		}
+	}
+
+	if p.MaxAmount != nil && req.Amount.Cmp(p.MaxAmount) > 0 {
+		violations = append(violations, fmt.Sprintf("amount %0.09f FIL exceeds the maximum of %0.09f FIL per withdrawal", util.ToFIL(req.Amount), util.ToFIL(p.MaxAmount)))
+	}
+
+	if p.MaxAmount24h != nil {
+		total := new(big.Int).Add(req.Withdrawn24h, req.Amount)
+		if total.Cmp(p.MaxAmount24h) > 0 {
+			violations = append(violations, fmt.Sprintf("withdrawing %0.09f FIL brings the 24h total to %0.09f FIL, exceeding the maximum of %0.09f FIL", util.ToFIL(req.Amount), util.ToFIL(total), util.ToFIL(p.MaxAmount24h)))
+		}
+	}
+
+	if p.MinLiquidBalance != nil {
+		remaining := new(big.Int).Sub(req.LiquidAssets, req.Amount)
+		if remaining.Cmp(p.MinLiquidBalance) < 0 {
+			violations = append(violations, fmt.Sprintf("remaining liquid balance %0.09f FIL is below the minimum of %0.09f FIL", util.ToFIL(remaining), util.ToFIL(p.MinLiquidBalance)))
+		}
+	}
+
+	if p.MaxDTL != nil && req.Debt.Sign() > 0 {
+		lv := new(big.Int).Sub(req.LiquidationValue, req.Amount)
+		if lv.Sign() <= 0 {
+			violations = append(violations, "withdrawal leaves no liquidation value to cover the Agent's debt")
+		} else if dtl := util.DivWad(req.Debt, lv); dtl.Cmp(p.MaxDTL) > 0 {
+			violations = append(violations, fmt.Sprintf("post-withdrawal DTL %0.02f%% exceeds the maximum of %0.02f%%", percBigInt(dtl), percBigInt(p.MaxDTL)))
+		}
+	}
+
+	return violations
+}
+
+// withdrawalHistory returns the journal events max-amount-24h is checked
+// against. It fails when withdrawals are not journaled, the nil journal or a
+// disabled agent:withdraw event would let the limit pass unchecked
+func withdrawalHistory(j jnal.Journal) ([]jnal.Event, error) {
+	if !j.RegisterEventType("agent", "withdraw").Enabled() {
+		return nil, fmt.Errorf("max-amount-24h cannot be checked because the journal is not recording withdrawals, enable the journal or remove the limit from [withdraw.policy]")
+	}
+	evts, err := j.ReadEvents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read withdrawal history from the journal: %w", err)
+	}
+	return evts, nil
+}
+
+// withdrawnSince sums the successful withdrawals from agent recorded in the
+// journal at or after since
+func withdrawnSince(evts []jnal.Event, agent common.Address, since time.Time) *big.Int {
+	total := big.NewInt(0)
+	for _, e := range evts {
+		if e.System != "agent" || e.Event != "withdraw" || e.Timestamp.Before(since) {
+			continue
+		}
+		data, ok := e.Data.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if errStr, _ := data["error"].(string); errStr != "" {
+			continue
+		}
+		if tx, _ := data["tx"].(string); tx == "" {
+			continue
+		}
+		if id, _ := data["agent_id"].(string); !strings.EqualFold(id, agent.String()) {
+			continue
+		}
+		amtStr, _ := data["amount"].(string)
+		amt, ok := new(big.Int).SetString(amtStr, 10)
+		if !ok {
+			continue
+		}
+		total.Add(total, amt)
+	}
+	return total
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	jnal "github.com/glifio/glif/v2/journal"
+	"github.com/glifio/glif/v2/journal/fsjournal"
+	"github.com/glifio/go-pools/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func fil(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), constants.WAD)
+}
+
+func TestWithdrawPolicyCheck(t *testing.T) {
+	owner := common.HexToAddress("0x1")
+	other := common.HexToAddress("0x2")
+
+	base := withdrawRequest{
+		Amount:           fil(10),
+		Receiver:         owner,
+		Withdrawn24h:     fil(0),
+		LiquidAssets:     fil(100),
+		Debt:             fil(50),
+		LiquidationValue: fil(200),
+	}
+
+	policy := &withdrawPolicy{
+		AllowedReceivers: []common.Address{owner},
+		MaxAmount:        fil(20),
+		MaxAmount24h:     fil(30),
+		MinLiquidBalance: fil(50),
+		MaxDTL:           new(big.Int).Div(constants.WAD, big.NewInt(2)),
+	}
+
+	assert.Empty(t, policy.Check(base))
+
+	req := base
+	req.Receiver = other
+	assert.Len(t, policy.Check(req), 1)
+
+	req = base
+	req.Amount = fil(25)
+	assert.Len(t, policy.Check(req), 1)
+
+	req = base
+	req.Withdrawn24h = fil(25)
+	assert.Len(t, policy.Check(req), 1)
+
+	req = base
+	req.LiquidAssets = fil(55)
+	assert.Len(t, policy.Check(req), 1)
+
+	// 50 / (105 - 10) > 50%
+	req = base
+	req.LiquidationValue = fil(105)
+	assert.Len(t, policy.Check(req), 1)
+
+	req = base
+	req.Amount = fil(1000)
+	req.Receiver = other
+	assert.Len(t, policy.Check(req), 5)
+}
+
+func TestWithdrawPolicyEmpty(t *testing.T) {
+	policy := &withdrawPolicy{}
+	assert.True(t, policy.Empty())
+	assert.Empty(t, policy.Check(withdrawRequest{
+		Amount:       fil(1000),
+		Receiver:     common.HexToAddress("0x2"),
+		Withdrawn24h: fil(0),
+		LiquidAssets: fil(0),
+	}))
+}
+
+func TestWithdrawnSince(t *testing.T) {
+	agent := common.HexToAddress("0xabc")
+	now := time.Now()
+	evt := func(ts time.Time, data map[string]interface{}) jnal.Event {
+		return jnal.Event{
+			EventType: jnal.EventType{System: "agent", Event: "withdraw"},
+			Timestamp: ts,
+			Data:      data,
+		}
+	}
+
+	evts := []jnal.Event{
+		evt(now.Add(-time.Hour), map[string]interface{}{"agent_id": agent.String(), "amount": fil(1).String(), "tx": "0x1"}),
+		evt(now.Add(-2*time.Hour), map[string]interface{}{"agent_id": agent.String(), "amount": fil(2).String(), "tx": "0x2"}),
+		// too old
+		evt(now.Add(-25*time.Hour), map[string]interface{}{"agent_id": agent.String(), "amount": fil(4).String(), "tx": "0x3"}),
+		// failed
+		evt(now.Add(-time.Hour), map[string]interface{}{"agent_id": agent.String(), "amount": fil(8).String(), "tx": "0x4", "error": "reverted"}),
+		// different agent
+		evt(now.Add(-time.Hour), map[string]interface{}{"agent_id": "0xdef", "amount": fil(16).String(), "tx": "0x5"}),
+	}
+
+	total := withdrawnSince(evts, agent, now.Add(-24*time.Hour))
+	assert.Equal(t, 0, total.Cmp(fil(3)), "got %s", total)
+}
+
+func TestWithdrawalHistory(t *testing.T) {
+	// without a journal the 24h limit can't be checked, so it fails closed
+	_, err := withdrawalHistory(jnal.NilJournal())
+	assert.ErrorContains(t, err, "max-amount-24h cannot be checked")
+
+	disabled, err := fsjournal.OpenFSJournal(t.TempDir(), jnal.DisabledEvents{{System: "agent", Event: "withdraw"}})
+	assert.NoError(t, err)
+	defer disabled.Close()
+	_, err = withdrawalHistory(disabled)
+	assert.ErrorContains(t, err, "max-amount-24h cannot be checked")
+
+	j, err := fsjournal.OpenFSJournal(t.TempDir(), nil)
+	assert.NoError(t, err)
+	defer j.Close()
+	_, err = withdrawalHistory(j)
+	assert.NoError(t, err)
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/glifio/go-pools/abigen"
 	"github.com/glifio/go-pools/constants"
 
@@ -14,6 +19,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// hedgeyClaimedABI is the public claimed mapping of the Hedgey claim campaigns
+// contract, which the IHedgeyAirdrop binding does not expose
+const hedgeyClaimedABI = `[
+	{"inputs":[
+		{"internalType":"bytes16","name":"","type":"bytes16"},
+		{"internalType":"address","name":"","type":"address"}
+	],"name":"claimed","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}
+]`
+
 var airdropCmd = &cobra.Command{
 	Use:   "airdrop",
 	Short: "Airdrop related commands",
@@ -88,50 +102,33 @@ var claimCmd = &cobra.Command{
 
 		fmt.Printf("Claiming airdrop for %s from %s...\n", strAddr, auth.From.Hex())
 
-		delegatee, err := interactiveClaimExp(cmd.Context(), auth.From)
+		delegate, err := cmd.Flags().GetString("delegate")
 		if err != nil {
 			logFatal(err)
 		}
-
-		fmt.Printf("You have selected to delegate your vote to: %s\n", delegatee.Hex())
-
-		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-		s.Start()
-		defer s.Stop()
-
-		ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+		yes, err := cmd.Flags().GetBool("yes")
 		if err != nil {
 			logFatal(err)
 		}
 
-		airdropInstance, err := abigen.NewIHedgeyAirdropTransactor(PoolsSDK.Query().DelegatedClaimsCampaigns(), ethClient)
-		if err != nil {
-			logFatalf("Failed to create IHedgeyAirdrop instance %s", err)
-		}
-
-		mt := &token.MerkleTree{}
-		mt, err = mt.ReadFromJSON(isTestDrop)
+		delegatee, err := claimDelegatee(cmd.Context(), auth.From, delegate, yes)
 		if err != nil {
 			logFatal(err)
 		}
 
-		proof, err := mt.GetProofForAddrWithAgent(addressToClaimOnBehalf, agentAddr)
-		if err != nil {
-			logFatal(err)
-		}
+		fmt.Printf("You have selected to delegate your vote to: %s\n", delegatee.Hex())
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
 
-		value, err := mt.GetLeafValueForAddrWithAgent(addressToClaimOnBehalf, agentAddr)
+		mt := &token.MerkleTree{}
+		mt, err = mt.ReadFromJSON(isTestDrop)
 		if err != nil {
 			logFatal(err)
 		}
 
-		tx, err := airdropInstance.ClaimAndDelegate(auth, mt.ID(), proof, value, delegatee, abigen.IHedgeyAirdropSignatureParams{
-			V:      0,
-			R:      [32]byte{},
-			S:      [32]byte{},
-			Nonce:  big.NewInt(0),
-			Expiry: big.NewInt(0),
-		})
+		tx, err := claimAirdrop(auth, mt, addressToClaimOnBehalf, agentAddr, delegatee)
 		if err != nil {
 			logFatalf("Failed to claim airdrop %s", err)
 		}
@@ -151,9 +148,69 @@ var claimCmd = &cobra.Command{
 	},
 }
 
+// claimAirdrop claims the airdrop of claimer, or of agentAddr when claimer is
+// the owner of an Agent, and delegates its votes to delegatee
+func claimAirdrop(auth *bind.TransactOpts, mt *token.MerkleTree, claimer common.Address, agentAddr *common.Address, delegatee common.Address) (*types.Transaction, error) {
+	ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+	defer ethClient.Close()
+
+	airdropInstance, err := abigen.NewIHedgeyAirdropTransactor(PoolsSDK.Query().DelegatedClaimsCampaigns(), ethClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IHedgeyAirdrop instance %s", err)
+	}
+
+	proof, err := mt.GetProofForAddrWithAgent(claimer, agentAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := mt.GetLeafValueForAddrWithAgent(claimer, agentAddr)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("%s is not eligible for the airdrop", claimer.Hex())
+	}
+
+	return airdropInstance.ClaimAndDelegate(auth, mt.ID(), proof, value, delegatee, abigen.IHedgeyAirdropSignatureParams{
+		V:      0,
+		R:      [32]byte{},
+		S:      [32]byte{},
+		Nonce:  big.NewInt(0),
+		Expiry: big.NewInt(0),
+	})
+}
+
+// airdropClaimedOnChain reports whether claimer already claimed from the
+// campaign, claiming again would revert
+func airdropClaimedOnChain(ctx context.Context, campaign [16]byte, claimer common.Address) (bool, error) {
+	ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return false, err
+	}
+	defer ethClient.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(hedgeyClaimedABI))
+	if err != nil {
+		return false, err
+	}
+	campaigns := bind.NewBoundContract(PoolsSDK.Query().DelegatedClaimsCampaigns(), parsed, ethClient, nil, nil)
+
+	var out []interface{}
+	if err := campaigns.Call(&bind.CallOpts{Context: ctx}, &out, "claimed", campaign, claimer); err != nil {
+		return false, fmt.Errorf("failed to check the airdrop claim of %s: %w", claimer.Hex(), err)
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
+
 func init() {
 	rootCmd.AddCommand(airdropCmd)
 	airdropCmd.AddCommand(checkEligibilityCmd)
 	airdropCmd.AddCommand(claimCmd)
 	claimCmd.Flags().String("from", "", "address of the owner or operator of the agent")
+	claimCmd.Flags().String("delegate", "", "delegate votes to glif, self or an address, skips the delegate prompt")
+	claimCmd.Flags().Bool("yes", false, "accept the terms at https://glif.io/terms without prompting, requires --delegate")
 }
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/token"
+	poolsutil "github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+const (
+	airdropEligible       = "eligible"
+	airdropClaimed        = "claimed"
+	airdropAlreadyClaimed = "already claimed"
+	airdropFailed         = "failed"
+)
+
+// airdropCandidate is a wallet account, or an Agent owned by one, that may be
+// eligible for the airdrop
+type airdropCandidate struct {
+	Name    string
+	Claimer common.Address
+	Agent   *common.Address
+}
+
+type airdropClaimResult struct {
+	airdropCandidate
+	Amount *big.Int
+	Status string
+	Tx     string
+	Error  string
+}
+
+// airdropCandidates lists every wallet account, and every Agent whose owner is
+// a wallet account, sorted by account name. Agents come before their owner so
+// that owners with several Agents are matched to the right merkle entries
+func airdropCandidates(accounts map[string]common.Address, agentOwners map[common.Address]common.Address) []airdropCandidate {
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	owned := map[common.Address][]common.Address{}
+	for agent, owner := range agentOwners {
+		owned[owner] = append(owned[owner], agent)
+	}
+
+	var candidates []airdropCandidate
+	seen := map[common.Address]bool{}
+	for _, name := range names {
+		addr := accounts[name]
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+
+		agents := owned[addr]
+		sort.Slice(agents, func(i, j int) bool { return bytes.Compare(agents[i][:], agents[j][:]) < 0 })
+		for _, agent := range agents {
+			candidates = append(candidates, airdropCandidate{Name: name, Claimer: addr, Agent: &agent})
+		}
+		candidates = append(candidates, airdropCandidate{Name: name, Claimer: addr})
+	}
+
+	return candidates
+}
+
+var claimAllCmd = &cobra.Command{
+	Use:   "claim-all",
+	Short: "Claim the airdrop for every eligible wallet account and Agent",
+	Long: `Claim the airdrop for every eligible wallet account and Agent.
+
+Every account in the wallet is checked for eligibility, along with every Agent
+owned by a wallet account. Airdrops that were already claimed on chain are
+skipped, the rest are claimed with the account's key, GLIF_PASSPHRASE is used
+to unlock every account when set.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		delegate, err := cmd.Flags().GetString("delegate")
+		if err != nil {
+			logFatal(err)
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			logFatal(err)
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			logFatal(err)
+		}
+		if delegate == "" && !dryRun {
+			logFatal("claim-all requires --delegate <glif|self|address>")
+		}
+
+		isTestDrop := PoolsSDK.Query().ChainID().Int64() != constants.MainnetChainID
+		agentToOwnerMap, err := token.ReadAgentOwnerMap(isTestDrop)
+		if err != nil {
+			logFatalf("Failed to read agent owner map %s", err)
+		}
+
+		mt := &token.MerkleTree{}
+		mt, err = mt.ReadFromJSON(isTestDrop)
+		if err != nil {
+			logFatal(err)
+		}
+
+		accounts := walletAccounts()
+
+		// several candidates can resolve to the same merkle entry, claim each once
+		var results []*airdropClaimResult
+		claimed := map[int]bool{}
+		claimedOnChain := map[common.Address]bool{}
+		pending := 0
+		for _, c := range airdropCandidates(accounts, agentToOwnerMap) {
+			idx, err := mt.GetIdxForAddrWithAgent(c.Claimer, c.Agent)
+			if err != nil {
+				logFatal(err)
+			}
+			if idx < 0 || claimed[idx] {
+				continue
+			}
+			claimed[idx] = true
+
+			amount, err := mt.GetLeafValueForAddrWithAgent(c.Claimer, c.Agent)
+			if err != nil {
+				logFatal(err)
+			}
+
+			done, ok := claimedOnChain[c.Claimer]
+			if !ok {
+				done, err = airdropClaimedOnChain(ctx, mt.ID(), c.Claimer)
+				if err != nil {
+					logFatal(err)
+				}
+				claimedOnChain[c.Claimer] = done
+			}
+
+			status := airdropEligible
+			if done {
+				status = airdropAlreadyClaimed
+			} else {
+				pending++
+			}
+			results = append(results, &airdropClaimResult{airdropCandidate: c, Amount: amount, Status: status})
+		}
+
+		fmt.Printf("Found %d eligible airdrops across %d wallet accounts, %d already claimed\n", len(results), len(accounts), len(results)-pending)
+
+		if !dryRun && pending > 0 {
+			if err := acceptClaimTerms(yes); err != nil {
+				logFatal(err)
+			}
+
+			auths := map[common.Address]*bind.TransactOpts{}
+			for _, r := range results {
+				if r.Status == airdropAlreadyClaimed {
+					continue
+				}
+
+				auth, ok := auths[r.Claimer]
+				if !ok {
+					fmt.Printf("Unlocking %s (%s)...\n", r.Name, r.Claimer.Hex())
+					auth, _, err = commonGenericAccountSetup(cmd, r.Claimer.Hex())
+					if err != nil {
+						r.Status, r.Error = airdropFailed, err.Error()
+						continue
+					}
+					auths[r.Claimer] = auth
+				}
+
+				delegatee, err := resolveDelegatee(ctx, delegate, r.Claimer)
+				if err != nil {
+					logFatal(err)
+				}
+
+				s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+				s.Start()
+				err = claimAndWait(cmd, auth, mt, r, delegatee)
+				s.Stop()
+				if err != nil {
+					r.Status, r.Error = airdropFailed, err.Error()
+					continue
+				}
+				r.Status = airdropClaimed
+			}
+		}
+
+		printAirdropClaimSummary(results)
+	},
+}
+
+func claimAndWait(cmd *cobra.Command, auth *bind.TransactOpts, mt *token.MerkleTree, r *airdropClaimResult, delegatee common.Address) error {
+	// the nonce is fetched per transaction when claiming several airdrops from one account
+	auth.Nonce = nil
+
+	tx, err := claimAirdrop(auth, mt, r.Claimer, r.Agent, delegatee)
+	if err != nil {
+		return err
+	}
+	r.Tx = tx.Hash().Hex()
+
+	_, err = PoolsSDK.Query().StateWaitReceipt(cmd.Context(), tx.Hash())
+	return err
+}
+
+func printAirdropClaimSummary(results []*airdropClaimResult) {
+	generateHeader("AIRDROP CLAIMS")
+
+	tbl := table.New("Account", "Claimer", "Agent", "Amount", "Status", "Tx")
+	total := big.NewInt(0)
+	claimedTotal := big.NewInt(0)
+	claimedCount := 0
+	alreadyCount := 0
+	for _, r := range results {
+		agent := "-"
+		if r.Agent != nil {
+			agent = r.Agent.Hex()
+		}
+		status := r.Status
+		if r.Error != "" {
+			status = fmt.Sprintf("%s: %s", r.Status, r.Error)
+		}
+		tx := r.Tx
+		if tx == "" {
+			tx = "-"
+		}
+		tbl.AddRow(r.Name, r.Claimer.Hex(), agent, fmt.Sprintf("%0.02f GLF", poolsutil.ToFIL(r.Amount)), status, tx)
+
+		switch r.Status {
+		case airdropAlreadyClaimed:
+			alreadyCount++
+			continue
+		case airdropClaimed:
+			claimedCount++
+			claimedTotal.Add(claimedTotal, r.Amount)
+		}
+		total.Add(total, r.Amount)
+	}
+	tbl.Print()
+
+	fmt.Printf("\nClaimed %d of %d eligible airdrops, %0.02f of %0.02f GLF\n", claimedCount, len(results)-alreadyCount, poolsutil.ToFIL(claimedTotal), poolsutil.ToFIL(total))
+	if alreadyCount > 0 {
+		fmt.Printf("%d airdrops were already claimed\n", alreadyCount)
+	}
+}
+
+func init() {
+	airdropCmd.AddCommand(claimAllCmd)
+	claimAllCmd.Flags().String("delegate", "", "delegate votes to glif, self or an address, self delegates each claim to its claimer")
+	claimAllCmd.Flags().Bool("yes", false, "accept the terms at https://glif.io/terms without prompting")
+	claimAllCmd.Flags().Bool("dry-run", false, "only report eligible airdrops, do not claim them")
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAirdropCandidates(t *testing.T) {
+	owner := common.HexToAddress("0x01")
+	other := common.HexToAddress("0x02")
+	agentA := common.HexToAddress("0xa1")
+	agentB := common.HexToAddress("0xa2")
+	foreignAgent := common.HexToAddress("0xa3")
+
+	accounts := map[string]common.Address{
+		"owner":   owner,
+		"alias":   owner,
+		"savings": other,
+	}
+	agentOwners := map[common.Address]common.Address{
+		agentB:       owner,
+		agentA:       owner,
+		foreignAgent: common.HexToAddress("0x03"),
+	}
+
+	candidates := airdropCandidates(accounts, agentOwners)
+
+	// "alias" sorts first and claims for the owner address, its Agents come first
+	assert.Equal(t, []airdropCandidate{
+		{Name: "alias", Claimer: owner, Agent: &agentA},
+		{Name: "alias", Claimer: owner, Agent: &agentB},
+		{Name: "alias", Claimer: owner},
+		{Name: "savings", Claimer: other},
+	}, candidates)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/ethereum/go-ethereum/common"
@@ -79,12 +80,7 @@ func getDelegateeAddressByLanguage(ctx context.Context, self common.Address, lan
 
 	switch selectedOption {
 	case VOTE_OPTION_DELEGATEE_GLIF_LTD, VOTE_OPTION_DELEGATEE_GLIF_LTD_CH:
-		testDrop := PoolsSDK.Query().ChainID().Int64() != constants.MainnetChainID
-		if testDrop {
-			delegatee = common.HexToAddress(TESTNET_GLIF_LTD_DELEGATEE)
-		} else {
-			delegatee = common.HexToAddress(MAINNET_GLIF_LTD_DELEGATEE)
-		}
+		delegatee = glifDelegatee()
 	case VOTE_OPTION_DELEGATEE_MYSELF, VOTE_OPTION_DELEGATEE_MYSELF_CH:
 		// Assuming the account address is available in the context
 		delegatee = self
@@ -210,3 +206,59 @@ func interactiveClaimExp(ctx context.Context, addr common.Address) (common.Addre
 
 	return delegatee, nil
 }
+
+func glifDelegatee() common.Address {
+	testDrop := PoolsSDK.Query().ChainID().Int64() != constants.MainnetChainID
+	if testDrop {
+		return common.HexToAddress(TESTNET_GLIF_LTD_DELEGATEE)
+	}
+	return common.HexToAddress(MAINNET_GLIF_LTD_DELEGATEE)
+}
+
+// resolveDelegatee parses a --delegate value: glif, self, or an address or account name
+func resolveDelegatee(ctx context.Context, delegate string, self common.Address) (common.Address, error) {
+	switch strings.ToLower(delegate) {
+	case "glif":
+		return glifDelegatee(), nil
+	case "self":
+		return self, nil
+	}
+	delegatee, err := AddressOrAccountNameToEVM(ctx, delegate)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid delegate %s, expected glif, self or an address: %w", delegate, err)
+	}
+	return delegatee, nil
+}
+
+// acceptClaimTerms asks the user to accept the airdrop terms, unless they
+// were accepted up front with --yes
+func acceptClaimTerms(yes bool) error {
+	if yes {
+		return nil
+	}
+
+	lang, err := getUserLanguagePreference()
+	if err != nil {
+		return fmt.Errorf("failed to get user language preference: %w", err)
+	}
+
+	return getAcceptTermsByLanguage(lang)
+}
+
+// claimDelegatee returns the delegatee for a claim by self. Without flags the
+// full interactive experience is used, --delegate and --yes skip the
+// corresponding prompts
+func claimDelegatee(ctx context.Context, self common.Address, delegate string, yes bool) (common.Address, error) {
+	if delegate == "" {
+		if yes {
+			return common.Address{}, fmt.Errorf("--yes requires --delegate <glif|self|address>")
+		}
+		return interactiveClaimExp(ctx, self)
+	}
+
+	if err := acceptClaimTerms(yes); err != nil {
+		return common.Address{}, err
+	}
+
+	return resolveDelegatee(ctx, delegate, self)
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// vestingPlan is an airdrop plan held by a wallet account
+type vestingPlan struct {
+	Account    string
+	Owner      common.Address
+	ID         *big.Int
+	Plan       abigen.IHedgeyVoteTokenLockupPlanPlan
+	Redeemable *big.Int
+}
+
+// monthUnlock is the amount of GLF that unlocks in a calendar month
+type monthUnlock struct {
+	Month  time.Time
+	Amount *big.Int
+}
+
+// planUnlockedAt returns the amount of the plan's remaining tokens that are
+// unlocked at t. Plans unlock rate tokens every period seconds from start,
+// nothing unlocks before the cliff
+func planUnlockedAt(p abigen.IHedgeyVoteTokenLockupPlanPlan, t time.Time) *big.Int {
+	ts := big.NewInt(t.Unix())
+	if p.Period.Sign() <= 0 || ts.Cmp(p.Start) < 0 || ts.Cmp(p.Cliff) < 0 {
+		return big.NewInt(0)
+	}
+
+	periods := new(big.Int).Sub(ts, p.Start)
+	periods.Div(periods, p.Period)
+	return bigMin(new(big.Int).Mul(p.Rate, periods), p.Amount)
+}
+
+// planEnd returns the time the last tokens of the plan unlock
+func planEnd(p abigen.IHedgeyVoteTokenLockupPlanPlan) time.Time {
+	if p.Rate.Sign() <= 0 {
+		return time.Unix(p.Start.Int64(), 0)
+	}
+	periods := new(big.Int).Add(p.Amount, new(big.Int).Sub(p.Rate, big.NewInt(1)))
+	periods.Div(periods, p.Rate)
+	end := new(big.Int).Add(p.Start, new(big.Int).Mul(periods, p.Period))
+	return time.Unix(bigMax(end, p.Cliff).Int64(), 0)
+}
+
+// unlockCalendar groups the tokens that unlock after now by calendar month
+func unlockCalendar(plans []abigen.IHedgeyVoteTokenLockupPlanPlan, now time.Time) []monthUnlock {
+	var last time.Time
+	for _, p := range plans {
+		if end := planEnd(p); end.After(last) {
+			last = end
+		}
+	}
+
+	unlockedAt := func(t time.Time) *big.Int {
+		total := big.NewInt(0)
+		for _, p := range plans {
+			total.Add(total, planUnlockedAt(p, t))
+		}
+		return total
+	}
+
+	var calendar []monthUnlock
+	prev := unlockedAt(now)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !month.After(last) {
+		next := month.AddDate(0, 1, 0)
+		// unlocks happen at the end of a period, so a plan ending exactly on
+		// the month boundary counts towards the month before
+		unlocked := unlockedAt(next.Add(-time.Second))
+		if amount := new(big.Int).Sub(unlocked, prev); amount.Sign() > 0 {
+			calendar = append(calendar, monthUnlock{Month: month, Amount: amount})
+		}
+		prev = unlocked
+		month = next
+	}
+
+	return calendar
+}
+
+// listVestingPlans returns every airdrop plan held by the given accounts
+func listVestingPlans(ctx context.Context, accounts map[string]common.Address, now time.Time) ([]vestingPlan, error) {
+	ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+	defer ethClient.Close()
+
+	caller, err := abigen.NewIHedgeyVoteTokenLockupPlanCaller(PoolsSDK.Query().TokenNFTWrapper(), ethClient)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	opts := &bind.CallOpts{Context: ctx}
+	unixNow := big.NewInt(now.Unix())
+
+	var plans []vestingPlan
+	seen := map[common.Address]bool{}
+	for _, name := range names {
+		owner := accounts[name]
+		if seen[owner] {
+			continue
+		}
+		seen[owner] = true
+
+		count, err := caller.BalanceOf(opts, owner)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := big.NewInt(0); i.Cmp(count) == -1; i.Add(i, big.NewInt(1)) {
+			planID, err := caller.TokenOfOwnerByIndex(opts, owner, i)
+			if err != nil {
+				return nil, err
+			}
+
+			plan, err := caller.Plans(opts, planID)
+			if err != nil {
+				return nil, err
+			}
+
+			balance, err := caller.PlanBalanceOf(opts, planID, unixNow, unixNow)
+			if err != nil {
+				return nil, err
+			}
+
+			plans = append(plans, vestingPlan{
+				Account:    name,
+				Owner:      owner,
+				ID:         planID,
+				Plan:       plan,
+				Redeemable: balance.Balance,
+			})
+		}
+	}
+
+	return plans, nil
+}
+
+var plansSummaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Summarize the airdrop plans held by every wallet account",
+	Long:  "Summarize the airdrop plans held by every wallet account, with the total locked, the total redeemable now and a calendar of when the locked tokens unlock",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		now := time.Now()
+		plans, err := listVestingPlans(cmd.Context(), walletAccounts(), now)
+		if err != nil {
+			logFatal(err)
+		}
+
+		s.Stop()
+
+		if len(plans) == 0 {
+			fmt.Println("No airdrop plans found for the accounts in your wallet")
+			return
+		}
+
+		totalLocked := big.NewInt(0)
+		totalRedeemable := big.NewInt(0)
+		raw := make([]abigen.IHedgeyVoteTokenLockupPlanPlan, 0, len(plans))
+
+		generateHeader("AIRDROP PLANS")
+		tbl := table.New("Account", "Plan ID", "Locked", "Redeemable", "Fully unlocked")
+		for _, p := range plans {
+			locked := new(big.Int).Sub(p.Plan.Amount, p.Redeemable)
+			totalLocked.Add(totalLocked, locked)
+			totalRedeemable.Add(totalRedeemable, p.Redeemable)
+			raw = append(raw, p.Plan)
+
+			tbl.AddRow(
+				p.Account,
+				p.ID.String(),
+				fmt.Sprintf("%0.04f GLF", util.ToFIL(locked)),
+				fmt.Sprintf("%0.04f GLF", util.ToFIL(p.Redeemable)),
+				planEnd(p.Plan).UTC().Format("2006-01-02"),
+			)
+		}
+		tbl.Print()
+
+		generateHeader("TOTALS")
+		printTable(
+			[]string{"Plans", "Total locked", "Total redeemable now"},
+			[]string{
+				fmt.Sprintf("%d", len(plans)),
+				fmt.Sprintf("%0.04f GLF", util.ToFIL(totalLocked)),
+				fmt.Sprintf("%0.04f GLF", util.ToFIL(totalRedeemable)),
+			},
+		)
+
+		calendar := unlockCalendar(raw, now)
+		if len(calendar) > 0 {
+			generateHeader("UNLOCK CALENDAR")
+			tbl = table.New("Month", "Unlocking", "Cumulative")
+			cumulative := new(big.Int).Set(totalRedeemable)
+			for _, m := range calendar {
+				cumulative.Add(cumulative, m.Amount)
+				tbl.AddRow(
+					m.Month.Format("2006 January"),
+					fmt.Sprintf("%0.04f GLF", util.ToFIL(m.Amount)),
+					fmt.Sprintf("%0.04f GLF", util.ToFIL(cumulative)),
+				)
+			}
+			tbl.Print()
+		}
+
+		if totalRedeemable.Sign() > 0 {
+			fmt.Println()
+			fmt.Println("Redeem with: glif airdrop plans redeem <plan-id> --from <account>")
+		}
+	},
+}
+
+func init() {
+	plansCmd.AddCommand(plansSummaryCmd)
+}
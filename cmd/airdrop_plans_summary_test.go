@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/stretchr/testify/assert"
+)
+
+const planDay = 24 * 60 * 60
+
+// testPlan unlocks 1 GLF per day for 90 days from start, after a 30 day cliff
+func testPlan(start time.Time) abigen.IHedgeyVoteTokenLockupPlanPlan {
+	return abigen.IHedgeyVoteTokenLockupPlanPlan{
+		Amount: fil(90),
+		Start:  big.NewInt(start.Unix()),
+		Cliff:  big.NewInt(start.Unix() + 30*planDay),
+		Rate:   fil(1),
+		Period: big.NewInt(planDay),
+	}
+}
+
+func TestPlanUnlockedAt(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := testPlan(start)
+
+	assert.Equal(t, 0, planUnlockedAt(p, start.AddDate(0, 0, -1)).Sign())
+	// nothing unlocks before the cliff
+	assert.Equal(t, 0, planUnlockedAt(p, start.AddDate(0, 0, 29)).Sign())
+	assert.Equal(t, 0, planUnlockedAt(p, start.AddDate(0, 0, 30)).Cmp(fil(30)))
+	assert.Equal(t, 0, planUnlockedAt(p, start.AddDate(0, 0, 45).Add(time.Hour)).Cmp(fil(45)))
+	assert.Equal(t, 0, planUnlockedAt(p, start.AddDate(1, 0, 0)).Cmp(fil(90)))
+
+	assert.Equal(t, start.AddDate(0, 0, 90), planEnd(p).UTC())
+}
+
+func TestUnlockCalendar(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	plans := []abigen.IHedgeyVoteTokenLockupPlanPlan{testPlan(start), testPlan(start.AddDate(0, 1, 0))}
+
+	now := start.AddDate(0, 0, 10)
+	calendar := unlockCalendar(plans, now)
+
+	total := big.NewInt(0)
+	for _, m := range calendar {
+		total.Add(total, m.Amount)
+	}
+	assert.Equal(t, 0, total.Cmp(fil(180)))
+
+	assert.Equal(t, time.January, calendar[0].Month.Month())
+	// the first plan's cliff releases 30 GLF on January 31st
+	assert.Equal(t, 0, calendar[0].Amount.Cmp(fil(30)))
+	assert.Equal(t, time.May, calendar[len(calendar)-1].Month.Month())
+
+	// once everything unlocked there is nothing left to schedule
+	assert.Empty(t, unlockCalendar(plans, start.AddDate(1, 0, 0)))
+}
+
+func TestPlansToRedeem(t *testing.T) {
+	a := common.HexToAddress("0x01")
+	b := common.HexToAddress("0x02")
+	plans := []vestingPlan{
+		{Owner: a, ID: big.NewInt(1), Redeemable: fil(150)},
+		{Owner: a, ID: big.NewInt(2), Redeemable: fil(50)},
+		{Owner: b, ID: big.NewInt(3), Redeemable: fil(100)},
+		{Owner: b, ID: big.NewInt(4), Redeemable: big.NewInt(0)},
+	}
+
+	redeem := plansToRedeem(plans, fil(100))
+	assert.Len(t, redeem, 2)
+	assert.Len(t, redeem[a], 1)
+	assert.Equal(t, int64(1), redeem[a][0].ID.Int64())
+	assert.Len(t, redeem[b], 1)
+
+	// a zero threshold redeems anything redeemable
+	redeem = plansToRedeem(plans, big.NewInt(0))
+	assert.Len(t, redeem[a], 2)
+	assert.Len(t, redeem[b], 1)
+}
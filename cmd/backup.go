@@ -0,0 +1,107 @@
+/*
+Copyright © 2023 Glif LTD
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Create, verify and restore encrypted backups of the config directory",
+}
+
+// getBackupPassphrase reads the backup passphrase from GLIF_BACKUP_PASSPHRASE
+// or prompts for it, asking twice when confirm is set
+func getBackupPassphrase(confirm bool) (string, error) {
+	passphrase, envSet := os.LookupEnv("GLIF_BACKUP_PASSPHRASE")
+	if envSet {
+		return passphrase, nil
+	}
+
+	prompt := &survey.Password{Message: "Backup passphrase"}
+	survey.AskOne(prompt, &passphrase)
+	if passphrase == "" {
+		return "", fmt.Errorf("aborting, no passphrase entered")
+	}
+
+	if confirm {
+		var confirmPassphrase string
+		confirmPrompt := &survey.Password{Message: "Confirm backup passphrase"}
+		survey.AskOne(confirmPrompt, &confirmPassphrase)
+		if passphrase != confirmPassphrase {
+			return "", fmt.Errorf("aborting, passphrase confirmation did not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// currentAccounts returns the named accounts in accounts.toml
+func currentAccounts() (map[string]string, error) {
+	as := util.AccountsStore()
+	accounts := map[string]string{}
+	for _, name := range as.AccountNames() {
+		addr, err := as.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		if addr == "" {
+			continue
+		}
+		accounts[name] = addr
+	}
+	return accounts, nil
+}
+
+// checkBackupAccounts returns an error if any current account with a key in
+// the keystore is missing from the backup. Read-only accounts have no key to
+// back up, accounts.toml still carries their labels
+func checkBackupAccounts(backup *util.Backup) error {
+	accounts, err := currentAccounts()
+	if err != nil {
+		return err
+	}
+
+	missing := missingBackupAccounts(backup, accounts, util.KeyStore().HasAddress)
+	if len(missing) > 0 {
+		return fmt.Errorf("backup is missing accounts: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// missingBackupAccounts returns the accounts hasKey holds a key for that are
+// not in the backup with the same address and key, sorted by name
+func missingBackupAccounts(backup *util.Backup, accounts map[string]string, hasKey func(common.Address) bool) []string {
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var missing []string
+	for _, name := range names {
+		addr := accounts[name]
+		if !common.IsHexAddress(addr) || !hasKey(common.HexToAddress(addr)) {
+			continue
+		}
+		if !strings.EqualFold(backup.Manifest.Accounts[name], addr) || !backup.HasKey(addr) {
+			missing = append(missing, fmt.Sprintf("%s (%s)", name, addr))
+		}
+	}
+	return missing
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+}
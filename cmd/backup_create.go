@@ -0,0 +1,83 @@
+/*
+Copyright © 2023 Glif LTD
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an encrypted backup of the keystore, accounts, agent and config",
+	Long: `Create an encrypted backup of the keystore, accounts.toml, agent.toml and config.toml.
+
+The archive is encrypted with a passphrase using scrypt, the same scheme used by the keystore,
+and contains a manifest with a checksum of every file. The archive is verified right after it is
+written, and a verified backup satisfies the backup check.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		output := cmd.Flag("output").Value.String()
+		if output == "" {
+			output = fmt.Sprintf("glif-backup-%s.glifbak", time.Now().UTC().Format("20060102-150405"))
+		}
+		output, err := filepath.Abs(output)
+		if err != nil {
+			logFatal(err)
+		}
+		if _, err := os.Stat(output); err == nil {
+			logFatalf("%s already exists", output)
+		}
+
+		passphrase, err := getBackupPassphrase(true)
+		if err != nil {
+			logFatal(err)
+		}
+
+		accounts, err := currentAccounts()
+		if err != nil {
+			logFatal(err)
+		}
+
+		archive, err := util.CreateBackup(cfgDir, passphrase, chainID, accounts, keystore.StandardScryptN, keystore.StandardScryptP)
+		if err != nil {
+			logFatal(err)
+		}
+
+		if err := os.WriteFile(output, archive, 0600); err != nil {
+			logFatal(err)
+		}
+
+		// read the archive back from disk to make sure it can be restored
+		written, err := os.ReadFile(output)
+		if err != nil {
+			logFatal(err)
+		}
+		backup, err := util.OpenBackup(written, passphrase)
+		if err != nil {
+			logFatalf("Backup written to %s failed verification: %s", output, err)
+		}
+		if err := checkBackupAccounts(backup); err != nil {
+			logFatalf("Backup written to %s failed verification: %s", output, err)
+		}
+
+		util.BackupsStore().ConfirmVerified(output)
+
+		fmt.Printf("Backup of %d files and %d accounts written to %s\n", len(backup.Manifest.Files), len(backup.Manifest.Accounts), output)
+		fmt.Printf("SHA-256: %x\n", sha256.Sum256(written))
+		fmt.Println("Store the archive and its passphrase in separate, safe places.")
+	},
+}
+
+func init() {
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCreateCmd.Flags().String("output", "", "path to write the backup archive to, defaults to glif-backup-<timestamp>.glifbak in the current directory")
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2023 Glif LTD
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore a backup archive into a fresh config directory",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		to, err := filepath.Abs(cmd.Flag("to").Value.String())
+		if err != nil {
+			logFatal(err)
+		}
+		if to == cfgDir {
+			logFatal("Can not restore into the config directory in use, choose a fresh directory")
+		}
+
+		archive, err := os.ReadFile(args[0])
+		if err != nil {
+			logFatal(err)
+		}
+
+		passphrase, err := getBackupPassphrase(false)
+		if err != nil {
+			logFatal(err)
+		}
+
+		backup, err := util.OpenBackup(archive, passphrase)
+		if err != nil {
+			logFatal(err)
+		}
+
+		if backup.Manifest.ChainID != chainID {
+			logFatalf("Backup is for chain ID %d, but the %s network uses chain ID %d", backup.Manifest.ChainID, network.Name, chainID)
+		}
+
+		if err := backup.Restore(to); err != nil {
+			logFatal(err)
+		}
+		// the manifest's chain ID matches, record it for backups made before keystores had a record
+		if err := util.MarkKeyStoreNetwork(filepath.Join(to, "keystore"), network.Name, chainID); err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Restored %d files and %d accounts to %s\n", len(backup.Manifest.Files), len(backup.Manifest.Accounts), to)
+		fmt.Printf("Use it with: glif --config-dir %s <command>\n", to)
+	},
+}
+
+func init() {
+	backupCmd.AddCommand(backupRestoreCmd)
+	backupRestoreCmd.Flags().String("to", "", "fresh config directory to restore into")
+	backupRestoreCmd.MarkFlagRequired("to")
+}
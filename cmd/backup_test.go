@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingBackupAccounts(t *testing.T) {
+	owner := "0x1111111111111111111111111111111111111111"
+	operator := "0x2222222222222222222222222222222222222222"
+	label := "0x3333333333333333333333333333333333333333"
+
+	keys := map[common.Address]bool{
+		common.HexToAddress(owner):    true,
+		common.HexToAddress(operator): true,
+	}
+	hasKey := func(addr common.Address) bool { return keys[addr] }
+
+	accounts := map[string]string{
+		"owner":    owner,
+		"operator": operator,
+		"exchange": label,
+	}
+
+	backup := &util.Backup{
+		Manifest: util.BackupManifest{Accounts: map[string]string{"owner": owner, "operator": operator}},
+		Files: map[string][]byte{
+			"keystore/UTC--2024-01-01T00-00-00.000000000Z--1111111111111111111111111111111111111111": nil,
+			"keystore/UTC--2024-01-01T00-00-00.000000000Z--2222222222222222222222222222222222222222": nil,
+		},
+	}
+
+	// the label-only account has no key to back up
+	assert.Empty(t, missingBackupAccounts(backup, accounts, hasKey))
+
+	delete(backup.Files, "keystore/UTC--2024-01-01T00-00-00.000000000Z--2222222222222222222222222222222222222222")
+	assert.Equal(t, []string{"operator (" + operator + ")"}, missingBackupAccounts(backup, accounts, hasKey))
+
+	// once the labeled address has a key it must be backed up too
+	keys[common.HexToAddress(label)] = true
+	assert.Equal(t, []string{"exchange (" + label + ")", "operator (" + operator + ")"}, missingBackupAccounts(backup, accounts, hasKey))
+}
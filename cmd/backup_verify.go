@@ -0,0 +1,58 @@
+/*
+Copyright © 2023 Glif LTD
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Verify that a backup archive decrypts and contains every current account",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		archivePath, err := filepath.Abs(args[0])
+		if err != nil {
+			logFatal(err)
+		}
+
+		archive, err := os.ReadFile(archivePath)
+		if err != nil {
+			logFatal(err)
+		}
+
+		passphrase, err := getBackupPassphrase(false)
+		if err != nil {
+			logFatal(err)
+		}
+
+		backup, err := util.OpenBackup(archive, passphrase)
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Backup created at %s contains %d files\n", backup.Manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"), len(backup.Manifest.Files))
+
+		if backup.Manifest.ChainID != chainID {
+			logFatalf("Backup is for chain ID %d, but the %s network uses chain ID %d", backup.Manifest.ChainID, network.Name, chainID)
+		}
+
+		if err := checkBackupAccounts(backup); err != nil {
+			logFatal(err)
+		}
+
+		util.BackupsStore().ConfirmVerified(archivePath)
+
+		fmt.Println("Backup verified, every current account is included.")
+	},
+}
+
+func init() {
+	backupCmd.AddCommand(backupVerifyCmd)
+}
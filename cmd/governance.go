@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/spf13/cobra"
+)
+
+var governanceCmd = &cobra.Command{
+	Use:   "governance",
+	Short: "Manage GLF governance delegation across wallet accounts and airdrop plans",
+}
+
+// glfHolding is GLF held by a wallet account, either liquid in the account or
+// locked in one of its airdrop plans. Votes of locked GLF are delegated by the
+// plan's voting vault
+type glfHolding struct {
+	Account string
+	Owner   common.Address
+	// PlanID is nil for liquid GLF
+	PlanID   *big.Int
+	Amount   *big.Int
+	Delegate common.Address
+}
+
+func (h glfHolding) Kind() string {
+	if h.PlanID == nil {
+		return "wallet"
+	}
+	return fmt.Sprintf("plan %s", h.PlanID)
+}
+
+// listGLFHoldings returns the liquid GLF and airdrop plans of every wallet
+// account holding GLF, with the delegate of each
+func listGLFHoldings(ctx context.Context, accounts map[string]common.Address) ([]glfHolding, error) {
+	ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+	defer ethClient.Close()
+
+	glf, err := abigen.NewTokenCaller(PoolsSDK.Query().GLF(), ethClient)
+	if err != nil {
+		return nil, err
+	}
+	plansCaller, err := abigen.NewIHedgeyVoteTokenLockupPlanCaller(PoolsSDK.Query().TokenNFTWrapper(), ethClient)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var holdings []glfHolding
+	seen := map[common.Address]bool{}
+	for _, name := range names {
+		owner := accounts[name]
+		if seen[owner] {
+			continue
+		}
+		seen[owner] = true
+
+		balance, err := glf.BalanceOf(opts, owner)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Sign() == 0 {
+			continue
+		}
+		delegate, err := glf.Delegates(opts, owner)
+		if err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, glfHolding{Account: name, Owner: owner, Amount: balance, Delegate: delegate})
+	}
+
+	plans, err := listVestingPlans(ctx, accounts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plans {
+		vault, err := plansCaller.VotingVaults(opts, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		delegate, err := glf.Delegates(opts, vault)
+		if err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, glfHolding{Account: p.Account, Owner: p.Owner, PlanID: p.ID, Amount: p.Plan.Amount, Delegate: delegate})
+	}
+
+	return holdings, nil
+}
+
+// redelegations returns the holdings whose delegate differs from the
+// delegatee chosen for their owner
+func redelegations(holdings []glfHolding, delegatee func(owner common.Address) common.Address) []glfHolding {
+	var changes []glfHolding
+	for _, h := range holdings {
+		if h.Delegate != delegatee(h.Owner) {
+			changes = append(changes, h)
+		}
+	}
+	return changes
+}
+
+func init() {
+	rootCmd.AddCommand(governanceCmd)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var governanceDelegateCmd = &cobra.Command{
+	Use:   "delegate <glif, self or address>",
+	Short: "Delegate the votes of every GLF holding in the wallet to one delegatee",
+	Long: `Delegate the votes of the liquid GLF of every wallet account, and of every airdrop
+plan held by a wallet account, to one delegatee. Holdings that are already delegated
+to the delegatee are skipped. With self, each holding is delegated to the account
+holding it.
+
+Each delegation is signed by the account holding the GLF, GLIF_PASSPHRASE is used
+to unlock every account when set.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			logFatal(err)
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		holdings, err := listGLFHoldings(ctx, walletAccounts())
+		if err != nil {
+			logFatal(err)
+		}
+
+		s.Stop()
+
+		// resolve the delegatee once per owner, self differs per account
+		delegatees := map[common.Address]common.Address{}
+		for _, h := range holdings {
+			if _, ok := delegatees[h.Owner]; ok {
+				continue
+			}
+			delegatees[h.Owner], err = resolveDelegatee(ctx, args[0], h.Owner)
+			if err != nil {
+				logFatal(err)
+			}
+		}
+		delegateeOf := func(owner common.Address) common.Address { return delegatees[owner] }
+
+		changes := redelegations(holdings, delegateeOf)
+		if len(changes) == 0 {
+			fmt.Println("Every GLF holding is already delegated to the delegatee")
+			return
+		}
+
+		tbl := table.New("Account", "Holding", "Amount", "From", "To")
+		for _, h := range changes {
+			tbl.AddRow(h.Account, h.Kind(), fmt.Sprintf("%0.04f GLF", util.ToFIL(h.Amount)), h.Delegate.Hex(), delegateeOf(h.Owner).Hex())
+		}
+		tbl.Print()
+
+		if dryRun {
+			return
+		}
+
+		auths := map[common.Address]*bind.TransactOpts{}
+		var failed []string
+		for _, h := range changes {
+			auth, ok := auths[h.Owner]
+			if !ok {
+				auth, _, err = commonGenericAccountSetup(cmd, h.Owner.Hex())
+				if err != nil {
+					failed = append(failed, fmt.Sprintf("%s %s: %s", h.Account, h.Kind(), err))
+					continue
+				}
+				auths[h.Owner] = auth
+			}
+			// the nonce is fetched per transaction when one account signs several delegations
+			auth.Nonce = nil
+
+			s.Start()
+			tx, err := delegateHolding(auth, h, delegateeOf(h.Owner))
+			if err == nil {
+				_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+			}
+			s.Stop()
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s %s: %s", h.Account, h.Kind(), err))
+				continue
+			}
+
+			fmt.Printf("%s %s delegated to %s\n", h.Account, h.Kind(), delegateeOf(h.Owner).Hex())
+		}
+
+		if len(failed) > 0 {
+			logFatalf("Failed to delegate %d of %d holdings:\n%s", len(failed), len(changes), strings.Join(failed, "\n"))
+		}
+
+		fmt.Printf("Delegated %d holdings.\n", len(changes))
+	},
+}
+
+// delegateHolding delegates the votes of liquid GLF with the token contract,
+// and the votes of an airdrop plan with the plan contract
+func delegateHolding(auth *bind.TransactOpts, h glfHolding, delegatee common.Address) (*types.Transaction, error) {
+	ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+	defer ethClient.Close()
+
+	if h.PlanID == nil {
+		glf, err := abigen.NewTokenTransactor(PoolsSDK.Query().GLF(), ethClient)
+		if err != nil {
+			return nil, err
+		}
+		return glf.Delegate(auth, delegatee)
+	}
+
+	plans, err := abigen.NewIHedgeyVoteTokenLockupPlanTransactor(PoolsSDK.Query().TokenNFTWrapper(), ethClient)
+	if err != nil {
+		return nil, err
+	}
+	return plans.Delegate(auth, h.PlanID, delegatee)
+}
+
+func init() {
+	governanceCmd.AddCommand(governanceDelegateCmd)
+	governanceDelegateCmd.Flags().Bool("dry-run", false, "only show the delegations that would change")
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var governanceDelegatesCmd = &cobra.Command{
+	Use:   "delegates",
+	Short: "Show the delegate of every GLF holding and the voting power of every wallet account",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		accounts := walletAccounts()
+		holdings, err := listGLFHoldings(ctx, accounts)
+		if err != nil {
+			logFatal(err)
+		}
+
+		ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer ethClient.Close()
+
+		glf, err := abigen.NewTokenCaller(PoolsSDK.Query().GLF(), ethClient)
+		if err != nil {
+			logFatal(err)
+		}
+
+		// accounts can be stored under several names, label each address with its first name
+		names := map[common.Address]string{}
+		for name, addr := range accounts {
+			if n, ok := names[addr]; !ok || name < n {
+				names[addr] = name
+			}
+		}
+
+		type accountVotes struct {
+			name  string
+			votes *big.Int
+		}
+		var votes []accountVotes
+		for addr, name := range names {
+			v, err := glf.GetVotes(&bind.CallOpts{Context: ctx}, addr)
+			if err != nil {
+				logFatal(err)
+			}
+			if v.Sign() > 0 {
+				votes = append(votes, accountVotes{name, v})
+			}
+		}
+		sort.Slice(votes, func(i, j int) bool { return votes[i].name < votes[j].name })
+
+		s.Stop()
+
+		delegateName := func(addr common.Address) string {
+			if addr == (common.Address{}) {
+				return "not delegated"
+			}
+			if addr == glifDelegatee() {
+				return "Glif Ltd. " + addr.Hex()
+			}
+			if n, ok := names[addr]; ok {
+				return fmt.Sprintf("%s %s", n, addr.Hex())
+			}
+			return addr.Hex()
+		}
+
+		generateHeader("GLF HOLDINGS")
+		if len(holdings) == 0 {
+			fmt.Println("No GLF held by the accounts in your wallet")
+		} else {
+			tbl := table.New("Account", "Holding", "Amount", "Delegate")
+			for _, h := range holdings {
+				tbl.AddRow(h.Account, h.Kind(), fmt.Sprintf("%0.04f GLF", util.ToFIL(h.Amount)), delegateName(h.Delegate))
+			}
+			tbl.Print()
+		}
+
+		generateHeader("VOTING POWER")
+		if len(votes) == 0 {
+			fmt.Println("No votes are delegated to the accounts in your wallet")
+		} else {
+			tbl := table.New("Account", "Votes")
+			for _, v := range votes {
+				tbl.AddRow(v.name, fmt.Sprintf("%0.04f GLF", util.ToFIL(v.votes)))
+			}
+			tbl.Print()
+		}
+
+		if len(holdings) > 0 {
+			fmt.Println()
+			fmt.Println("Re-delegate every holding with: glif governance delegate <glif, self or address>")
+		}
+	},
+}
+
+func init() {
+	governanceCmd.AddCommand(governanceDelegatesCmd)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedelegations(t *testing.T) {
+	alice := common.HexToAddress("0x01")
+	bob := common.HexToAddress("0x02")
+	glif := common.HexToAddress("0xff")
+
+	holdings := []glfHolding{
+		{Account: "alice", Owner: alice, Amount: fil(10), Delegate: glif},
+		{Account: "alice", Owner: alice, PlanID: big.NewInt(7), Amount: fil(100), Delegate: alice},
+		{Account: "bob", Owner: bob, Amount: fil(5)},
+		{Account: "bob", Owner: bob, PlanID: big.NewInt(9), Amount: fil(50), Delegate: bob},
+	}
+
+	toGlif := redelegations(holdings, func(common.Address) common.Address { return glif })
+	assert.Len(t, toGlif, 3)
+	assert.Equal(t, "plan 7", toGlif[0].Kind())
+	assert.Equal(t, "wallet", toGlif[1].Kind())
+
+	// self delegation only changes holdings delegated elsewhere
+	toSelf := redelegations(holdings, func(owner common.Address) common.Address { return owner })
+	assert.Len(t, toSelf, 2)
+	assert.Equal(t, "alice", toSelf[0].Account)
+	assert.Nil(t, toSelf[0].PlanID)
+	assert.Equal(t, "bob", toSelf[1].Account)
+	assert.Nil(t, toSelf[1].PlanID)
+}
@@ -29,6 +29,7 @@ var iFILTransferCmd = &cobra.Command{
 		if err != nil {
 			logFatalf("Failed to parse address %s", err)
 		}
+		warnUnknownRecipient(strAddr)
 
 		amt := big.NewInt(0)
 		amt, ok := amt.SetString(strAmt, 10)
@@ -92,6 +92,7 @@ var rootInfoCmd = &cobra.Command{
 	Long:  `Prints information about the CLI`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Config directory: %s\n", cfgDir)
+		fmt.Printf("Network: %s\n", network.Name)
 		fmt.Printf("Chain ID: %d\n", chainID)
 		fmt.Printf("Commit hash: %s\n", CommitHash)
 
@@ -33,6 +33,7 @@ var redeemFILCmd = &cobra.Command{
 		if err != nil {
 			logFatal(err)
 		}
+		warnUnknownRecipient(args[1])
 
 		fmt.Printf("Burning %0.09f iFIL to receive wFIL\n", util.ToFIL(amount))
 
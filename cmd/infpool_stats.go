@@ -0,0 +1,434 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// poolSample is the raw state of the Infinity Pool at an epoch, in attoFIL
+type poolSample struct {
+	Epoch               int64    `json:"epoch"`
+	TotalAssets         *big.Int `json:"total_assets"`
+	TotalBorrowed       *big.Int `json:"total_borrowed"`
+	BorrowableLiquidity *big.Int `json:"borrowable_liquidity"`
+	ExitReserve         *big.Int `json:"exit_reserve"`
+	IFILPrice           *big.Int `json:"ifil_price"`
+	IFILSupply          *big.Int `json:"ifil_supply"`
+}
+
+// poolStat is a sample with the metrics computed from it
+type poolStat struct {
+	poolSample
+	Time          time.Time
+	Utilization   float64
+	TotalEarnings *big.Int
+	// APY is annualized from the iFIL price change since the previous sample,
+	// nil for the first sample
+	APY *float64
+}
+
+// poolStatsSummary describes a whole window of samples
+type poolStatsSummary struct {
+	From           int64
+	To             int64
+	APY            *float64
+	MinUtilization float64
+	AvgUtilization float64
+	MaxUtilization float64
+	PriceChange    float64
+}
+
+// sampleEpochs returns the epochs to sample in the window before head,
+// oldest first. Epochs are aligned to multiples of interval so repeated
+// queries hit the cache, and head is always the last sample
+func sampleEpochs(head, window, interval int64) []int64 {
+	from := head - window
+	if from < 0 {
+		from = 0
+	}
+
+	var epochs []int64
+	for e := head - head%interval; e >= from; e -= interval {
+		epochs = append([]int64{e}, epochs...)
+	}
+	if len(epochs) == 0 || epochs[len(epochs)-1] != head {
+		epochs = append(epochs, head)
+	}
+	return epochs
+}
+
+// annualizedAPY compounds the growth of the iFIL price over epochs into a
+// yearly yield in percent
+func annualizedAPY(from, to *big.Int, epochs int64) *float64 {
+	if epochs <= 0 || from.Sign() == 0 {
+		return nil
+	}
+	growth, _ := new(big.Float).Quo(new(big.Float).SetInt(to), new(big.Float).SetInt(from)).Float64()
+	apy := (math.Pow(growth, float64(constants.EpochsInYear)/float64(epochs)) - 1) * 100
+	return &apy
+}
+
+// computePoolStats derives the metrics of every sample, samples must be
+// ordered oldest first
+func computePoolStats(samples []poolSample, chainID int64) []poolStat {
+	stats := make([]poolStat, len(samples))
+	for i, s := range samples {
+		st := poolStat{
+			poolSample: s,
+			Time:       util.EpochHeightToTimestamp(big.NewInt(s.Epoch), big.NewInt(chainID)),
+		}
+		if s.TotalAssets.Sign() > 0 {
+			st.Utilization, _ = new(big.Float).Quo(new(big.Float).SetInt(s.TotalBorrowed), new(big.Float).SetInt(s.TotalAssets)).Float64()
+			st.Utilization *= 100
+		}
+		st.TotalEarnings = new(big.Int).Sub(s.TotalAssets, s.IFILSupply)
+		if i > 0 {
+			st.APY = annualizedAPY(samples[i-1].IFILPrice, s.IFILPrice, s.Epoch-samples[i-1].Epoch)
+		}
+		stats[i] = st
+	}
+	return stats
+}
+
+func summarizePoolStats(stats []poolStat) poolStatsSummary {
+	if len(stats) == 0 {
+		return poolStatsSummary{}
+	}
+	first, last := stats[0], stats[len(stats)-1]
+	sum := poolStatsSummary{
+		From:           first.Epoch,
+		To:             last.Epoch,
+		APY:            annualizedAPY(first.IFILPrice, last.IFILPrice, last.Epoch-first.Epoch),
+		MinUtilization: first.Utilization,
+		MaxUtilization: first.Utilization,
+	}
+	var total float64
+	for _, st := range stats {
+		sum.MinUtilization = math.Min(sum.MinUtilization, st.Utilization)
+		sum.MaxUtilization = math.Max(sum.MaxUtilization, st.Utilization)
+		total += st.Utilization
+	}
+	sum.AvgUtilization = total / float64(len(stats))
+	if first.IFILPrice.Sign() > 0 {
+		change, _ := new(big.Float).Quo(new(big.Float).SetInt(last.IFILPrice), new(big.Float).SetInt(first.IFILPrice)).Float64()
+		sum.PriceChange = (change - 1) * 100
+	}
+	return sum
+}
+
+// fetchPoolSample reads the pool at epoch. Calls at a null round fail, so
+// the epochs before it are tried instead
+func fetchPoolSample(ctx context.Context, pool *abigen.InfinityPoolV2Caller, ifil *abigen.PoolTokenCaller, epoch int64) (poolSample, error) {
+	var err error
+	for attempt := 0; attempt < 5 && epoch-int64(attempt) >= 0; attempt++ {
+		var s poolSample
+		s, err = readPoolSample(ctx, pool, ifil, epoch-int64(attempt))
+		if err == nil {
+			return s, nil
+		}
+		if !strings.Contains(err.Error(), "null round") {
+			break
+		}
+	}
+	return poolSample{}, fmt.Errorf("failed to read the Infinity Pool at epoch %d, historical queries need an archive node: %w", epoch, err)
+}
+
+func readPoolSample(ctx context.Context, pool *abigen.InfinityPoolV2Caller, ifil *abigen.PoolTokenCaller, epoch int64) (poolSample, error) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: big.NewInt(epoch)}
+	s := poolSample{Epoch: epoch}
+
+	var err error
+	if s.TotalAssets, err = pool.TotalAssets(opts); err != nil {
+		return s, err
+	}
+	if s.TotalBorrowed, err = pool.TotalBorrowed(opts); err != nil {
+		return s, err
+	}
+	if s.BorrowableLiquidity, err = pool.TotalBorrowableAssets(opts); err != nil {
+		return s, err
+	}
+	if s.IFILPrice, err = pool.ConvertToAssets(opts, constants.WAD); err != nil {
+		return s, err
+	}
+	if s.IFILSupply, err = ifil.TotalSupply(opts); err != nil {
+		return s, err
+	}
+
+	// the exit reserve is the pool's minimum liquidity, or all of its liquid
+	// assets when it holds less
+	liquid, err := pool.GetLiquidAssets(opts)
+	if err != nil {
+		return s, err
+	}
+	minLiquidity, err := pool.GetAbsMinLiquidity(opts)
+	if err != nil {
+		return s, err
+	}
+	s.ExitReserve = minLiquidity
+	if liquid.Cmp(minLiquidity) < 0 {
+		s.ExitReserve = liquid
+	}
+
+	return s, nil
+}
+
+func formatAPY(apy *float64) string {
+	if apy == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*apy, 'f', 4, 64)
+}
+
+func writePoolStatsCSV(w io.Writer, stats []poolStat) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"date", "epoch", "ifil_price", "total_assets_fil", "total_borrowed_fil", "utilization_pct", "available_liquidity_fil", "exit_reserve_fil", "total_earnings_fil", "apy_pct"})
+	if err != nil {
+		return err
+	}
+	for _, st := range stats {
+		err := cw.Write([]string{
+			st.Time.UTC().Format(time.RFC3339),
+			strconv.FormatInt(st.Epoch, 10),
+			formatFILAmount(st.IFILPrice),
+			formatFILAmount(st.TotalAssets),
+			formatFILAmount(st.TotalBorrowed),
+			strconv.FormatFloat(st.Utilization, 'f', 4, 64),
+			formatFILAmount(st.BorrowableLiquidity),
+			formatFILAmount(st.ExitReserve),
+			formatFILAmount(st.TotalEarnings),
+			formatAPY(st.APY),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type poolStatJSON struct {
+	Time               string   `json:"time"`
+	Epoch              int64    `json:"epoch"`
+	IFILPrice          string   `json:"ifil_price"`
+	TotalAssets        string   `json:"total_assets_fil"`
+	TotalBorrowed      string   `json:"total_borrowed_fil"`
+	Utilization        float64  `json:"utilization_pct"`
+	AvailableLiquidity string   `json:"available_liquidity_fil"`
+	ExitReserve        string   `json:"exit_reserve_fil"`
+	TotalEarnings      string   `json:"total_earnings_fil"`
+	APY                *float64 `json:"apy_pct"`
+}
+
+func writePoolStatsJSON(w io.Writer, stats []poolStat) error {
+	out := make([]poolStatJSON, len(stats))
+	for i, st := range stats {
+		out[i] = poolStatJSON{
+			Time:               st.Time.UTC().Format(time.RFC3339),
+			Epoch:              st.Epoch,
+			IFILPrice:          formatFILAmount(st.IFILPrice),
+			TotalAssets:        formatFILAmount(st.TotalAssets),
+			TotalBorrowed:      formatFILAmount(st.TotalBorrowed),
+			Utilization:        st.Utilization,
+			AvailableLiquidity: formatFILAmount(st.BorrowableLiquidity),
+			ExitReserve:        formatFILAmount(st.ExitReserve),
+			TotalEarnings:      formatFILAmount(st.TotalEarnings),
+			APY:                st.APY,
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func printPoolStats(stats []poolStat) {
+	tbl := table.New("Date", "Epoch", "iFIL price", "Total assets", "Borrowed", "Utilization", "Avail. liquidity", "Exit reserve", "Earnings", "APY")
+	for _, st := range stats {
+		apy := formatAPY(st.APY)
+		if apy != "" {
+			apy += "%"
+		}
+		tbl.AddRow(
+			st.Time.Format(time.DateTime),
+			st.Epoch,
+			fmt.Sprintf("%0.09f", util.ToFIL(st.IFILPrice)),
+			fmt.Sprintf("%0.02f", util.ToFIL(st.TotalAssets)),
+			fmt.Sprintf("%0.02f", util.ToFIL(st.TotalBorrowed)),
+			fmt.Sprintf("%0.02f%%", st.Utilization),
+			fmt.Sprintf("%0.02f", util.ToFIL(st.BorrowableLiquidity)),
+			fmt.Sprintf("%0.02f", util.ToFIL(st.ExitReserve)),
+			fmt.Sprintf("%0.02f", util.ToFIL(st.TotalEarnings)),
+			apy,
+		)
+	}
+	tbl.Print()
+
+	sum := summarizePoolStats(stats)
+	fmt.Println()
+	fmt.Printf("Window: epochs %d to %d\n", sum.From, sum.To)
+	fmt.Printf("iFIL price change: %0.04f%%\n", sum.PriceChange)
+	if sum.APY != nil {
+		fmt.Printf("APY over the window: %0.04f%%\n", *sum.APY)
+	}
+	fmt.Printf("Utilization: min %0.02f%%, avg %0.02f%%, max %0.02f%%\n", sum.MinUtilization, sum.AvgUtilization, sum.MaxUtilization)
+}
+
+var infpoolStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the history of the Infinity Pool's iFIL price, utilization and APY",
+	Long: `Sample the Infinity Pool's total assets, total borrowed, utilization rate, available
+liquidity, exit reserve, earnings and iFIL price at historical epochs over --days,
+one sample every --interval-hours. The APY of each sample is computed from the
+iFIL price change since the previous sample.
+
+Samples older than finality are cached in the cache directory of your config
+directory, so repeated queries only read new epochs from the node. Reading
+historical state requires a node that keeps it, like an archive node.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		days, err := cmd.Flags().GetInt("days")
+		if err != nil {
+			logFatal(err)
+		}
+		intervalHours, err := cmd.Flags().GetInt("interval-hours")
+		if err != nil {
+			logFatal(err)
+		}
+		if days <= 0 || intervalHours <= 0 {
+			logFatal("--days and --interval-hours must be greater than 0")
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			logFatal(err)
+		}
+		if format != "table" && format != "csv" && format != "json" {
+			logFatalf("invalid format %s, must be one of: table, csv, json", format)
+		}
+		noCache, err := cmd.Flags().GetBool("no-cache")
+		if err != nil {
+			logFatal(err)
+		}
+
+		cache := &poolStatsCache{samples: map[string]map[string]poolSample{}}
+		if !noCache {
+			cache, err = loadPoolStatsCache(poolStatsCachePath())
+			if err != nil {
+				logFatal(err)
+			}
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		client, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer client.Close()
+
+		poolAddr := PoolsSDK.Query().InfinityPool()
+		pool, err := abigen.NewInfinityPoolV2Caller(poolAddr, client)
+		if err != nil {
+			logFatal(err)
+		}
+		ifil, err := abigen.NewPoolTokenCaller(PoolsSDK.Query().IFIL(), client)
+		if err != nil {
+			logFatal(err)
+		}
+
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			logFatal(err)
+		}
+		// stay behind the head like the other pool queries
+		latest := int64(head) - constants.ChainHeadLookbackEpochs
+
+		epochs := sampleEpochs(latest, int64(days)*constants.EpochsInDay, int64(intervalHours)*60*constants.EpochsInMinute)
+		samples := make([]poolSample, 0, len(epochs))
+		var fetched int
+		for _, epoch := range epochs {
+			if sample, ok := cache.get(poolAddr.Hex(), epoch); ok {
+				samples = append(samples, sample)
+				continue
+			}
+			sample, err := fetchPoolSample(ctx, pool, ifil, epoch)
+			if err != nil {
+				logFatal(err)
+			}
+			if epoch <= latest-poolStatsFinality {
+				cache.put(poolAddr.Hex(), epoch, sample)
+				fetched++
+			}
+			samples = append(samples, sample)
+		}
+
+		if !noCache && fetched > 0 {
+			if err := cache.save(); err != nil {
+				logFatalf("Failed to save the stats cache %s", err)
+			}
+		}
+
+		stats := computePoolStats(samples, chainID)
+
+		s.Stop()
+
+		var out io.Writer = os.Stdout
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logFatal(err)
+		}
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				logFatal(err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		switch format {
+		case "csv":
+			if err := writePoolStatsCSV(out, stats); err != nil {
+				logFatal(err)
+			}
+		case "json":
+			if err := writePoolStatsJSON(out, stats); err != nil {
+				logFatal(err)
+			}
+		default:
+			printPoolStats(stats)
+		}
+
+		if output != "" {
+			fmt.Printf("Stats written to %s\n", output)
+		}
+	},
+}
+
+func init() {
+	infinitypoolCmd.AddCommand(infpoolStatsCmd)
+	infpoolStatsCmd.Flags().Int("days", 30, "Number of days of history to sample")
+	infpoolStatsCmd.Flags().Int("interval-hours", 24, "Hours between samples")
+	infpoolStatsCmd.Flags().String("format", "table", "Output format: table, csv or json")
+	infpoolStatsCmd.Flags().String("output", "", "Write the stats to a file instead of stdout")
+	infpoolStatsCmd.Flags().Bool("no-cache", false, "Read every sample from the node, without reading or updating the cache")
+}
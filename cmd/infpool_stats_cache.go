@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// poolStatsFinality is how many epochs behind the head a sample must be
+// before it is cached, newer samples can still change in a reorg
+const poolStatsFinality = 900
+
+// poolStatsCache stores Infinity Pool samples on disk, keyed by pool address
+// and the epoch that was requested
+type poolStatsCache struct {
+	path    string
+	samples map[string]map[string]poolSample
+}
+
+func poolStatsCachePath() string {
+	return filepath.Join(cfgDir, "cache", "infinity-pool-stats.json")
+}
+
+// loadPoolStatsCache reads the cache at path, a missing file is an empty cache
+func loadPoolStatsCache(path string) (*poolStatsCache, error) {
+	c := &poolStatsCache{
+		path:    path,
+		samples: map[string]map[string]poolSample{},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.samples); err != nil {
+		return nil, fmt.Errorf("failed to read stats cache %s, delete it to start over: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *poolStatsCache) get(pool string, epoch int64) (poolSample, bool) {
+	s, ok := c.samples[pool][strconv.FormatInt(epoch, 10)]
+	return s, ok
+}
+
+func (c *poolStatsCache) put(pool string, epoch int64, s poolSample) {
+	if c.samples[pool] == nil {
+		c.samples[pool] = map[string]poolSample{}
+	}
+	c.samples[pool][strconv.FormatInt(epoch, 10)] = s
+}
+
+func (c *poolStatsCache) save() error {
+	data, err := json.Marshal(c.samples)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0600)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/glifio/go-pools/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleEpochs(t *testing.T) {
+	assert.Equal(t, []int64{1000, 1100, 1200, 1250}, sampleEpochs(1250, 300, 100))
+	assert.Equal(t, []int64{1000, 1100, 1200}, sampleEpochs(1200, 200, 100))
+	// the window starts before genesis
+	assert.Equal(t, []int64{0, 100, 150}, sampleEpochs(150, 1000, 100))
+	// the window is shorter than the interval
+	assert.Equal(t, []int64{1250}, sampleEpochs(1250, 10, 100))
+}
+
+func TestAnnualizedAPY(t *testing.T) {
+	// a price that grows 10% over a year has a 10% APY
+	apy := annualizedAPY(fil(1), new(big.Int).Div(fil(11), big.NewInt(10)), constants.EpochsInYear)
+	assert.InDelta(t, 10, *apy, 1e-9)
+
+	// 5% over half a year compounds to 10.25%
+	apy = annualizedAPY(fil(100), fil(105), constants.EpochsInYear/2)
+	assert.InDelta(t, 10.25, *apy, 1e-9)
+
+	assert.Nil(t, annualizedAPY(fil(1), fil(1), 0))
+	assert.Nil(t, annualizedAPY(big.NewInt(0), fil(1), 100))
+}
+
+func TestComputePoolStats(t *testing.T) {
+	sample := func(epoch int64, price *big.Int, assets, borrowed int64) poolSample {
+		return poolSample{
+			Epoch:               epoch,
+			TotalAssets:         fil(assets),
+			TotalBorrowed:       fil(borrowed),
+			BorrowableLiquidity: fil(100),
+			ExitReserve:         fil(50),
+			IFILPrice:           price,
+			IFILSupply:          fil(800),
+		}
+	}
+
+	stats := computePoolStats([]poolSample{
+		sample(0, fil(1), 800, 400),
+		sample(constants.EpochsInYear, new(big.Int).Div(fil(12), big.NewInt(10)), 960, 768),
+	}, constants.MainnetChainID)
+
+	assert.Len(t, stats, 2)
+	assert.Nil(t, stats[0].APY)
+	assert.InDelta(t, 50, stats[0].Utilization, 1e-9)
+	assert.Zero(t, stats[0].TotalEarnings.Sign())
+
+	assert.InDelta(t, 20, *stats[1].APY, 1e-9)
+	assert.InDelta(t, 80, stats[1].Utilization, 1e-9)
+	assert.Equal(t, fil(160), stats[1].TotalEarnings)
+
+	sum := summarizePoolStats(stats)
+	assert.Equal(t, int64(0), sum.From)
+	assert.Equal(t, int64(constants.EpochsInYear), sum.To)
+	assert.InDelta(t, 20, *sum.APY, 1e-9)
+	assert.InDelta(t, 20, sum.PriceChange, 1e-9)
+	assert.InDelta(t, 50, sum.MinUtilization, 1e-9)
+	assert.InDelta(t, 65, sum.AvgUtilization, 1e-9)
+	assert.InDelta(t, 80, sum.MaxUtilization, 1e-9)
+
+	var buf bytes.Buffer
+	assert.NoError(t, writePoolStatsCSV(&buf, stats))
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "apy_pct", records[0][9])
+	assert.Equal(t, "", records[1][9])
+	assert.Equal(t, "20.0000", records[2][9])
+	assert.Equal(t, "1.200000000000000000", records[2][2])
+}
+
+func TestPoolStatsCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "stats.json")
+
+	cache, err := loadPoolStatsCache(path)
+	assert.NoError(t, err)
+	_, ok := cache.get("0xpool", 100)
+	assert.False(t, ok)
+
+	// the sample was read at the epoch before a null round
+	s := poolSample{
+		Epoch:               99,
+		TotalAssets:         fil(1000),
+		TotalBorrowed:       fil(500),
+		BorrowableLiquidity: fil(100),
+		ExitReserve:         fil(50),
+		IFILPrice:           fil(1),
+		IFILSupply:          fil(800),
+	}
+	cache.put("0xpool", 100, s)
+	assert.NoError(t, cache.save())
+
+	cache, err = loadPoolStatsCache(path)
+	assert.NoError(t, err)
+	got, ok := cache.get("0xpool", 100)
+	assert.True(t, ok)
+	assert.Equal(t, s, got)
+	_, ok = cache.get("0xother", 100)
+	assert.False(t, ok)
+}
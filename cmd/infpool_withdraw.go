@@ -33,6 +33,7 @@ var withdrawFILCmd = &cobra.Command{
 		if err != nil {
 			logFatal(err)
 		}
+		warnUnknownRecipient(args[1])
 
 		fmt.Printf("Withdrawing %0.09f WFIL from the Infinity Pool\n", util.ToFIL(amount))
 
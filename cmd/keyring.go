@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+// defaultKeyringTTL is how long the keyring keeps keys by default
+const defaultKeyringTTL = 15 * time.Minute
+
+var keyringCmd = &cobra.Command{
+	Use:   "keyring",
+	Short: "Keep unlocked keys in memory so commands don't ask for passphrases",
+	Long: `The keyring holds unlocked keys in memory behind a Unix socket, like ssh-agent.
+While it holds a key, commands sign with it instead of asking for its passphrase.
+Keys are dropped after their TTL, and all of them when the keyring stops.
+
+The socket is keyring.sock in the config directory, or GLIF_KEYRING_SOCK when set.`,
+}
+
+func keyringSocketPath() string {
+	if path := os.Getenv("GLIF_KEYRING_SOCK"); path != "" {
+		return path
+	}
+	return filepath.Join(cfgDir, "keyring.sock")
+}
+
+// keyringTransactor returns transact options that sign with the keyring when
+// one is running and holds the key of addr
+func keyringTransactor(addr common.Address) (*bind.TransactOpts, bool) {
+	client := util.DialKeyring(keyringSocketPath())
+	if has, err := client.Has(addr); err != nil || !has {
+		return nil, false
+	}
+	return client.Transactor(addr, big.NewInt(chainID)), true
+}
+
+// resolveAccount returns the address of an account name or a 0x address and
+// the account name to show for it
+func resolveAccount(as *util.AccountsStorage, nameOrAddr string) (common.Address, string, error) {
+	if strings.HasPrefix(nameOrAddr, "0x") {
+		if !common.IsHexAddress(nameOrAddr) {
+			return common.Address{}, "", fmt.Errorf("invalid address %s", nameOrAddr)
+		}
+		addr := common.HexToAddress(nameOrAddr)
+		if names := accountNamesByAddress(as)[addr]; len(names) > 0 {
+			return addr, names[0], nil
+		}
+		return addr, addr.Hex(), nil
+	}
+
+	addr, _, err := as.GetAddrs(strings.ToLower(nameOrAddr))
+	if err != nil {
+		return common.Address{}, "", fmt.Errorf("account %s not found in wallet: %w", nameOrAddr, err)
+	}
+	return addr, strings.ToLower(nameOrAddr), nil
+}
+
+// passphraseEnv is the environment variable holding the passphrase of the
+// account name, as used by the commands that sign with it
+func passphraseEnv(name string) string {
+	switch name {
+	case string(util.OwnerKey):
+		return "GLIF_OWNER_PASSPHRASE"
+	case string(util.OperatorKey):
+		return "GLIF_OPERATOR_PASSPHRASE"
+	default:
+		return "GLIF_PASSPHRASE"
+	}
+}
+
+// unlockKey decrypts the key of addr from the keystore, taking the passphrase
+// from env, trying an empty one, then asking for it
+func unlockKey(ks *keystore.KeyStore, addr common.Address, name string) (*keystore.Key, error) {
+	account, err := ks.Find(accounts.Account{Address: addr})
+	if err != nil {
+		return nil, fmt.Errorf("no key for %s in the keystore: %w", name, err)
+	}
+
+	passphrase, envSet := os.LookupEnv(passphraseEnv(name))
+	if !envSet {
+		if err := ks.Unlock(account, ""); err != nil {
+			passphrase, err = askPassphrase(fmt.Sprintf("Passphrase for %s", name), addr)
+			if err != nil {
+				return nil, err
+			}
+			if passphrase == "" {
+				return nil, fmt.Errorf("Aborted")
+			}
+		} else if err := ks.Lock(addr); err != nil {
+			return nil, err
+		}
+	}
+
+	keyJSON, err := ks.Export(account, passphrase, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return keystore.DecryptKey(keyJSON, passphrase)
+}
+
+func init() {
+	rootCmd.AddCommand(keyringCmd)
+}
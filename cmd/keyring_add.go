@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var keyringAddCmd = &cobra.Command{
+	Use:   "add [account-name|address...]",
+	Short: "Unlock keys and add them to the keyring",
+	Long: `Unlock keys and add them to the running keyring. Without arguments the owner and
+operator keys are added.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, err := cmd.Flags().GetDuration("ttl")
+		if err != nil {
+			logFatal(err)
+		}
+		if ttl < 0 {
+			logFatal("--ttl can't be negative")
+		}
+
+		client := util.DialKeyring(keyringSocketPath())
+		if _, err := client.List(); err != nil {
+			logFatalf("No keyring running, start one with: glif keyring start (%s)", err)
+		}
+
+		if len(args) == 0 {
+			args = []string{string(util.OwnerKey), string(util.OperatorKey)}
+		}
+
+		as := util.AccountsStore()
+		ks := util.KeyStore()
+		for _, arg := range args {
+			addr, name, err := resolveAccount(as, arg)
+			if err != nil {
+				logFatal(err)
+			}
+			key, err := unlockKey(ks, addr, name)
+			if err != nil {
+				logFatal(err)
+			}
+			if err := client.Add(key.PrivateKey, ttl); err != nil {
+				logFatal(err)
+			}
+			fmt.Printf("Added %s (%s) to the keyring\n", name, addr.Hex())
+		}
+	},
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringAddCmd)
+	keyringAddCmd.Flags().Duration("ttl", 0, "how long to keep the keys, defaults to the keyring's --ttl")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+var keyringListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the keys held by the keyring",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		keys, err := util.DialKeyring(keyringSocketPath()).List()
+		if err != nil {
+			logFatalf("No keyring running, start one with: glif keyring start (%s)", err)
+		}
+		if len(keys) == 0 {
+			fmt.Println("The keyring holds no keys")
+			return
+		}
+
+		names := accountNamesByAddress(util.AccountsStore())
+		tbl := table.New("Account", "Address", "Expires in")
+		for _, k := range keys {
+			name := "-"
+			if n := names[k.Address]; len(n) > 0 {
+				name = n[0]
+			}
+			tbl.AddRow(name, k.Address.Hex(), time.Until(k.Expires).Round(time.Second))
+		}
+		tbl.Print()
+	},
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringListCmd)
+}
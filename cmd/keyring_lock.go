@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var keyringLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Remove every key from the keyring, leaving it running",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := util.DialKeyring(keyringSocketPath()).Lock(); err != nil {
+			logFatal(err)
+		}
+		fmt.Println("Removed every key from the keyring")
+	},
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringLockCmd)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var keyringRemoveCmd = &cobra.Command{
+	Use:   "remove <account-name|address>",
+	Short: "Remove a key from the keyring",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, name, err := resolveAccount(util.AccountsStore(), args[0])
+		if err != nil {
+			logFatal(err)
+		}
+		if err := util.DialKeyring(keyringSocketPath()).Remove(addr); err != nil {
+			logFatal(err)
+		}
+		fmt.Printf("Removed %s (%s) from the keyring\n", name, addr.Hex())
+	},
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringRemoveCmd)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var keyringStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Run the keyring in the foreground",
+	Long: `Run the keyring in the foreground until it is interrupted or stopped with
+glif keyring stop. Add keys to it with glif keyring add.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ttl, err := cmd.Flags().GetDuration("ttl")
+		if err != nil {
+			logFatal(err)
+		}
+		if ttl <= 0 {
+			logFatal("--ttl must be positive")
+		}
+
+		path := keyringSocketPath()
+		l, err := util.ListenKeyring(path)
+		if err != nil {
+			logFatal(err)
+		}
+
+		k := util.NewKeyring(ttl)
+
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-sigs
+			k.Stop()
+		}()
+
+		fmt.Printf("Keyring listening on %s, keys expire after %s\n", path, ttl)
+		if err := k.Serve(l); err != nil {
+			logFatal(err)
+		}
+		fmt.Println("Keyring stopped")
+	},
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringStartCmd)
+	keyringStartCmd.Flags().Duration("ttl", defaultKeyringTTL, "how long keys are kept unless added with their own --ttl")
+}
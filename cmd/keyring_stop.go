@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var keyringStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Remove every key and stop the keyring",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := util.DialKeyring(keyringSocketPath()).Stop(); err != nil {
+			logFatal(err)
+		}
+		fmt.Println("Keyring stopped")
+	},
+}
+
+func init() {
+	keyringCmd.AddCommand(keyringStopCmd)
+}
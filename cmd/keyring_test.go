@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bufio"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/glif/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPassphraseLine(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("owner pass\r\n\noperator pass"))
+
+	pass, err := readPassphraseLine(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner pass", pass)
+
+	// an empty line is an empty passphrase
+	pass, err = readPassphraseLine(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "", pass)
+
+	pass, err = readPassphraseLine(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "operator pass", pass)
+
+	_, err = readPassphraseLine(r)
+	assert.Error(t, err)
+}
+
+func TestPassphraseFromCommand(t *testing.T) {
+	addr := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	pass, err := passphraseFromCommand(`printf '%s:%s\n' "$GLIF_ACCOUNT" "$GLIF_ADDRESS"`, "owner", addr)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner:"+addr.Hex(), pass)
+
+	_, err = passphraseFromCommand("exit 3", "owner", addr)
+	assert.Error(t, err)
+}
+
+func TestKeyringTransactor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyring.sock")
+	t.Setenv("GLIF_KEYRING_SOCK", path)
+	defer func(id int64) { chainID = id }(chainID)
+	chainID = 314
+
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	_, ok := keyringTransactor(addr)
+	assert.False(t, ok, "no keyring running")
+
+	l, err := util.ListenKeyring(path)
+	assert.NoError(t, err)
+	k := util.NewKeyring(time.Minute)
+	go k.Serve(l)
+	defer k.Stop()
+
+	_, ok = keyringTransactor(addr)
+	assert.False(t, ok, "key not in the keyring")
+
+	k.Add(key, 0)
+	auth, ok := keyringTransactor(addr)
+	assert.True(t, ok)
+	assert.Equal(t, addr, auth.From)
+
+	to := common.HexToAddress("0x2")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(chainID),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(1),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	})
+	signed, err := auth.Signer(addr, tx)
+	assert.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(chainID)), signed)
+	assert.NoError(t, err)
+	assert.Equal(t, addr, sender)
+}
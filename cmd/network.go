@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/deploy"
+	types "github.com/glifio/go-pools/types"
+	"github.com/spf13/viper"
+)
+
+// glifNetwork is a network glif can run against
+type glifNetwork struct {
+	Name    string
+	ChainID int64
+	// Extern is nil for networks whose contracts are found from routes.router
+	Extern *types.Extern
+	// FilForwarder is the zero address when the network has no known FilForwarder
+	FilForwarder common.Address
+}
+
+var networkNames = []string{"mainnet", "calibnet", "localnet", "anvil", "custom"}
+
+// network is the network selected for this run
+var network glifNetwork
+
+// networkFlag is set by the --network persistent flag
+var networkFlag string
+
+// networkConfigDir returns the default config directory of a network, mainnet
+// uses ~/.glif and every other network a subdirectory named after it
+func networkConfigDir(home string, name string) string {
+	if name == "mainnet" {
+		return filepath.Join(home, ".glif")
+	}
+	return filepath.Join(home, ".glif", name)
+}
+
+// lookupNetwork returns the network with name. The chain ID of a custom
+// network, and the FilForwarder of any network, are read from the network
+// section of the config
+func lookupNetwork(name string) (glifNetwork, error) {
+	var n glifNetwork
+	switch name {
+	case "mainnet":
+		extern := deploy.Extern
+		n = glifNetwork{Name: "mainnet", ChainID: constants.MainnetChainID, Extern: &extern, FilForwarder: deploy.FilForwarder}
+	case "calibnet":
+		extern := deploy.TestExtern
+		n = glifNetwork{Name: "calibnet", ChainID: constants.CalibnetChainID, Extern: &extern, FilForwarder: deploy.TFilForwarder}
+	case "localnet":
+		n = glifNetwork{Name: "localnet", ChainID: constants.LocalnetChainID, FilForwarder: common.HexToAddress(os.Getenv("GLIF_FIL_FORWARDER"))}
+	case "anvil":
+		n = glifNetwork{Name: "anvil", ChainID: constants.AnvilChainID}
+	case "custom":
+		n = glifNetwork{Name: "custom", ChainID: viper.GetInt64("network.chain-id")}
+		if n.ChainID == 0 {
+			return glifNetwork{}, fmt.Errorf("the custom network requires network.chain-id in your config")
+		}
+	default:
+		return glifNetwork{}, fmt.Errorf("unknown network %s, expected one of %s", name, strings.Join(networkNames, ", "))
+	}
+
+	if fwd := viper.GetString("network.fil-forwarder"); fwd != "" {
+		n.FilForwarder = common.HexToAddress(fwd)
+	}
+
+	return n, nil
+}
+
+// selectNetworkName returns the network to run against: the --network flag,
+// then GLIF_NETWORK, then network.name in the config file in baseDir, then mainnet
+func selectNetworkName(flag string, baseDir string) string {
+	if flag != "" {
+		return strings.ToLower(flag)
+	}
+	if env := os.Getenv("GLIF_NETWORK"); env != "" {
+		return strings.ToLower(env)
+	}
+
+	// the config directory depends on the network, so only the base directory's
+	// config file is checked
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(baseDir, "config.toml"))
+	if err := v.ReadInConfig(); err == nil {
+		if name := v.GetString("network.name"); name != "" {
+			return strings.ToLower(name)
+		}
+	}
+
+	return "mainnet"
+}
+
+// legacyKeyStoreNetwork returns the network whose keys the per-network builds
+// kept in keydir by default: ~/.glif for mainnet and ~/.glif/calibnet for calibnet
+func legacyKeyStoreNetwork(home string, keydir string) (string, bool) {
+	for _, name := range []string{"mainnet", "calibnet"} {
+		if filepath.Clean(keydir) == filepath.Join(networkConfigDir(home, name), "keystore") {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// checkKeyStoreNetwork stops glif from using keys created for another
+// network. A keystore holding keys without a network record is recorded for
+// the network of its legacy default directory, any other is left as is until
+// keys are next created in it
+func checkKeyStoreNetwork(home string, keydir string) error {
+	err := util.CheckKeyStoreNetwork(keydir, network.Name, chainID)
+	if !errors.Is(err, util.ErrKeyStoreNetworkUnknown) {
+		return err
+	}
+
+	name, ok := legacyKeyStoreNetwork(home, keydir)
+	if !ok {
+		return nil
+	}
+	legacy, err := lookupNetwork(name)
+	if err != nil {
+		return err
+	}
+	if err := util.MarkKeyStoreNetwork(keydir, legacy.Name, legacy.ChainID); err != nil {
+		return err
+	}
+	return util.CheckKeyStoreNetwork(keydir, network.Name, chainID)
+}
+
+// recordKeyStoreNetwork records the network in use for the keystore, called
+// by every command that creates or imports keys
+func recordKeyStoreNetwork() error {
+	return util.MarkKeyStoreNetwork(keyStoreDir(), network.Name, chainID)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/deploy"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkConfigDir(t *testing.T) {
+	assert.Equal(t, "/home/sp/.glif", networkConfigDir("/home/sp", "mainnet"))
+	assert.Equal(t, "/home/sp/.glif/calibnet", networkConfigDir("/home/sp", "calibnet"))
+	assert.Equal(t, "/home/sp/.glif/anvil", networkConfigDir("/home/sp", "anvil"))
+}
+
+func TestLookupNetwork(t *testing.T) {
+	mainnet, err := lookupNetwork("mainnet")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(constants.MainnetChainID), mainnet.ChainID)
+	assert.Equal(t, deploy.Extern.LotusDialAddr, mainnet.Extern.LotusDialAddr)
+	assert.Equal(t, deploy.FilForwarder, mainnet.FilForwarder)
+
+	calibnet, err := lookupNetwork("calibnet")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(constants.CalibnetChainID), calibnet.ChainID)
+	assert.Equal(t, deploy.TestExtern.LotusDialAddr, calibnet.Extern.LotusDialAddr)
+
+	anvil, err := lookupNetwork("anvil")
+	assert.NoError(t, err)
+	assert.Nil(t, anvil.Extern)
+	assert.Equal(t, common.Address{}, anvil.FilForwarder)
+
+	_, err = lookupNetwork("custom")
+	assert.Error(t, err)
+
+	_, err = lookupNetwork("devnet")
+	assert.Error(t, err)
+
+	forwarder := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	viper.Set("network.chain-id", 1234)
+	viper.Set("network.fil-forwarder", forwarder.Hex())
+	defer viper.Set("network.chain-id", 0)
+	defer viper.Set("network.fil-forwarder", "")
+
+	custom, err := lookupNetwork("custom")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1234), custom.ChainID)
+	assert.Nil(t, custom.Extern)
+	assert.Equal(t, forwarder, custom.FilForwarder)
+}
+
+func TestSelectNetworkName(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("GLIF_NETWORK", "")
+
+	assert.Equal(t, "mainnet", selectNetworkName("", dir))
+	assert.Equal(t, "anvil", selectNetworkName("Anvil", dir))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), []byte("[network]\nname = 'calibnet'\n"), 0644))
+	assert.Equal(t, "calibnet", selectNetworkName("", dir))
+
+	t.Setenv("GLIF_NETWORK", "localnet")
+	assert.Equal(t, "localnet", selectNetworkName("", dir))
+	assert.Equal(t, "mainnet", selectNetworkName("mainnet", dir))
+}
+
+func TestCheckKeyStoreNetwork(t *testing.T) {
+	home := t.TempDir()
+	newKey := func(keydir string) {
+		ks := keystore.NewKeyStore(keydir, keystore.LightScryptN, keystore.LightScryptP)
+		_, err := ks.NewAccount("")
+		assert.NoError(t, err)
+	}
+	defer func(n glifNetwork, id int64) { network, chainID = n, id }(network, chainID)
+
+	mainnet, err := lookupNetwork("mainnet")
+	assert.NoError(t, err)
+	calibnet, err := lookupNetwork("calibnet")
+	assert.NoError(t, err)
+
+	// the legacy mainnet keystore is recorded as mainnet
+	mainnetDir := filepath.Join(home, ".glif", "keystore")
+	newKey(mainnetDir)
+	network, chainID = mainnet, mainnet.ChainID
+	assert.NoError(t, checkKeyStoreNetwork(home, mainnetDir))
+	assert.FileExists(t, filepath.Join(mainnetDir, ".network"))
+
+	// the legacy calibnet keystore is recorded as calibnet, and then refuses mainnet
+	calibnetDir := filepath.Join(home, ".glif", "calibnet", "keystore")
+	newKey(calibnetDir)
+	assert.Error(t, checkKeyStoreNetwork(home, calibnetDir))
+	network, chainID = calibnet, calibnet.ChainID
+	assert.NoError(t, checkKeyStoreNetwork(home, calibnetDir))
+
+	// any other keystore without a record is used without being marked
+	otherDir := filepath.Join(home, "glif", "keystore")
+	newKey(otherDir)
+	assert.NoError(t, checkKeyStoreNetwork(home, otherDir))
+	assert.NoFileExists(t, filepath.Join(otherDir, ".network"))
+	network, chainID = mainnet, mainnet.ChainID
+	assert.NoError(t, checkKeyStoreNetwork(home, otherDir))
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/viper"
+)
+
+// passphraseFD is the --passphrase-fd flag, -1 when unset
+var passphraseFD int
+
+var (
+	passphraseReaderOnce sync.Once
+	passphraseReader     *bufio.Reader
+)
+
+// readPassphraseLine reads the next line from r without its line ending. A
+// file descriptor can feed several passphrases, one per line, in the order
+// the command asks for them
+func readPassphraseLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		if errors.Is(err, io.EOF) {
+			return "", errors.New("no passphrase left to read")
+		}
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// passphraseFromFD reads the next passphrase from --passphrase-fd
+func passphraseFromFD(fd int) (string, error) {
+	passphraseReaderOnce.Do(func() {
+		passphraseReader = bufio.NewReader(os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd)))
+	})
+	passphrase, err := readPassphraseLine(passphraseReader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase from file descriptor %d: %w", fd, err)
+	}
+	return passphrase, nil
+}
+
+// passphraseCommand returns the password manager command from
+// GLIF_PASSPHRASE_COMMAND or wallet.passphrase-command in config.toml
+func passphraseCommand() string {
+	if command := os.Getenv("GLIF_PASSPHRASE_COMMAND"); command != "" {
+		return command
+	}
+	return viper.GetString("wallet.passphrase-command")
+}
+
+// passphraseFromCommand runs command with the shell and returns what it
+// prints. The account name and address are passed in GLIF_ACCOUNT and
+// GLIF_ADDRESS so one command can serve every key
+func passphraseFromCommand(command string, name string, addr common.Address) (string, error) {
+	c := exec.Command("sh", "-c", command)
+	c.Env = append(os.Environ(), "GLIF_ACCOUNT="+name, "GLIF_ADDRESS="+addr.Hex())
+	c.Stdin = os.Stdin
+	c.Stderr = os.Stderr
+	var out bytes.Buffer
+	c.Stdout = &out
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("passphrase command failed: %w", err)
+	}
+	return strings.TrimRight(out.String(), "\r\n"), nil
+}
+
+// askPassphrase gets the passphrase of addr from --passphrase-fd, the
+// passphrase command, or by prompting with message, in that order
+func askPassphrase(message string, addr common.Address) (string, error) {
+	if passphraseFD >= 0 {
+		return passphraseFromFD(passphraseFD)
+	}
+
+	if command := passphraseCommand(); command != "" {
+		var name string
+		if names := accountNamesByAddress(util.AccountsStore())[addr]; len(names) > 0 {
+			name = names[0]
+		}
+		return passphraseFromCommand(command, name, addr)
+	}
+
+	var passphrase string
+	survey.AskOne(&survey.Password{Message: message}, &passphrase)
+	return passphrase, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&passphraseFD, "passphrase-fd", -1, "read key passphrases from this file descriptor, one per line")
+}
@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/econ"
+	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+type tierAdviceParams struct {
+	currentTier uint8
+	hasCard     bool
+	currentLock *big.Int
+	tierInfos   []abigen.TierInfo
+	// baseRate is the base FIL to GLF conversion rate of the cash back program
+	baseRate *big.Int
+	// glfPrice is the market price of 1 GLF in FIL
+	glfPrice *big.Int
+	// cashBackPercent is in basis points
+	cashBackPercent *big.Int
+	// interest is the interest the agent is projected to pay over the horizon
+	interest        *big.Int
+	days            int
+	mintPrice       *big.Int
+	penaltyFee      *big.Int
+	inPenaltyWindow bool
+	agentDTL        *big.Int
+}
+
+type tierAdvice struct {
+	Tier     uint8
+	MaxDTL   *big.Int
+	Eligible bool
+	// LockChange is the change in locked GLF when switching to this tier
+	LockChange  *big.Int
+	CashBack    *big.Int
+	GLFBurned   *big.Int
+	NetCashBack *big.Int
+	SwitchCost  *big.Int
+	// Benefit is the expected net FIL benefit over the horizon compared to
+	// staying on the current tier
+	Benefit *big.Int
+	// BreakEvenDays is how long it takes the tier to pay back its switch cost,
+	// or -1 if it never does
+	BreakEvenDays float64
+}
+
+// tierNetCashBack returns the FIL earned by the cash back program on tier,
+// the GLF burned to earn it, and the FIL earned less the market value of the
+// GLF burned
+func tierNetCashBack(p tierAdviceParams, tier uint8) (cashBack, glfBurned, net *big.Int) {
+	if tier == 0 {
+		return big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	}
+
+	cashBack = cashBackFor(p.interest, p.cashBackPercent)
+	// matches the SpPlus contract logic: conversionRateWithPremium = filToGlf.rawMulWad(tierInfo.cashBackPremium)
+	glfPerFIL := util.MulWad(p.baseRate, p.tierInfos[tier].CashBackPremium)
+	glfBurned = util.MulWad(cashBack, glfPerFIL)
+	net = new(big.Int).Sub(cashBack, util.MulWad(glfBurned, p.glfPrice))
+	return cashBack, glfBurned, net
+}
+
+func lockAmountForTier(p tierAdviceParams, tier uint8) *big.Int {
+	if tier == 0 {
+		return big.NewInt(0)
+	}
+	return p.tierInfos[tier].TokenLockAmount
+}
+
+// adviseTiers compares every tier against the current one over the horizon
+// in p, pricing GLF costs in FIL at the market price
+func adviseTiers(p tierAdviceParams) []tierAdvice {
+	_, _, currentNet := tierNetCashBack(p, p.currentTier)
+
+	advice := make([]tierAdvice, 0, len(p.tierInfos))
+	for tier := uint8(0); tier < uint8(len(p.tierInfos)); tier++ {
+		a := tierAdvice{
+			Tier:   tier,
+			MaxDTL: getDTLForTier(tier, p.tierInfos),
+		}
+		a.Eligible = p.agentDTL.Cmp(a.MaxDTL) <= 0
+		a.LockChange = new(big.Int).Sub(lockAmountForTier(p, tier), p.currentLock)
+		a.CashBack, a.GLFBurned, a.NetCashBack = tierNetCashBack(p, tier)
+
+		switchGLF := big.NewInt(0)
+		switch {
+		case tier == p.currentTier:
+		case !p.hasCard && tier > 0:
+			switchGLF = p.mintPrice
+		case tier < p.currentTier && p.inPenaltyWindow:
+			refund := new(big.Int).Neg(a.LockChange)
+			switchGLF = new(big.Int).Div(new(big.Int).Mul(refund, p.penaltyFee), big.NewInt(10000))
+		}
+		a.SwitchCost = util.MulWad(switchGLF, p.glfPrice)
+
+		gain := new(big.Int).Sub(a.NetCashBack, currentNet)
+		a.Benefit = new(big.Int).Sub(gain, a.SwitchCost)
+
+		switch {
+		case gain.Sign() <= 0 || p.days <= 0:
+			a.BreakEvenDays = -1
+			if tier == p.currentTier {
+				a.BreakEvenDays = 0
+			}
+		case a.SwitchCost.Sign() == 0:
+			a.BreakEvenDays = 0
+		default:
+			cost, _ := new(big.Float).SetInt(a.SwitchCost).Float64()
+			daily, _ := new(big.Float).Quo(new(big.Float).SetInt(gain), big.NewFloat(float64(p.days))).Float64()
+			a.BreakEvenDays = cost / daily
+		}
+
+		advice = append(advice, a)
+	}
+
+	return advice
+}
+
+// recommendTier returns the eligible tier with the highest expected benefit,
+// staying on the current tier unless another tier is strictly better
+func recommendTier(advice []tierAdvice, current uint8) (uint8, string) {
+	best := current
+	bestBenefit := big.NewInt(0)
+	for _, a := range advice {
+		if !a.Eligible || a.Tier == current {
+			continue
+		}
+		if a.Benefit.Cmp(bestBenefit) > 0 {
+			best = a.Tier
+			bestBenefit = a.Benefit
+		}
+	}
+
+	switch {
+	case best > current:
+		return best, "upgrade"
+	case best < current:
+		return best, "downgrade"
+	default:
+		return best, "stay"
+	}
+}
+
+// plusProgramRates returns the base FIL to GLF conversion rate and the
+// maximum cash back percentage of the GLIF Card program
+func plusProgramRates(ctx context.Context) (*big.Int, *big.Int, error) {
+	client, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer client.Close()
+
+	plus, err := abigen.NewSPPlusCaller(PoolsSDK.Query().SPPlus(), client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+
+	baseRate, err := plus.BaseConversionRateFILtoGLF(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	maxCashBackPercent, err := plus.MaxCashBackPercent(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return baseRate, maxCashBackPercent, nil
+}
+
+var plusAdviseCmd = &cobra.Command{
+	Use:   "advise",
+	Short: "Compare GLIF Card tiers against your Agent's projected interest and recommend a tier",
+	Long: `Compare GLIF Card tiers against your Agent's projected interest payments.
+
+For each tier, the expected cash back over the horizon is priced at the current GLF/FIL market
+price from Sushi, and compared to the current tier after the cost of switching (minting a card,
+or the penalty for downgrading early). Tiers whose max DTL is below your Agent's current DTL
+are not eligible. The horizon defaults to the tier switch penalty window.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		agentAddr, err := getAgentAddressWithFlags(cmd)
+		if err != nil {
+			logFatal(err)
+		}
+
+		days, err := cmd.Flags().GetInt("days")
+		if err != nil {
+			logFatal(err)
+		}
+		if days < 0 {
+			logFatal("days must not be negative")
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		query := PoolsSDK.Query()
+
+		tasks := []util.TaskFunc{
+			func() (interface{}, error) {
+				return query.InfPoolGetAccount(ctx, agentAddr, nil)
+			},
+			func() (interface{}, error) {
+				return query.InfPoolGetRate(ctx)
+			},
+			func() (interface{}, error) {
+				return query.SPPlusTierInfo(ctx, nil)
+			},
+			func() (interface{}, error) {
+				return query.SPPlusMintPrice(ctx, nil)
+			},
+			func() (interface{}, error) {
+				return econ.GetAgentFiFromAPI(agentAddr, PoolsSDK.Extern().GetEventsURL())
+			},
+		}
+		results, err := util.Multiread(tasks)
+		if err != nil {
+			logFatal(err)
+		}
+
+		account := results[0].(abigen.Account)
+		rate := results[1].(*big.Int)
+		tierInfos := results[2].([]abigen.TierInfo)
+		mintPrice := results[3].(*big.Int)
+		afi := results[4].(*econ.AgentFi)
+
+		penaltyWindow, penaltyFee, err := query.SPPlusTierSwitchPenaltyInfo(ctx, nil)
+		if err != nil {
+			logFatal(err)
+		}
+		if days == 0 {
+			days = int(penaltyWindow.Int64() / (24 * 60 * 60))
+		}
+
+		params := tierAdviceParams{
+			currentLock: big.NewInt(0),
+			tierInfos:   tierInfos,
+			interest:    interestForEpochs(account.Principal, rate, big.NewInt(int64(days)*constants.EpochsInDay)),
+			days:        days,
+			mintPrice:   mintPrice,
+			penaltyFee:  penaltyFee,
+			agentDTL:    big.NewInt(0),
+		}
+
+		if lv := afi.LiquidationValue(); lv.Sign() > 0 {
+			params.agentDTL = util.DivWad(afi.Debt(), lv)
+		}
+
+		tokenID, err := getPlusTokenID()
+		if err != nil && !errors.Is(err, errPlusCardNotMinted) {
+			logFatal(err)
+		}
+		if err == nil {
+			info, err := query.SPPlusInfo(ctx, big.NewInt(tokenID), nil)
+			if err != nil {
+				logFatal(err)
+			}
+			_, windowEnd, _, _ := getTierSwitchWindow(info, penaltyWindow)
+
+			params.hasCard = true
+			params.currentTier = info.Tier
+			params.currentLock = info.TierLockAmount
+			params.baseRate = info.BaseConversionRateFILtoGLF
+			params.cashBackPercent = info.PersonalCashBackPercent
+			params.inPenaltyWindow = info.Tier > 0 && windowEnd.After(time.Now())
+		} else {
+			// without a card, compare tiers at the program's default cash back percentage
+			params.baseRate, params.cashBackPercent, err = plusProgramRates(ctx)
+			if err != nil {
+				logFatal(err)
+			}
+		}
+
+		priceFlag := cmd.Flag("glf-price").Value.String()
+		if priceFlag != "" {
+			params.glfPrice, err = parseFILAmount(priceFlag)
+			if err != nil {
+				logFatal(err)
+			}
+		} else {
+			if query.ChainID().Cmp(big.NewInt(constants.MainnetChainID)) != 0 {
+				logFatal("Sushi is only available on Filecoin Mainnet, pass the GLF price in FIL with --glf-price")
+			}
+			oneGLF := new(big.Int).Set(constants.WAD)
+			quote, err := quoteSushiExactInput(ctx, query.GLF(), query.WFIL(), oneGLF)
+			if err != nil {
+				logFatal(err)
+			}
+			params.glfPrice = quote.AmountOut
+		}
+
+		advice := adviseTiers(params)
+		best, action := recommendTier(advice, params.currentTier)
+
+		s.Stop()
+
+		generateHeader("TIER ADVISOR")
+		printTable([]string{
+			"Current tier",
+			"Horizon",
+			fmt.Sprintf("Projected interest (%d days)", days),
+			"Cash back percentage",
+			"GLF price",
+			"Agent DTL",
+		}, []string{
+			tierName(params.currentTier),
+			fmt.Sprintf("%d days", days),
+			fmt.Sprintf("%0.09f FIL", util.ToFIL(params.interest)),
+			fmt.Sprintf("%0.02f%%", float64(params.cashBackPercent.Int64())/100),
+			fmt.Sprintf("1 GLF = %0.09f FIL", util.ToFIL(params.glfPrice)),
+			fmt.Sprintf("%0.02f%%", percBigInt(params.agentDTL)),
+		})
+
+		tbl := table.New("Tier", "Max DTL", "Eligible", "Lock change", "Net cash back", "Switch cost", "Net benefit", "Break-even")
+		for _, a := range advice {
+			eligible := "yes"
+			if !a.Eligible {
+				eligible = "no, DTL too high"
+			}
+			breakEven := "never"
+			switch {
+			case a.Tier == params.currentTier:
+				breakEven = "-"
+			case a.BreakEvenDays == 0:
+				breakEven = "immediate"
+			case a.BreakEvenDays > 0:
+				breakEven = fmt.Sprintf("%0.01f days", a.BreakEvenDays)
+			}
+			tbl.AddRow(
+				tierName(a.Tier),
+				fmt.Sprintf("%0.01f%%", percBigInt(a.MaxDTL)),
+				eligible,
+				fmt.Sprintf("%+0.02f GLF", util.ToFIL(a.LockChange)),
+				fmt.Sprintf("%0.09f FIL", util.ToFIL(a.NetCashBack)),
+				fmt.Sprintf("%0.09f FIL", util.ToFIL(a.SwitchCost)),
+				fmt.Sprintf("%0.09f FIL", util.ToFIL(a.Benefit)),
+				breakEven,
+			)
+		}
+		fmt.Println()
+		tbl.Print()
+		fmt.Println()
+
+		switch action {
+		case "upgrade":
+			if params.hasCard {
+				fmt.Printf("Recommendation: upgrade to %s with: glif plus tiers upgrade %s\n", tierName(best), tierName(best))
+			} else {
+				fmt.Printf("Recommendation: mint a GLIF Card at %s with: glif plus mint %s\n", tierName(best), tierName(best))
+			}
+		case "downgrade":
+			fmt.Printf("Recommendation: downgrade to %s with: glif plus tiers downgrade %s\n", tierName(best), tierName(best))
+		default:
+			fmt.Printf("Recommendation: stay on %s\n", tierName(best))
+		}
+		if params.inPenaltyWindow {
+			fmt.Println("Downgrades before the end of the tier switch window pay a penalty on the GLF refunded, see: glif plus info")
+		}
+		fmt.Println("Locked GLF is returned when downgrading and is not counted as a cost.")
+	},
+}
+
+func init() {
+	plusCmd.AddCommand(plusAdviseCmd)
+	plusAdviseCmd.Flags().String("agent-addr", "", "Agent address")
+	plusAdviseCmd.Flags().Int("days", 0, "number of days to compare tiers over, defaults to the tier switch penalty window")
+	plusAdviseCmd.Flags().String("glf-price", "", "price of 1 GLF in FIL, defaults to the Sushi quote")
+}
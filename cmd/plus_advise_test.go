@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func wadFrac(num, denom int64) *big.Int {
+	return new(big.Int).Div(new(big.Int).Mul(big.NewInt(num), constants.WAD), big.NewInt(denom))
+}
+
+func testTierAdviceParams() tierAdviceParams {
+	return tierAdviceParams{
+		currentLock: big.NewInt(0),
+		tierInfos: []abigen.TierInfo{
+			{CashBackPremium: big.NewInt(0), TokenLockAmount: big.NewInt(0), DebtToLiquidationValue: big.NewInt(0)},
+			{CashBackPremium: wadFrac(100, 100), TokenLockAmount: fil(1000), DebtToLiquidationValue: wadFrac(80, 100)},
+			{CashBackPremium: wadFrac(110, 100), TokenLockAmount: fil(5000), DebtToLiquidationValue: wadFrac(85, 100)},
+			{CashBackPremium: wadFrac(120, 100), TokenLockAmount: fil(10000), DebtToLiquidationValue: wadFrac(90, 100)},
+		},
+		// 100 GLF per FIL at a market price of 0.005 FIL per GLF
+		baseRate:        fil(100),
+		glfPrice:        wadFrac(5, 1000),
+		cashBackPercent: big.NewInt(1000),
+		interest:        fil(1000),
+		days:            90,
+		mintPrice:       fil(1000),
+		penaltyFee:      big.NewInt(850),
+		agentDTL:        wadFrac(50, 100),
+	}
+}
+
+func TestAdviseTiersNoCard(t *testing.T) {
+	p := testTierAdviceParams()
+
+	advice := adviseTiers(p)
+	assert.Len(t, advice, 4)
+
+	// 100 FIL cash back burns 10000 GLF worth 50 FIL on bronze
+	bronze := advice[1]
+	assert.Equal(t, 0, bronze.CashBack.Cmp(fil(100)))
+	assert.Equal(t, 0, bronze.GLFBurned.Cmp(fil(10000)))
+	assert.Equal(t, 0, bronze.NetCashBack.Cmp(fil(50)))
+	// minting costs 1000 GLF, 5 FIL
+	assert.Equal(t, 0, bronze.SwitchCost.Cmp(fil(5)))
+	assert.Equal(t, 0, bronze.Benefit.Cmp(fil(45)))
+	assert.InDelta(t, 9.0, bronze.BreakEvenDays, 0.0001)
+
+	tier, action := recommendTier(advice, p.currentTier)
+	assert.Equal(t, uint8(1), tier)
+	assert.Equal(t, "upgrade", action)
+}
+
+func TestAdviseTiersDTLEligibility(t *testing.T) {
+	p := testTierAdviceParams()
+	p.agentDTL = wadFrac(88, 100)
+
+	advice := adviseTiers(p)
+	assert.False(t, advice[0].Eligible)
+	assert.False(t, advice[1].Eligible)
+	assert.False(t, advice[2].Eligible)
+	assert.True(t, advice[3].Eligible)
+
+	tier, action := recommendTier(advice, p.currentTier)
+	assert.Equal(t, uint8(3), tier)
+	assert.Equal(t, "upgrade", action)
+}
+
+func TestAdviseTiersDowngradePenalty(t *testing.T) {
+	p := testTierAdviceParams()
+	p.hasCard = true
+	p.currentTier = 3
+	p.currentLock = fil(10000)
+	// a small horizon makes the cash back gain smaller than the penalty
+	p.interest = fil(10)
+
+	p.inPenaltyWindow = true
+	advice := adviseTiers(p)
+	// downgrading to bronze refunds 9000 GLF, paying an 8.5% penalty of 765 GLF
+	assert.Equal(t, 0, advice[1].SwitchCost.Cmp(wadFrac(765*5, 1000)))
+	assert.True(t, advice[1].Benefit.Sign() < 0)
+	tier, action := recommendTier(advice, p.currentTier)
+	assert.Equal(t, uint8(3), tier)
+	assert.Equal(t, "stay", action)
+
+	p.inPenaltyWindow = false
+	advice = adviseTiers(p)
+	assert.Equal(t, 0, advice[1].SwitchCost.Sign())
+	tier, action = recommendTier(advice, p.currentTier)
+	assert.Equal(t, uint8(1), tier)
+	assert.Equal(t, "downgrade", action)
+}
+
+func TestAdviseTiersNeverBreaksEven(t *testing.T) {
+	p := testTierAdviceParams()
+	// GLF is worth more than the FIL it buys back, cash back loses money
+	p.glfPrice = wadFrac(2, 100)
+
+	advice := adviseTiers(p)
+	for _, a := range advice[1:] {
+		assert.True(t, a.NetCashBack.Sign() < 0)
+		assert.Equal(t, float64(-1), a.BreakEvenDays)
+	}
+
+	tier, action := recommendTier(advice, p.currentTier)
+	assert.Equal(t, uint8(0), tier)
+	assert.Equal(t, "stay", action)
+}
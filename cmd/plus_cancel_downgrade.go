@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var plusCancelDowngradeCmd = &cobra.Command{
+	Use:   "cancel-downgrade",
+	Short: "Cancel a downgrade scheduled with --when-free",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		pending, err := getPendingTierChange()
+		if err != nil {
+			logFatal(err)
+		}
+		if pending == nil {
+			logFatal("No downgrade is scheduled")
+		}
+
+		err = clearPendingTierChange()
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Scheduled downgrade to %s cancelled.\n", tierName(pending.Tier))
+	},
+}
+
+func init() {
+	plusTiersCmd.AddCommand(plusCancelDowngradeCmd)
+}
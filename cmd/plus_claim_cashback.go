@@ -34,6 +34,7 @@ var plusClaimCashBackCmd = &cobra.Command{
 		if err != nil {
 			logFatal(err)
 		}
+		warnUnknownRecipient(args[0])
 
 		tx, err := PoolsSDK.Act().SPPlusClaimCashBack(ctx, auth, big.NewInt(tokenID), receiver)
 		if err != nil {
@@ -11,14 +11,24 @@ import (
 )
 
 var acceptPenalty bool
+var whenFree bool
 
 var plusDowngradeCmd = &cobra.Command{
 	Use:   "downgrade <new tier: inactive, bronze or silver>",
 	Short: "Downgrade to a lower tier",
-	Args:  cobra.ExactArgs(1),
+	Long: `Downgrade to a lower tier.
+
+Downgrading within the tier switch penalty window costs a penalty on the refunded GLF.
+Pass --when-free to schedule the downgrade instead, autopilot executes it as soon as
+the penalty window ends.`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := cmd.Context()
 
+		if whenFree && acceptPenalty {
+			logFatal("--when-free and --accept-penalty can not be used together")
+		}
+
 		tokenID, err := getPlusTokenID()
 		if err != nil {
 			logFatal(err)
@@ -61,6 +71,22 @@ var plusDowngradeCmd = &cobra.Command{
 
 		windowStart, windowEnd, days, hours := getTierSwitchWindow(info, penaltyWindow)
 
+		if whenFree && refundGlf.Sign() == 1 && windowEnd.After(time.Now()) {
+			err = setPendingTierChange(&pendingTierChange{
+				TokenID:   tokenID,
+				Tier:      tier,
+				Requested: time.Now(),
+			})
+			if err != nil {
+				logFatal(err)
+			}
+			windowEndFormatted := windowEnd.UTC().Format("January 2 2006 15:04")
+			fmt.Printf("Downgrade to %s scheduled for %v UTC (%d days, %d hours)\n", tierName(tier), windowEndFormatted, days, hours)
+			fmt.Println("Autopilot will execute the downgrade once the penalty window ends, make sure it is running with GLIF_OWNER_PASSPHRASE set.")
+			fmt.Println("Cancel with: glif plus tiers cancel-downgrade")
+			return
+		}
+
 		if refundGlf.Sign() == 1 && windowEnd.After(time.Now()) {
 			fmt.Println("Attempting to downgrade early...")
 			windowStartFormatted := windowStart.UTC().Format("January 2 2006 15:04")
@@ -98,18 +124,19 @@ var plusDowngradeCmd = &cobra.Command{
 		s.Start()
 		defer s.Stop()
 
-		tx, err := PoolsSDK.Act().SPPlusDowngrade(ctx, auth, big.NewInt(tokenID), tier, agentAddr, requesterKey)
+		err = downgradeWithAuth(ctx, auth, agentAddr, requesterKey, tokenID, info.Tier, tier)
 		if err != nil {
 			logFatalf("Failed to downgrade tier %s", err)
 		}
 
-		_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+		s.Stop()
+
+		// a scheduled downgrade is superseded by this one
+		err = clearPendingTierChange()
 		if err != nil {
-			logFatalf("Failed to downgrade tier %s", err)
+			logFatal(err)
 		}
 
-		s.Stop()
-
 		fmt.Println("Tier successfully downgraded.")
 		err = printGlfOwnerBalance("GLF balance of owner after downgrade")
 		if err != nil {
@@ -121,4 +148,5 @@ var plusDowngradeCmd = &cobra.Command{
 func init() {
 	plusTiersCmd.AddCommand(plusDowngradeCmd)
 	plusDowngradeCmd.Flags().BoolVar(&acceptPenalty, "accept-penalty", false, "Pay penalty for early downgrade")
+	plusDowngradeCmd.Flags().BoolVar(&whenFree, "when-free", false, "Schedule the downgrade for autopilot to execute when the penalty window ends")
 }
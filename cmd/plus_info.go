@@ -68,6 +68,17 @@ var plusInfoCmd = &cobra.Command{
 			}
 		}
 
+		if tokenIDFlag <= 0 {
+			pending, err := getPendingTierChange()
+			if err != nil {
+				logFatal(err)
+			}
+			if pending != nil && pending.TokenID == tokenID && pending.Tier < info.Tier {
+				fmt.Printf("Scheduled downgrade: %s, executed by autopilot when the penalty window ends\n", tierName(pending.Tier))
+				fmt.Printf("Cancel with: glif plus tiers cancel-downgrade\n")
+			}
+		}
+
 		filVaultBalance, err := PoolsSDK.Query().SPPlusFILVaultBalance(ctx, nil)
 		if err != nil {
 			logFatal(err)
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/glif/v2/util"
+	poolsutil "github.com/glifio/go-pools/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	pendingTierKey          = "plus-pending-tier"
+	pendingTierTokenIDKey   = "plus-pending-tier-token-id"
+	pendingTierRequestedKey = "plus-pending-tier-requested"
+)
+
+// pendingTierChange is a downgrade scheduled with `plus tiers downgrade
+// --when-free`, that autopilot executes once the penalty window has ended
+type pendingTierChange struct {
+	TokenID   int64
+	Tier      uint8
+	Requested time.Time
+}
+
+// tier change actions returned by pendingTierAction
+const (
+	tierChangeWait    = "wait"
+	tierChangeExecute = "execute"
+	tierChangeStale   = "stale"
+)
+
+func getPendingTierChange() (*pendingTierChange, error) {
+	as := util.AgentStore()
+
+	tierStr, err := as.Get(pendingTierKey)
+	if err != nil {
+		var e *util.ErrKeyNotFound
+		if errors.As(err, &e) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if tierStr == "" {
+		return nil, nil
+	}
+
+	tier, err := parseTierName(tierStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s: %w", pendingTierKey, tierStr, err)
+	}
+
+	p := &pendingTierChange{Tier: tier}
+
+	if tokenIDStr, err := as.Get(pendingTierTokenIDKey); err == nil && tokenIDStr != "" {
+		p.TokenID, err = strconv.ParseInt(tokenIDStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", pendingTierTokenIDKey, tokenIDStr, err)
+		}
+	}
+
+	if requested, err := as.Get(pendingTierRequestedKey); err == nil && requested != "" {
+		p.Requested, err = time.Parse(time.RFC3339, requested)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", pendingTierRequestedKey, requested, err)
+		}
+	}
+
+	return p, nil
+}
+
+func setPendingTierChange(p *pendingTierChange) error {
+	as := util.AgentStore()
+	if err := as.Set(pendingTierKey, tierName(p.Tier)); err != nil {
+		return err
+	}
+	if err := as.Set(pendingTierTokenIDKey, strconv.FormatInt(p.TokenID, 10)); err != nil {
+		return err
+	}
+	return as.Set(pendingTierRequestedKey, p.Requested.UTC().Format(time.RFC3339))
+}
+
+func clearPendingTierChange() error {
+	as := util.AgentStore()
+	for _, key := range []string{pendingTierKey, pendingTierTokenIDKey, pendingTierRequestedKey} {
+		if err := as.Delete(key); err != nil {
+			var e *util.ErrKeyNotFound
+			if !errors.As(err, &e) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pendingTierAction decides what to do with a pending downgrade given the
+// Card's current tier and the end of its tier switch penalty window
+func pendingTierAction(p *pendingTierChange, tokenID int64, currentTier uint8, windowEnd, now time.Time) string {
+	// the Card was replaced, or its tier already changed to or below the target
+	if p.TokenID != tokenID || currentTier <= p.Tier {
+		return tierChangeStale
+	}
+	if now.Before(windowEnd) {
+		return tierChangeWait
+	}
+	return tierChangeExecute
+}
+
+// autopilotTierChange executes a pending downgrade once the free window opens
+func autopilotTierChange(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+
+	pending, err := getPendingTierChange()
+	if err != nil || pending == nil {
+		return err
+	}
+
+	tokenID, err := getPlusTokenID()
+	if err != nil {
+		return err
+	}
+
+	info, err := PoolsSDK.Query().SPPlusInfo(ctx, big.NewInt(tokenID), nil)
+	if err != nil {
+		return err
+	}
+
+	penaltyWindow, _, err := PoolsSDK.Query().SPPlusTierSwitchPenaltyInfo(ctx, nil)
+	if err != nil {
+		return err
+	}
+	_, windowEnd, _, _ := getTierSwitchWindow(info, penaltyWindow)
+
+	switch pendingTierAction(pending, tokenID, info.Tier, windowEnd, time.Now()) {
+	case tierChangeStale:
+		log.Printf("Card is %s, dropping pending downgrade to %s", tierName(info.Tier), tierName(pending.Tier))
+		return clearPendingTierChange()
+	case tierChangeWait:
+		log.Printf("Pending downgrade to %s after %s UTC", tierName(pending.Tier), windowEnd.UTC().Format("January 2 2006 15:04"))
+		return nil
+	}
+
+	tierInfos, err := PoolsSDK.Query().SPPlusTierInfo(ctx, nil)
+	if err != nil {
+		return err
+	}
+	refundGlf := new(big.Int).Sub(info.TierLockAmount, tierInfos[pending.Tier].TokenLockAmount)
+	if refundGlf.Sign() == -1 {
+		if err := checkGlfPlusBalanceAndAllowance(new(big.Int).Neg(refundGlf)); err != nil {
+			return fmt.Errorf("unable to downgrade to %s: %w", tierName(pending.Tier), err)
+		}
+	}
+
+	agentAddr, auth, _, requesterKey, err := commonSetupOwnerCall(cmd)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Free downgrade window open, downgrading Card from %s to %s", tierName(info.Tier), tierName(pending.Tier))
+	if err := downgradeWithAuth(ctx, auth, agentAddr, requesterKey, tokenID, info.Tier, pending.Tier); err != nil {
+		return fmt.Errorf("failed to downgrade tier: %w", err)
+	}
+	if refundGlf.Sign() == 1 {
+		log.Printf("%0.09f GLF returned to owner", poolsutil.ToFIL(refundGlf))
+	}
+
+	return clearPendingTierChange()
+}
+
+func downgradeWithAuth(ctx context.Context, auth *bind.TransactOpts, agentAddr common.Address, requesterKey *ecdsa.PrivateKey, tokenID int64, from, to uint8) error {
+	downgradeevt := journal.RegisterEventType("plus", "downgrade")
+	evt := &events.PlusTierChange{
+		TokenID:  fmt.Sprintf("%d", tokenID),
+		FromTier: tierName(from),
+		ToTier:   tierName(to),
+	}
+	defer journal.RecordEvent(downgradeevt, func() interface{} { return evt })
+
+	tx, err := PoolsSDK.Act().SPPlusDowngrade(ctx, auth, big.NewInt(tokenID), to, agentAddr, requesterKey)
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	evt.Tx = tx.Hash().String()
+
+	_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+	if err != nil {
+		evt.Error = err.Error()
+		return err
+	}
+	return nil
+}
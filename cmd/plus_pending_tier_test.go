@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glifio/glif/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPendingTierChangeStore(t *testing.T) {
+	err := util.NewAgentStore(filepath.Join(t.TempDir(), "agent.toml"))
+	assert.NoError(t, err)
+
+	pending, err := getPendingTierChange()
+	assert.NoError(t, err)
+	assert.Nil(t, pending)
+
+	requested := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	err = setPendingTierChange(&pendingTierChange{TokenID: 42, Tier: 1, Requested: requested})
+	assert.NoError(t, err)
+
+	pending, err = getPendingTierChange()
+	assert.NoError(t, err)
+	assert.Equal(t, &pendingTierChange{TokenID: 42, Tier: 1, Requested: requested}, pending)
+
+	err = clearPendingTierChange()
+	assert.NoError(t, err)
+	pending, err = getPendingTierChange()
+	assert.NoError(t, err)
+	assert.Nil(t, pending)
+
+	// clearing with nothing scheduled is not an error
+	assert.NoError(t, clearPendingTierChange())
+}
+
+func TestPendingTierAction(t *testing.T) {
+	now := time.Now()
+	pending := &pendingTierChange{TokenID: 42, Tier: 1}
+
+	assert.Equal(t, tierChangeWait, pendingTierAction(pending, 42, 3, now.Add(time.Hour), now))
+	assert.Equal(t, tierChangeExecute, pendingTierAction(pending, 42, 3, now.Add(-time.Hour), now))
+	assert.Equal(t, tierChangeExecute, pendingTierAction(pending, 42, 2, now, now))
+	// already at or below the scheduled tier
+	assert.Equal(t, tierChangeStale, pendingTierAction(pending, 42, 1, now.Add(-time.Hour), now))
+	assert.Equal(t, tierChangeStale, pendingTierAction(pending, 42, 0, now.Add(-time.Hour), now))
+	// scheduled for a different Card
+	assert.Equal(t, tierChangeStale, pendingTierAction(pending, 7, 3, now.Add(-time.Hour), now))
+}
@@ -35,6 +35,7 @@ var plusWithdrawGlfVaultCmd = &cobra.Command{
 		if err != nil {
 			logFatal(err)
 		}
+		warnUnknownRecipient(args[1])
 
 		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 		s.Start()
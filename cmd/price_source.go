@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// usdPriceSource provides the price of FIL in USD
+type usdPriceSource interface {
+	FILPriceUSD() (float64, error)
+}
+
+// coinGeckoPriceSource fetches the FIL price from the CoinGecko API
+type coinGeckoPriceSource struct{}
+
+func (coinGeckoPriceSource) FILPriceUSD() (float64, error) {
+	return GetFilecoinPriceUSD()
+}
+
+// filePriceSource reads the FIL price from a file, for use offline. The file
+// holds either a plain number, or a CoinGecko simple price response
+type filePriceSource struct {
+	path string
+}
+
+func (f filePriceSource) FILPriceUSD() (float64, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read price file: %v", err)
+	}
+	return parseFILPriceUSD(data)
+}
+
+// parseFILPriceUSD parses a plain number or a CoinGecko simple price response
+func parseFILPriceUSD(data []byte) (float64, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if price, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		if price <= 0 {
+			return 0, fmt.Errorf("invalid FIL price %v", price)
+		}
+		return price, nil
+	}
+
+	var result CoinGeckoResponse
+	if err := json.Unmarshal([]byte(trimmed), &result); err != nil {
+		return 0, fmt.Errorf("failed to parse FIL price, expected a number or a CoinGecko response: %v", err)
+	}
+	if result.Filecoin.USD <= 0 {
+		return 0, fmt.Errorf("no FIL price found")
+	}
+	return result.Filecoin.USD, nil
+}
+
+// newUSDPriceSource returns the price source set by the price.source config
+// key: coingecko (the default) or file, which reads price.file. Relative
+// file paths are resolved from the config directory
+func newUSDPriceSource() (usdPriceSource, error) {
+	switch source := viper.GetString("price.source"); source {
+	case "", "coingecko":
+		return coinGeckoPriceSource{}, nil
+	case "file":
+		path := viper.GetString("price.file")
+		if path == "" {
+			return nil, fmt.Errorf("price.source is file but price.file is not set")
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cfgDir, path)
+		}
+		return filePriceSource{path: path}, nil
+	default:
+		return nil, fmt.Errorf("unknown price.source %q, expected coingecko or file", source)
+	}
+}
+
+// getFILPriceUSD returns the FIL price from the configured price source
+func getFILPriceUSD() (float64, error) {
+	source, err := newUSDPriceSource()
+	if err != nil {
+		return 0, err
+	}
+	return source.FILPriceUSD()
+}
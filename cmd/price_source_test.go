@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFILPriceUSD(t *testing.T) {
+	price, err := parseFILPriceUSD([]byte("3.21\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3.21, price)
+
+	price, err = parseFILPriceUSD([]byte(`{"filecoin":{"usd":4.5}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 4.5, price)
+
+	_, err = parseFILPriceUSD([]byte("0"))
+	assert.Error(t, err)
+
+	_, err = parseFILPriceUSD([]byte(`{"bitcoin":{"usd":1}}`))
+	assert.Error(t, err)
+
+	_, err = parseFILPriceUSD([]byte("not a price"))
+	assert.Error(t, err)
+}
+
+func TestFilePriceSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fil-price")
+	assert.NoError(t, os.WriteFile(path, []byte("5"), 0600))
+
+	price, err := filePriceSource{path: path}.FILPriceUSD()
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, price)
+
+	_, err = filePriceSource{path: filepath.Join(t.TempDir(), "missing")}.FILPriceUSD()
+	assert.Error(t, err)
+}
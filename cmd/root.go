@@ -24,12 +24,12 @@ import (
 	"math/big"
 	"os"
 	"runtime/debug"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	jnal "github.com/glifio/glif/v2/journal"
 	"github.com/glifio/glif/v2/journal/fsjournal"
 	"github.com/glifio/glif/v2/util"
-	"github.com/glifio/go-pools/constants"
 	"github.com/glifio/go-pools/deploy"
 	"github.com/glifio/go-pools/sdk"
 	types "github.com/glifio/go-pools/types"
@@ -39,8 +39,8 @@ import (
 )
 
 var cfgDir string
-var useCalibnet bool // only set in root_calibnet.go
-var chainID int64 = constants.MainnetChainID
+var useCalibnet bool
+var chainID int64
 var PoolsSDK types.PoolsSDK
 var journal jnal.Journal
 
@@ -79,6 +79,9 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&cfgDir, "config-dir", "", "config directory")
+	rootCmd.PersistentFlags().StringVar(&networkFlag, "network", "", "network to use: mainnet, calibnet, localnet, anvil or custom")
+	rootCmd.PersistentFlags().BoolVar(&useCalibnet, "calibnet", false, "use calibnet")
+	rootCmd.PersistentFlags().MarkDeprecated("calibnet", "use --network calibnet instead")
 	rootCmd.PersistentFlags().Float64("gas-premium-multiply", 1.0, "Multiply the default gas premium by this amount")
 	rootCmd.PersistentFlags().Uint64("nonce", 0, "Specify nonce (for replacing transactions)")
 	rootCmd.PersistentFlags().Int64("gas-premium", -1, "(advanced) Override gas premium / priority fee per gas")
@@ -86,22 +89,55 @@ func init() {
 	rootCmd.PersistentFlags().Uint64("gas-fee-cap", 0, "(advanced) Override fee cap / max fee per gas")
 }
 
+// calledCommand resolves the command args run, initConfig runs before cobra
+// hands it over so it is looked up the same way Execute does. It returns nil
+// when args do not name a command
+func calledCommand(args []string) *cobra.Command {
+	cmd, _, err := rootCmd.Find(args)
+	if err != nil {
+		return nil
+	}
+	return cmd
+}
+
+// isSubcommandOf reports whether cmd is parent or one of its subcommands
+func isSubcommandOf(cmd *cobra.Command, parent *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd == parent {
+			return true
+		}
+	}
+	return false
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	if os.Getenv("GLIF_CONFIG_DIR") != "" {
 		cfgDir = os.Getenv("GLIF_CONFIG_DIR")
 	}
+	if useCalibnet && networkFlag == "" {
+		networkFlag = "calibnet"
+	}
+
 	if cfgDir != "" {
 		viper.AddConfigPath(cfgDir)
-	} else {
-		// Find home directory.
-		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
+	}
 
-		cfgDir = fmt.Sprintf("%s/.glif", home)
-		if useCalibnet {
-			cfgDir = fmt.Sprintf("%s/.glif/%s", home, "calibnet")
-		}
+	// Find home directory.
+	home, err := os.UserHomeDir()
+	cobra.CheckErr(err)
+
+	baseDir := cfgDir
+	if baseDir == "" {
+		baseDir = networkConfigDir(home, "mainnet")
+	}
+	networkName := selectNetworkName(networkFlag, baseDir)
+	if !slices.Contains(networkNames, networkName) {
+		logFatalf("Unknown network %s, expected one of %s", networkName, strings.Join(networkNames, ", "))
+	}
+
+	if cfgDir == "" {
+		cfgDir = networkConfigDir(home, networkName)
 
 		// Search config in home directory with name ".glif" (without extension).
 		viper.AddConfigPath(cfgDir)
@@ -110,8 +146,8 @@ func initConfig() {
 
 	viper.SetConfigType("toml")
 	viper.SetConfigName("config")
+	viper.SetConfigPermissions(0600)
 
-	var err error
 	if journal, err = fsjournal.OpenFSJournal(cfgDir, nil); err != nil {
 		logFatal(err)
 	}
@@ -134,6 +170,14 @@ func initConfig() {
 		logFatal(err)
 	}
 
+	if err := util.NewTokenStore(fmt.Sprintf("%s/tokens.toml", cfgDir)); err != nil {
+		logFatal(err)
+	}
+
+	if err := util.NewAddressBookStore(fmt.Sprintf("%s/addressbook.toml", cfgDir)); err != nil {
+		logFatal(err)
+	}
+
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
@@ -141,8 +185,12 @@ func initConfig() {
 		if errors.Is(err, fs.ErrNotExist) {
 			logFatalf("No config file found at %s\n", viper.ConfigFileUsed())
 		} else if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			// No .glif/config.toml, populate with mainnet defaults
-			viper.Set("daemon.rpc-url", deploy.Extern.LotusDialAddr)
+			// No .glif/config.toml, populate with the network's defaults
+			rpcURL := deploy.Extern.LotusDialAddr
+			if networkName == "calibnet" {
+				rpcURL = deploy.TestExtern.LotusDialAddr
+			}
+			viper.Set("daemon.rpc-url", rpcURL)
 			viper.Set("daemon.token", "")
 			viper.SafeWriteConfig()
 		} else {
@@ -152,11 +200,28 @@ func initConfig() {
 
 	viper.WatchConfig()
 
-	if slices.Contains(os.Args[1:], "wallet") &&
-		(slices.Contains(os.Args[1:], "create-agent-accounts") ||
-			slices.Contains(os.Args[1:], "create-account") ||
-			slices.Contains(os.Args[1:], "migrate")) {
+	network, err = lookupNetwork(networkName)
+	if err != nil {
+		logFatal(err)
+	}
+	chainID = network.ChainID
+
+	if err := checkKeyStoreNetwork(home, fmt.Sprintf("%s/keystore", cfgDir)); err != nil {
+		logFatal(err)
+	}
+
+	called := calledCommand(os.Args[1:])
+	if called == createAgentAccountsCmd ||
+		called == createAccountCmd ||
+		called == migrateCmd ||
+		called == walletAuditCmd {
 		// Skip migration check
+	} else if isSubcommandOf(called, backupCmd) {
+		// Skip the backup check, this is how backups get made
+		err = checkWalletMigrated()
+		if err != nil {
+			logFatal(err)
+		}
 	} else {
 		err = checkWalletMigrated()
 		if err != nil {
@@ -180,7 +245,7 @@ func initConfig() {
 	eventsURL := viper.GetString("routes.events-url")
 
 	override := viper.GetBool("routes.override")
-	if override || chainID == constants.LocalnetChainID || chainID == constants.AnvilChainID {
+	if override || network.Extern == nil {
 		routerAddr := viper.GetString("routes.router")
 		router := common.HexToAddress(routerAddr)
 		err := sdk.LazyInit(context.Background(), &PoolsSDK, router, adoURL, "ADO", daemonURL, daemonToken, eventsURL)
@@ -188,15 +253,7 @@ func initConfig() {
 			logFatal(err)
 		}
 	} else {
-		var extern types.Extern
-		switch chainID {
-		case constants.MainnetChainID:
-			extern = deploy.Extern
-		case constants.CalibnetChainID:
-			extern = deploy.TestExtern
-		default:
-			logFatalf("Unknown chain id %d", chainID)
-		}
+		extern := *network.Extern
 
 		if daemonURL != "" {
 			extern.LotusDialAddr = daemonURL
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalledCommand(t *testing.T) {
+	assert.Equal(t, backupCreateCmd, calledCommand([]string{"backup", "create"}))
+	assert.Equal(t, createAccountCmd, calledCommand([]string{"--config-dir", "/tmp/glif", "wallet", "create-account", "backup"}))
+	assert.Equal(t, walletAuditCmd, calledCommand([]string{"wallet", "audit"}))
+
+	assert.True(t, isSubcommandOf(calledCommand([]string{"backup", "verify", "file"}), backupCmd))
+	// an argument named backup does not make a command a backup command
+	assert.False(t, isSubcommandOf(calledCommand([]string{"wallet", "create-account", "backup"}), backupCmd))
+	assert.False(t, isSubcommandOf(calledCommand([]string{"nope"}), backupCmd))
+}
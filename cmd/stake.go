@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/util"
+	"github.com/spf13/cobra"
+)
+
+var stakeCmd = &cobra.Command{
+	Use:   "stake <amount>",
+	Short: "Stake FIL in the Infinity Pool for iFIL",
+	Long: `Deposit FIL into the Infinity Pool and receive iFIL at the current iFIL price.
+The deposit is recorded in the journal, glif stake position uses it to track
+your cost basis and yield.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		from := cmd.Flag("from").Value.String()
+		auth, senderAccount, err := commonGenericAccountSetup(cmd, from)
+		if err != nil {
+			logFatal(err)
+		}
+
+		amount, err := parseFILAmount(args[0])
+		if err != nil {
+			logFatal(err)
+		}
+		if amount.Sign() == 0 {
+			logFatal("Amount must be greater than 0")
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		client, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer client.Close()
+
+		pool, err := abigen.NewInfinityPoolV2Caller(PoolsSDK.Query().InfinityPool(), client)
+		if err != nil {
+			logFatal(err)
+		}
+		ifil, err := abigen.NewPoolTokenCaller(PoolsSDK.Query().IFIL(), client)
+		if err != nil {
+			logFatal(err)
+		}
+
+		opts := &bind.CallOpts{Context: ctx}
+		price, err := PoolsSDK.Query().IFILPrice(ctx, nil)
+		if err != nil {
+			logFatal(err)
+		}
+		expected, err := pool.PreviewDeposit(opts, amount)
+		if err != nil {
+			logFatal(err)
+		}
+		balanceBefore, err := ifil.BalanceOf(opts, senderAccount.Address)
+		if err != nil {
+			logFatal(err)
+		}
+
+		s.Stop()
+
+		fmt.Printf("iFIL price: %0.09f FIL\n", util.ToFIL(price))
+		fmt.Printf("Staking %0.09f FIL for about %0.09f iFIL\n", util.ToFIL(amount), util.ToFIL(expected))
+
+		stakeevt := journal.RegisterEventType("stake", "deposit")
+		evt := &events.StakeDeposit{
+			Account: senderAccount.Address.String(),
+			Amount:  amount.String(),
+		}
+		defer journal.RecordEvent(stakeevt, func() interface{} { return evt })
+
+		s.Start()
+
+		tx, err := PoolsSDK.Act().InfPoolDepositFIL(ctx, auth, senderAccount.Address, amount)
+		if err != nil {
+			evt.Error = err.Error()
+			logFatal(err)
+		}
+		evt.Tx = tx.Hash().String()
+
+		// transaction landed on chain or errored
+		receipt, err := PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+		if err != nil {
+			evt.Error = err.Error()
+			logFatal(err)
+		}
+
+		if receipt == nil {
+			evt.Error = "failed to get receipt"
+			logFatal("Failed to get receipt")
+		}
+
+		if receipt.Status == 0 {
+			evt.Error = "transaction failed"
+			logFatal("Transaction failed")
+		}
+
+		// the deposit is recorded at the previewed amount if the balance read fails
+		received := expected
+		balanceAfter, err := ifil.BalanceOf(opts, senderAccount.Address)
+		if err == nil && balanceAfter.Cmp(balanceBefore) > 0 {
+			received = new(big.Int).Sub(balanceAfter, balanceBefore)
+		}
+		evt.IFIL = received.String()
+
+		s.Stop()
+
+		fmt.Printf("Staked %0.09f FIL and received %0.09f iFIL\n", util.ToFIL(amount), util.ToFIL(received))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stakeCmd)
+	stakeCmd.Flags().String("from", "default", "account to stake from")
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	jnal "github.com/glifio/glif/v2/journal"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// stakePosition is an account's iFIL position, with the cost basis tracked
+// from the stake and unstake history in the journal
+type stakePosition struct {
+	// Deposited is the FIL staked with glif stake
+	Deposited *big.Int
+	// Withdrawn is the FIL received from glif unstake
+	Withdrawn *big.Int
+	// CostBasis is the FIL paid for the tracked iFIL still held
+	CostBasis *big.Int
+	// Tracked is the iFIL held that was received from glif stake
+	Tracked *big.Int
+	// Untracked is the iFIL held with no stake in the journal, e.g. bought or
+	// transferred in, it has no cost basis
+	Untracked *big.Int
+	// Value is the FIL the tracked iFIL is worth at the current price
+	Value *big.Int
+	// Realized is the yield on the tracked iFIL that was unstaked
+	Realized *big.Int
+	// Unrealized is the yield on the tracked iFIL still held
+	Unrealized *big.Int
+}
+
+// stakeEventAmounts returns the amount and ifil fields of a successful stake
+// journal event of account
+func stakeEventAmounts(e jnal.Event, account common.Address) (*big.Int, *big.Int, bool) {
+	data, ok := e.Data.(map[string]interface{})
+	if !ok {
+		return nil, nil, false
+	}
+	if errStr, _ := data["error"].(string); errStr != "" {
+		return nil, nil, false
+	}
+	if tx, _ := data["tx"].(string); tx == "" {
+		return nil, nil, false
+	}
+	if addr, _ := data["account"].(string); !strings.EqualFold(addr, account.String()) {
+		return nil, nil, false
+	}
+	amtStr, _ := data["amount"].(string)
+	amt, ok := new(big.Int).SetString(amtStr, 10)
+	if !ok {
+		return nil, nil, false
+	}
+	ifilStr, _ := data["ifil"].(string)
+	ifil, ok := new(big.Int).SetString(ifilStr, 10)
+	if !ok {
+		return nil, nil, false
+	}
+	return amt, ifil, true
+}
+
+// computeStakePosition replays the stake journal events of account using the
+// average cost method, then values the iFIL balance at price, in wad
+func computeStakePosition(evts []jnal.Event, account common.Address, balance *big.Int, price *big.Int) stakePosition {
+	p := stakePosition{
+		Deposited: big.NewInt(0),
+		Withdrawn: big.NewInt(0),
+		CostBasis: big.NewInt(0),
+		Tracked:   big.NewInt(0),
+		Realized:  big.NewInt(0),
+	}
+
+	for _, e := range evts {
+		if e.System != "stake" {
+			continue
+		}
+		amt, ifil, ok := stakeEventAmounts(e, account)
+		if !ok {
+			continue
+		}
+
+		switch e.Event {
+		case "deposit":
+			p.Deposited.Add(p.Deposited, amt)
+			p.CostBasis.Add(p.CostBasis, amt)
+			p.Tracked.Add(p.Tracked, ifil)
+		case "exit":
+			p.Withdrawn.Add(p.Withdrawn, amt)
+			if p.Tracked.Sign() == 0 || ifil.Sign() == 0 {
+				continue
+			}
+			// only the tracked part of the burned iFIL has a cost basis
+			burned := ifil
+			if burned.Cmp(p.Tracked) > 0 {
+				burned = p.Tracked
+			}
+			cost := new(big.Int).Div(new(big.Int).Mul(p.CostBasis, burned), p.Tracked)
+			received := new(big.Int).Div(new(big.Int).Mul(amt, burned), ifil)
+			p.Realized.Add(p.Realized, new(big.Int).Sub(received, cost))
+			p.CostBasis.Sub(p.CostBasis, cost)
+			p.Tracked.Sub(p.Tracked, burned)
+		}
+	}
+
+	// iFIL that left the account outside of glif unstake takes its share of
+	// the cost basis with it
+	if balance.Cmp(p.Tracked) < 0 {
+		if p.Tracked.Sign() > 0 {
+			p.CostBasis.Div(new(big.Int).Mul(p.CostBasis, balance), p.Tracked)
+		}
+		p.Tracked = new(big.Int).Set(balance)
+	}
+	p.Untracked = new(big.Int).Sub(balance, p.Tracked)
+
+	p.Value = new(big.Int).Div(new(big.Int).Mul(p.Tracked, price), big.NewInt(1e18))
+	p.Unrealized = new(big.Int).Sub(p.Value, p.CostBasis)
+
+	return p
+}
+
+var stakePositionCmd = &cobra.Command{
+	Use:   "position",
+	Short: "Show your iFIL position with its cost basis and yield",
+	Long: `Show the iFIL position of an account, or of every wallet account, with the cost
+basis and realized and unrealized yield tracked from the glif stake and glif unstake
+history in the journal. iFIL received any other way is shown as untracked and has
+no cost basis.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		accounts := walletAccounts()
+		if from := cmd.Flag("from").Value.String(); from != "" {
+			addr, err := AddressOrAccountNameToEVM(ctx, from)
+			if err != nil {
+				logFatal(err)
+			}
+			accounts = map[string]common.Address{from: addr}
+		}
+
+		evts, err := journal.ReadEvents()
+		if err != nil {
+			logFatalf("Failed to read stake history from the journal %s", err)
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		client, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer client.Close()
+
+		ifil, err := abigen.NewPoolTokenCaller(PoolsSDK.Query().IFIL(), client)
+		if err != nil {
+			logFatal(err)
+		}
+		price, err := PoolsSDK.Query().IFILPrice(ctx, nil)
+		if err != nil {
+			logFatal(err)
+		}
+
+		names := make([]string, 0, len(accounts))
+		for name := range accounts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		opts := &bind.CallOpts{Context: ctx}
+		tbl := table.New("Account", "iFIL", "Untracked iFIL", "Cost basis", "Value", "Realized", "Unrealized")
+		var rows int
+		for _, name := range names {
+			balance, err := ifil.BalanceOf(opts, accounts[name])
+			if err != nil {
+				logFatal(err)
+			}
+			p := computeStakePosition(evts, accounts[name], balance, price)
+			if balance.Sign() == 0 && p.Deposited.Sign() == 0 {
+				continue
+			}
+			tbl.AddRow(
+				name,
+				fmt.Sprintf("%0.09f", util.ToFIL(p.Tracked)),
+				fmt.Sprintf("%0.09f", util.ToFIL(p.Untracked)),
+				fmt.Sprintf("%0.09f FIL", util.ToFIL(p.CostBasis)),
+				fmt.Sprintf("%0.09f FIL", util.ToFIL(p.Value)),
+				fmt.Sprintf("%0.09f FIL", util.ToFIL(p.Realized)),
+				fmt.Sprintf("%0.09f FIL", util.ToFIL(p.Unrealized)),
+			)
+			rows++
+		}
+
+		s.Stop()
+
+		fmt.Printf("iFIL price: %0.09f FIL\n\n", util.ToFIL(price))
+		if rows == 0 {
+			fmt.Println("No iFIL positions found, stake with: glif stake <amount>")
+			return
+		}
+		tbl.Print()
+	},
+}
+
+func init() {
+	stakeCmd.AddCommand(stakePositionCmd)
+	stakePositionCmd.Flags().String("from", "", "account to show, defaults to every wallet account")
+}
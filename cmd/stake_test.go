@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	jnal "github.com/glifio/glif/v2/journal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChooseExit(t *testing.T) {
+	// the withdrawal burns iFIL worth more than the FIL requested, redeeming it
+	// pays out the difference
+	plan := chooseExit(exitQuote{
+		Assets:       fil(10),
+		Shares:       fil(9),
+		SharesValue:  new(big.Int).Add(fil(10), big.NewInt(1)),
+		Balance:      fil(20),
+		BalanceValue: fil(22),
+	})
+	assert.Equal(t, exitRedeem, plan.Method)
+	assert.Equal(t, fil(9), plan.Shares)
+	assert.Equal(t, new(big.Int).Add(fil(10), big.NewInt(1)), plan.Assets)
+
+	plan = chooseExit(exitQuote{
+		Assets:       fil(10),
+		Shares:       fil(9),
+		SharesValue:  fil(10),
+		Balance:      fil(20),
+		BalanceValue: fil(22),
+	})
+	assert.Equal(t, exitWithdraw, plan.Method)
+	assert.Equal(t, fil(9), plan.Shares)
+	assert.Equal(t, fil(10), plan.Assets)
+
+	// the whole balance is redeemed rather than leaving dust behind
+	plan = chooseExit(exitQuote{
+		Assets:       fil(22),
+		Shares:       new(big.Int).Add(fil(20), big.NewInt(1)),
+		SharesValue:  fil(22),
+		Balance:      fil(20),
+		BalanceValue: new(big.Int).Sub(fil(22), big.NewInt(1)),
+	})
+	assert.Equal(t, exitRedeem, plan.Method)
+	assert.Equal(t, fil(20), plan.Shares)
+	assert.Equal(t, new(big.Int).Sub(fil(22), big.NewInt(1)), plan.Assets)
+}
+
+func TestComputeStakePosition(t *testing.T) {
+	account := common.HexToAddress("0xabc")
+	other := common.HexToAddress("0xdef")
+	evt := func(event string, data map[string]interface{}) jnal.Event {
+		return jnal.Event{
+			EventType: jnal.EventType{System: "stake", Event: event},
+			Data:      data,
+		}
+	}
+	half := func(n int64) *big.Int {
+		return new(big.Int).Div(fil(n), big.NewInt(2))
+	}
+	price := func(tenths int64) *big.Int {
+		return new(big.Int).Div(fil(tenths), big.NewInt(10))
+	}
+
+	evts := []jnal.Event{
+		evt("deposit", map[string]interface{}{"account": account.String(), "amount": fil(10).String(), "ifil": fil(10).String(), "tx": "0x1"}),
+		evt("deposit", map[string]interface{}{"account": account.String(), "amount": fil(11).String(), "ifil": fil(10).String(), "tx": "0x2"}),
+		// failed
+		evt("deposit", map[string]interface{}{"account": account.String(), "amount": fil(50).String(), "ifil": fil(50).String(), "tx": "0x3", "error": "reverted"}),
+		// another account
+		evt("deposit", map[string]interface{}{"account": other.String(), "amount": fil(50).String(), "ifil": fil(50).String(), "tx": "0x4"}),
+		evt("exit", map[string]interface{}{"account": account.String(), "method": exitRedeem, "amount": fil(12).String(), "ifil": fil(10).String(), "tx": "0x5"}),
+	}
+
+	// 2 iFIL were received outside of glif stake
+	p := computeStakePosition(evts, account, fil(12), price(12))
+	assert.Equal(t, fil(21), p.Deposited)
+	assert.Equal(t, fil(12), p.Withdrawn)
+	assert.Equal(t, fil(10), p.Tracked)
+	assert.Equal(t, fil(2), p.Untracked)
+	assert.Equal(t, half(21), p.CostBasis)
+	assert.Equal(t, half(3), p.Realized)
+	assert.Equal(t, fil(12), p.Value)
+	assert.Equal(t, half(3), p.Unrealized)
+
+	// half of the tracked iFIL was transferred out
+	p = computeStakePosition(evts, account, fil(5), price(12))
+	assert.Equal(t, fil(5), p.Tracked)
+	assert.Zero(t, p.Untracked.Sign())
+	assert.Equal(t, new(big.Int).Div(fil(21), big.NewInt(4)), p.CostBasis)
+	assert.Equal(t, fil(6), p.Value)
+	assert.Equal(t, new(big.Int).Div(fil(3), big.NewInt(4)), p.Unrealized)
+
+	p = computeStakePosition(nil, account, fil(3), price(12))
+	assert.Zero(t, p.Tracked.Sign())
+	assert.Equal(t, fil(3), p.Untracked)
+	assert.Zero(t, p.Unrealized.Sign())
+}
@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/deploy"
+	"github.com/spf13/viper"
+)
+
+// sushiFeeTiers are the Sushi V3 fee tiers in hundredths of a bip
+var sushiFeeTiers = []uint32{500, 3000, 10000}
+
+// sushiFactoryABI is the subset of the V3 factory used to find pools
+const sushiFactoryABI = `[
+	{"inputs":[
+		{"internalType":"address","name":"","type":"address"},
+		{"internalType":"address","name":"","type":"address"},
+		{"internalType":"uint24","name":"","type":"uint24"}
+	],"name":"getPool","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"}
+]`
+
+// sushiRoute is a swap path through one or more Sushi V3 pools, Fees[i] is the
+// fee tier of the pool between Tokens[i] and Tokens[i+1]
+type sushiRoute struct {
+	Tokens []common.Address
+	Fees   []uint32
+}
+
+// sushiRouteQuote is a quote for swapping through a route
+type sushiRouteQuote struct {
+	Route     sushiRoute
+	Pools     []common.Address
+	AmountOut *big.Int
+	// PriceImpact is how much worse the quote is than the pools' mid price
+	// after fees, in percent
+	PriceImpact float64
+}
+
+// encodePath encodes the route as the packed path expected by quoteExactInput
+// and exactInput: token, 3 byte fee, token, ...
+func (r sushiRoute) encodePath() []byte {
+	path := make([]byte, 0, len(r.Tokens)*20+len(r.Fees)*3)
+	for i, token := range r.Tokens {
+		path = append(path, token.Bytes()...)
+		if i < len(r.Fees) {
+			var fee [4]byte
+			binary.BigEndian.PutUint32(fee[:], r.Fees[i])
+			path = append(path, fee[1:]...)
+		}
+	}
+	return path
+}
+
+// String formats the route with token names from names, falling back to the
+// token address
+func (r sushiRoute) String(names map[common.Address]string) string {
+	var b strings.Builder
+	for i, token := range r.Tokens {
+		if name, ok := names[token]; ok {
+			b.WriteString(name)
+		} else {
+			b.WriteString(token.Hex())
+		}
+		if i < len(r.Fees) {
+			fmt.Fprintf(&b, " -(%0.02f%%)-> ", float64(r.Fees[i])/10000)
+		}
+	}
+	return b.String()
+}
+
+// sushiRoutes returns every direct route from tokenIn to tokenOut, and every
+// route with one hop through an intermediate token, across the fee tiers
+func sushiRoutes(tokenIn, tokenOut common.Address, intermediates []common.Address, fees []uint32) []sushiRoute {
+	var routes []sushiRoute
+	for _, fee := range fees {
+		routes = append(routes, sushiRoute{Tokens: []common.Address{tokenIn, tokenOut}, Fees: []uint32{fee}})
+	}
+
+	seen := map[common.Address]bool{tokenIn: true, tokenOut: true}
+	for _, mid := range intermediates {
+		if seen[mid] {
+			continue
+		}
+		seen[mid] = true
+		for _, fee1 := range fees {
+			for _, fee2 := range fees {
+				routes = append(routes, sushiRoute{
+					Tokens: []common.Address{tokenIn, mid, tokenOut},
+					Fees:   []uint32{fee1, fee2},
+				})
+			}
+		}
+	}
+
+	return routes
+}
+
+// sushiRouteTokens returns the tokens routes may hop through: iFIL, plus any
+// token in the sushi.route-tokens config key
+func sushiRouteTokens() []common.Address {
+	tokens := []common.Address{PoolsSDK.Query().IFIL()}
+	for _, t := range viper.GetStringSlice("sushi.route-tokens") {
+		tokens = append(tokens, common.HexToAddress(t))
+	}
+	return tokens
+}
+
+// spotOutPerIn returns the pool's mid price as raw units of the output token
+// per raw unit of the input token. Pools price token1 in token0 as
+// (sqrtPriceX96 / 2^96)^2
+func spotOutPerIn(sqrtPriceX96 *big.Int, tokenIn, tokenOut common.Address) *big.Float {
+	q96 := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 96))
+	sqrtPrice := new(big.Float).Quo(new(big.Float).SetInt(sqrtPriceX96), q96)
+	price := new(big.Float).Mul(sqrtPrice, sqrtPrice)
+	// token0 is the token with the lower address
+	if bytes.Compare(tokenIn.Bytes(), tokenOut.Bytes()) > 0 {
+		return new(big.Float).Quo(big.NewFloat(1), price)
+	}
+	return price
+}
+
+// priceImpact returns how much less amountOut is than amountIn at the mid
+// price after fees, in percent
+func priceImpact(amountIn, amountOut *big.Int, midOutPerIn *big.Float) float64 {
+	ideal := new(big.Float).Mul(new(big.Float).SetInt(amountIn), midOutPerIn)
+	if ideal.Sign() == 0 {
+		return 0
+	}
+	impact := new(big.Float).Sub(ideal, new(big.Float).SetInt(amountOut))
+	impact.Quo(impact, ideal)
+	perc, _ := impact.Mul(impact, big.NewFloat(100)).Float64()
+	return perc
+}
+
+// sushiQuoter quotes routes against the Sushi V3 QuoterV2, looking up and
+// caching the pool behind each hop
+type sushiQuoter struct {
+	client    *ethclient.Client
+	quoterABI *abi.ABI
+	factory   *bind.BoundContract
+	pools     map[string]common.Address
+}
+
+func newSushiQuoter(ctx context.Context, client *ethclient.Client) (*sushiQuoter, error) {
+	quoterABI, err := abigen.QuoterV2MetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quoter ABI %s", err)
+	}
+
+	quoter, err := abigen.NewQuoterV2Caller(deploy.SushiQuoterV2, client)
+	if err != nil {
+		return nil, err
+	}
+	factoryAddr, err := quoter.Factory(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quoter factory %s", err)
+	}
+
+	factoryABI, err := abi.JSON(strings.NewReader(sushiFactoryABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &sushiQuoter{
+		client:    client,
+		quoterABI: quoterABI,
+		factory:   bind.NewBoundContract(factoryAddr, factoryABI, client, client, client),
+		pools:     map[string]common.Address{},
+	}, nil
+}
+
+// pool returns the pool between two tokens for a fee tier, or the zero
+// address when the pool does not exist
+func (q *sushiQuoter) pool(ctx context.Context, tokenA, tokenB common.Address, fee uint32) (common.Address, error) {
+	if bytes.Compare(tokenA.Bytes(), tokenB.Bytes()) > 0 {
+		tokenA, tokenB = tokenB, tokenA
+	}
+	key := fmt.Sprintf("%s:%s:%d", tokenA, tokenB, fee)
+	if pool, ok := q.pools[key]; ok {
+		return pool, nil
+	}
+
+	var out []interface{}
+	if err := q.factory.Call(&bind.CallOpts{Context: ctx}, &out, "getPool", tokenA, tokenB, new(big.Int).SetUint64(uint64(fee))); err != nil {
+		return common.Address{}, fmt.Errorf("failed to get pool %s", err)
+	}
+	q.pools[key] = out[0].(common.Address)
+	return q.pools[key], nil
+}
+
+// quote quotes swapping amount through the route. It returns nil without an
+// error when one of the route's pools does not exist
+func (q *sushiQuoter) quote(ctx context.Context, route sushiRoute, amount *big.Int) (*sushiRouteQuote, error) {
+	opts := &bind.CallOpts{Context: ctx}
+
+	pools := make([]common.Address, len(route.Fees))
+	mid := big.NewFloat(1)
+	for i, fee := range route.Fees {
+		pool, err := q.pool(ctx, route.Tokens[i], route.Tokens[i+1], fee)
+		if err != nil {
+			return nil, err
+		}
+		if pool == (common.Address{}) {
+			return nil, nil
+		}
+		pools[i] = pool
+
+		caller, err := abigen.NewUniswapV3PoolCaller(pool, q.client)
+		if err != nil {
+			return nil, err
+		}
+		slot0, err := caller.Slot0(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get slot0 data %s", err)
+		}
+		afterFee := big.NewFloat(float64(1000000-fee) / 1000000)
+		mid.Mul(mid, spotOutPerIn(slot0.SqrtPriceX96, route.Tokens[i], route.Tokens[i+1]))
+		mid.Mul(mid, afterFee)
+	}
+
+	calldata, err := q.quoterABI.Pack("quoteExactInput", route.encodePath(), amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack quoteExactInput %s", err)
+	}
+	result, err := q.client.CallContract(ctx, ethereum.CallMsg{To: &deploy.SushiQuoterV2, Data: calldata}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call contract: %v", err)
+	}
+	outputs, err := q.quoterABI.Unpack("quoteExactInput", result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack return value: %v", err)
+	}
+
+	// uint256 amountOut,
+	// uint160[] sqrtPriceX96AfterList,
+	// uint32[] initializedTicksCrossedList,
+	// uint256 gasEstimate
+	amountOut := outputs[0].(*big.Int)
+
+	return &sushiRouteQuote{
+		Route:       route,
+		Pools:       pools,
+		AmountOut:   amountOut,
+		PriceImpact: priceImpact(amount, amountOut, mid),
+	}, nil
+}
+
+// quoteSushiRoutes quotes every route, skipping routes through pools that do
+// not exist or cannot fill the amount, best quote first
+func quoteSushiRoutes(ctx context.Context, client *ethclient.Client, routes []sushiRoute, amount *big.Int) ([]*sushiRouteQuote, error) {
+	q, err := newSushiQuoter(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var quotes []*sushiRouteQuote
+	var lastErr error
+	for _, route := range routes {
+		quote, err := q.quote(ctx, route, amount)
+		if err != nil {
+			// the quoter reverts when a pool lacks the liquidity to fill the amount
+			lastErr = err
+			continue
+		}
+		if quote != nil {
+			quotes = append(quotes, quote)
+		}
+	}
+	if len(quotes) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, fmt.Errorf("no Sushi V3 pools route between the tokens")
+	}
+
+	sortRouteQuotes(quotes)
+	return quotes, nil
+}
+
+// sortRouteQuotes orders quotes by amount out, preferring fewer hops on ties
+func sortRouteQuotes(quotes []*sushiRouteQuote) {
+	sort.SliceStable(quotes, func(i, j int) bool {
+		if c := quotes[i].AmountOut.Cmp(quotes[j].AmountOut); c != 0 {
+			return c > 0
+		}
+		return len(quotes[i].Pools) < len(quotes[j].Pools)
+	})
+}
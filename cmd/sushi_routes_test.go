@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	routeTokenA = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	routeTokenB = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	routeTokenC = common.HexToAddress("0x3000000000000000000000000000000000000003")
+)
+
+func TestSushiRouteEncodePath(t *testing.T) {
+	route := sushiRoute{Tokens: []common.Address{routeTokenA, routeTokenC, routeTokenB}, Fees: []uint32{500, 10000}}
+
+	want := "1000000000000000000000000000000000000001" + "0001f4" +
+		"3000000000000000000000000000000000000003" + "002710" +
+		"2000000000000000000000000000000000000002"
+	assert.Equal(t, want, hex.EncodeToString(route.encodePath()))
+}
+
+func TestSushiRouteString(t *testing.T) {
+	route := sushiRoute{Tokens: []common.Address{routeTokenA, routeTokenC, routeTokenB}, Fees: []uint32{500, 3000}}
+	names := map[common.Address]string{routeTokenA: "WFIL", routeTokenB: "GLF"}
+
+	assert.Equal(t, "WFIL -(0.05%)-> "+routeTokenC.Hex()+" -(0.30%)-> GLF", route.String(names))
+}
+
+func TestSushiRoutes(t *testing.T) {
+	fees := []uint32{500, 3000, 10000}
+
+	direct := sushiRoutes(routeTokenA, routeTokenB, nil, fees)
+	assert.Len(t, direct, 3)
+	for i, r := range direct {
+		assert.Equal(t, []common.Address{routeTokenA, routeTokenB}, r.Tokens)
+		assert.Equal(t, []uint32{fees[i]}, r.Fees)
+	}
+
+	// intermediates equal to either end, or repeated, are skipped
+	routes := sushiRoutes(routeTokenA, routeTokenB, []common.Address{routeTokenC, routeTokenA, routeTokenC}, fees)
+	assert.Len(t, routes, 3+9)
+	for _, r := range routes[3:] {
+		assert.Equal(t, []common.Address{routeTokenA, routeTokenC, routeTokenB}, r.Tokens)
+		assert.Len(t, r.Fees, 2)
+	}
+}
+
+func TestSpotOutPerIn(t *testing.T) {
+	// sqrtPriceX96 of 2^96 * 2 prices token1 at 4 token0
+	sqrtPrice := new(big.Int).Lsh(big.NewInt(2), 96)
+
+	zeroForOne, _ := spotOutPerIn(sqrtPrice, routeTokenA, routeTokenB).Float64()
+	assert.InDelta(t, 4, zeroForOne, 1e-9)
+
+	oneForZero, _ := spotOutPerIn(sqrtPrice, routeTokenB, routeTokenA).Float64()
+	assert.InDelta(t, 0.25, oneForZero, 1e-9)
+}
+
+func TestPriceImpact(t *testing.T) {
+	assert.InDelta(t, 0, priceImpact(fil(10), fil(20), big.NewFloat(2)), 1e-9)
+	assert.InDelta(t, 5, priceImpact(fil(10), fil(19), big.NewFloat(2)), 1e-9)
+	assert.Equal(t, float64(0), priceImpact(fil(10), fil(1), big.NewFloat(0)))
+}
+
+func TestSortRouteQuotes(t *testing.T) {
+	twoHop := &sushiRouteQuote{Pools: make([]common.Address, 2), AmountOut: fil(10)}
+	direct := &sushiRouteQuote{Pools: make([]common.Address, 1), AmountOut: fil(10)}
+	worse := &sushiRouteQuote{Pools: make([]common.Address, 1), AmountOut: fil(9)}
+	best := &sushiRouteQuote{Pools: make([]common.Address, 2), AmountOut: fil(11)}
+
+	quotes := []*sushiRouteQuote{worse, twoHop, direct, best}
+	sortRouteQuotes(quotes)
+
+	assert.Equal(t, []*sushiRouteQuote{best, direct, twoHop, worse}, quotes)
+}
@@ -6,10 +6,7 @@ import (
 
 	"github.com/briandowns/spinner"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/glifio/go-pools/abigen"
-	"github.com/glifio/go-pools/util"
-	denoms "github.com/glifio/go-pools/util"
 	"github.com/spf13/cobra"
 )
 
@@ -36,7 +33,7 @@ var wFILNewCmd = &cobra.Command{
 
 // generic methods for ERC20 tokens
 var allowanceFunc = func(cmd *cobra.Command, args []string) {
-	token, tokenAddress := parseToken(cmd)
+	token := parseToken(cmd)
 
 	owner := args[0]
 	spender := args[1]
@@ -61,7 +58,7 @@ var allowanceFunc = func(cmd *cobra.Command, args []string) {
 	}
 	defer client.Close()
 
-	poolToken, err := abigen.NewPoolTokenCaller(tokenAddress, client)
+	poolToken, err := abigen.NewPoolTokenCaller(token.Address, client)
 	if err != nil {
 		logFatalf("Failed to get iFIL balance %s", err)
 	}
@@ -73,12 +70,12 @@ var allowanceFunc = func(cmd *cobra.Command, args []string) {
 
 	s.Stop()
 
-	fmt.Printf("%s allowance for spender: %s on behalf of owner: %s is %.09f\n", token, spender, owner, util.ToFIL(allow))
+	fmt.Printf("%s allowance for spender: %s on behalf of owner: %s is %.09f\n", token, spender, owner, token.ToUnits(allow))
 }
 
 var approveFunc = func(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
-	token, tokenAddress := parseToken(cmd)
+	token := parseToken(cmd)
 
 	from := cmd.Flag("from").Value.String()
 
@@ -102,7 +99,7 @@ var approveFunc = func(cmd *cobra.Command, args []string) {
 		logFatalf("Failed to parse address %s", err)
 	}
 
-	amount, err := parseFILAmount(strAmt)
+	amount, err := token.ParseAmount(strAmt)
 	if err != nil {
 		logFatalf("Failed to parse amount %s", err)
 	}
@@ -111,7 +108,7 @@ var approveFunc = func(cmd *cobra.Command, args []string) {
 	s.Start()
 	defer s.Stop()
 
-	poolTokenTransactor, err := abigen.NewPoolTokenTransactor(tokenAddress, client)
+	poolTokenTransactor, err := abigen.NewPoolTokenTransactor(token.Address, client)
 	if err != nil {
 		logFatalf("Failed to get %s transactor %s", token, err)
 	}
@@ -133,7 +130,7 @@ var approveFunc = func(cmd *cobra.Command, args []string) {
 
 var transferFunc = func(cmd *cobra.Command, args []string) {
 	ctx := cmd.Context()
-	token, tokenAddress := parseToken(cmd)
+	token := parseToken(cmd)
 	from := cmd.Flag("from").Value.String()
 	auth, _, err := commonGenericAccountSetup(cmd, from)
 	if err != nil {
@@ -148,8 +145,9 @@ var transferFunc = func(cmd *cobra.Command, args []string) {
 	if err != nil {
 		logFatalf("Failed to parse address %s", err)
 	}
+	warnUnknownRecipient(strAddr)
 
-	amount, err := parseFILAmount(strAmt)
+	amount, err := token.ParseAmount(strAmt)
 	if err != nil {
 		logFatalf("Failed to parse amount %s", err)
 	}
@@ -164,7 +162,7 @@ var transferFunc = func(cmd *cobra.Command, args []string) {
 	}
 	defer client.Close()
 
-	poolTokenTransactor, err := abigen.NewPoolTokenTransactor(tokenAddress, client)
+	poolTokenTransactor, err := abigen.NewPoolTokenTransactor(token.Address, client)
 	if err != nil {
 		logFatalf("Failed to get %s transactor %s", token, err)
 	}
@@ -187,7 +185,7 @@ var transferFunc = func(cmd *cobra.Command, args []string) {
 
 	s.Stop()
 
-	fmt.Printf("Successfully transferred %0.03f %s from %s to %s!\n", util.ToFIL(amount), token, from, strAddr)
+	fmt.Printf("Successfully transferred %0.03f %s from %s to %s!\n", token.ToUnits(amount), token, from, strAddr)
 }
 
 var transferFromFunc = func(cmd *cobra.Command, args []string) {
@@ -195,7 +193,7 @@ var transferFromFunc = func(cmd *cobra.Command, args []string) {
 	holder := args[0]
 	to := args[1]
 	strAmt := args[2]
-	token, tokenAddress := parseToken(cmd)
+	token := parseToken(cmd)
 	from := cmd.Flag("from").Value.String()
 	auth, _, err := commonGenericAccountSetup(cmd, from)
 	if err != nil {
@@ -213,8 +211,9 @@ var transferFromFunc = func(cmd *cobra.Command, args []string) {
 	if err != nil {
 		logFatalf("Failed to parse to address %s", err)
 	}
+	warnUnknownRecipient(to)
 
-	amount, err := parseFILAmount(strAmt)
+	amount, err := token.ParseAmount(strAmt)
 	if err != nil {
 		logFatalf("Failed to parse amount %s", err)
 	}
@@ -229,7 +228,7 @@ var transferFromFunc = func(cmd *cobra.Command, args []string) {
 	}
 	defer client.Close()
 
-	poolTokenTransactor, err := abigen.NewPoolTokenTransactor(tokenAddress, client)
+	poolTokenTransactor, err := abigen.NewPoolTokenTransactor(token.Address, client)
 	if err != nil {
 		logFatalf("Failed to get %s transactor %s", token, err)
 	}
@@ -252,12 +251,12 @@ var transferFromFunc = func(cmd *cobra.Command, args []string) {
 
 	s.Stop()
 
-	fmt.Printf("Successfully transferred %0.03f %s from %s to %s!\n", util.ToFIL(amount), token, from, to)
+	fmt.Printf("Successfully transferred %0.03f %s from %s to %s!\n", token.ToUnits(amount), token, from, to)
 }
 
 var balanceOfFunc = func(cmd *cobra.Command, args []string) {
 	strAddr := args[0]
-	token, tokenAddress := parseToken(cmd)
+	token := parseToken(cmd)
 
 	fmt.Printf("Checking %s balance of %s...\n", strAddr, token)
 
@@ -276,7 +275,7 @@ var balanceOfFunc = func(cmd *cobra.Command, args []string) {
 	}
 	defer client.Close()
 
-	poolTokenCaller, err := abigen.NewPoolTokenCaller(tokenAddress, client)
+	poolTokenCaller, err := abigen.NewPoolTokenCaller(token.Address, client)
 	if err != nil {
 		logFatalf("Failed to get %s caller %s", token, err)
 	}
@@ -288,7 +287,7 @@ var balanceOfFunc = func(cmd *cobra.Command, args []string) {
 
 	s.Stop()
 
-	fmt.Printf("%s balance of %s is %.09f\n", token, strAddr, util.ToFIL(bal))
+	fmt.Printf("%s balance of %s is %.09f\n", token, strAddr, token.ToUnits(bal))
 }
 
 var supplyFunc = func(cmd *cobra.Command, args []string) {
@@ -296,7 +295,7 @@ var supplyFunc = func(cmd *cobra.Command, args []string) {
 	s.Start()
 	defer s.Stop()
 
-	token, tokenAddress := parseToken(cmd)
+	token := parseToken(cmd)
 
 	client, err := PoolsSDK.Extern().ConnectEthClient()
 	if err != nil {
@@ -304,7 +303,7 @@ var supplyFunc = func(cmd *cobra.Command, args []string) {
 	}
 	defer client.Close()
 
-	poolTokenCaller, err := abigen.NewPoolTokenCaller(tokenAddress, client)
+	poolTokenCaller, err := abigen.NewPoolTokenCaller(token.Address, client)
 	if err != nil {
 		logFatalf("Failed to get %s caller %s", token, err)
 	}
@@ -314,7 +313,7 @@ var supplyFunc = func(cmd *cobra.Command, args []string) {
 		logFatalf("Failed to get %s supply %s", token, err)
 	}
 
-	supplyFIL, _ := denoms.ToFIL(supply).Float64()
+	supplyFIL, _ := token.ToUnits(supply).Float64()
 
 	s.Stop()
 
@@ -373,16 +372,22 @@ func createCommand(cmd *cobra.Command) *cobra.Command {
 	}
 }
 
-func parseToken(cmd *cobra.Command) (string, common.Address) {
+// parseToken returns the token of a command from its parent, or from the
+// --token flag for commands under erc20
+func parseToken(cmd *cobra.Command) erc20Token {
+	var nameOrAddr string
 	switch cmd.Parent().Use {
-	case "ifil":
-		return "iFIL", PoolsSDK.Query().IFIL()
-	case "glf":
-		return "GLF", PoolsSDK.Query().GLF()
-	case "wfil":
-		return "wFIL", PoolsSDK.Query().WFIL()
-	}
-	return "", common.HexToAddress("0x0000000000000000000000000000000000000000")
+	case "ifil", "glf", "wfil":
+		nameOrAddr = cmd.Parent().Use
+	default:
+		nameOrAddr = cmd.Flag("token").Value.String()
+	}
+
+	token, err := resolveToken(cmd.Context(), nameOrAddr)
+	if err != nil {
+		logFatal(err)
+	}
+	return token
 }
 
 func init() {
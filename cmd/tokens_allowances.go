@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// approvalTopic is the topic of the ERC-20 Approval(owner, spender, value) event
+var approvalTopic = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+
+// logQueryRange is the most epochs queried for logs at once, nodes reject
+// larger ranges
+const logQueryRange = 2000
+
+// tokenAllowance is a non-zero allowance granted by a wallet account
+type tokenAllowance struct {
+	Account string
+	Owner   common.Address
+	Token   erc20Token
+	Spender common.Address
+	Amount  *big.Int
+}
+
+// knownSpenders returns the GLIF and Sushi contracts wallet accounts approve,
+// labelled by name
+func knownSpenders() map[common.Address]string {
+	query := PoolsSDK.Query()
+	return map[common.Address]string{
+		query.InfinityPool(): "Infinity Pool",
+		query.SPPlus():       "GLIF+",
+		query.LPPlus():       "GLIF+ LP",
+		query.Router():       "GLIF Router",
+		sushiSwapRouter():    "Sushi Router",
+	}
+}
+
+func spenderLabel(spender common.Address, known map[common.Address]string) string {
+	if label, ok := known[spender]; ok {
+		return fmt.Sprintf("%s %s", label, spender.Hex())
+	}
+	return spender.Hex()
+}
+
+// formatAllowance formats an allowance in whole tokens, or as unlimited for
+// the max approvals granted by most apps
+func formatAllowance(token erc20Token, amount *big.Int) string {
+	unlimited := new(big.Int).Lsh(big.NewInt(1), 255)
+	if amount.Cmp(unlimited) >= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%0.04f %s", token.ToUnits(amount), token)
+}
+
+// logRanges splits the lookback epochs before head into inclusive ranges of
+// at most size epochs, oldest first
+func logRanges(head, lookback, size uint64) [][2]uint64 {
+	from := uint64(0)
+	if head > lookback {
+		from = head - lookback
+	}
+
+	var ranges [][2]uint64
+	for start := from; start <= head; start += size {
+		end := start + size - 1
+		if end > head {
+			end = head
+		}
+		ranges = append(ranges, [2]uint64{start, end})
+	}
+	return ranges
+}
+
+// approvalSpenders returns the spenders approved per owner in Approval logs
+func approvalSpenders(logs []types.Log) map[common.Address][]common.Address {
+	spenders := map[common.Address][]common.Address{}
+	seen := map[[2]common.Address]bool{}
+	for _, l := range logs {
+		if len(l.Topics) < 3 || l.Topics[0] != approvalTopic {
+			continue
+		}
+		owner := common.BytesToAddress(l.Topics[1].Bytes())
+		spender := common.BytesToAddress(l.Topics[2].Bytes())
+		if seen[[2]common.Address{owner, spender}] {
+			continue
+		}
+		seen[[2]common.Address{owner, spender}] = true
+		spenders[owner] = append(spenders[owner], spender)
+	}
+	return spenders
+}
+
+// allowanceTokens returns iFIL, WFIL, GLF and every token in the registry
+func allowanceTokens() []erc20Token {
+	var tokens []erc20Token
+	for _, name := range builtinTokenNames {
+		t, _ := builtinToken(name)
+		tokens = append(tokens, t)
+	}
+	return append(tokens, registeredTokens()...)
+}
+
+// listAllowances returns the non-zero allowances the accounts granted for the
+// tokens, to the known spenders and to every spender approved in the last
+// lookback epochs
+func listAllowances(ctx context.Context, accounts map[string]common.Address, tokens []erc20Token, lookback uint64) ([]tokenAllowance, error) {
+	client, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var owners []common.Address
+	ownerNames := map[common.Address]string{}
+	for _, name := range names {
+		if _, ok := ownerNames[accounts[name]]; !ok {
+			ownerNames[accounts[name]] = name
+			owners = append(owners, accounts[name])
+		}
+	}
+	if len(owners) == 0 {
+		return nil, nil
+	}
+
+	tokenAddrs := make([]common.Address, len(tokens))
+	for i, t := range tokens {
+		tokenAddrs[i] = t.Address
+	}
+	ownerTopics := make([]common.Hash, len(owners))
+	for i, o := range owners {
+		ownerTopics[i] = common.BytesToHash(o.Bytes())
+	}
+
+	// spenders are checked per owner and token, a spender approved for one
+	// token is checked for all of them
+	var logs []types.Log
+	if lookback > 0 {
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range logRanges(head, lookback, logQueryRange) {
+			found, err := client.FilterLogs(ctx, ethereum.FilterQuery{
+				FromBlock: new(big.Int).SetUint64(r[0]),
+				ToBlock:   new(big.Int).SetUint64(r[1]),
+				Addresses: tokenAddrs,
+				Topics:    [][]common.Hash{{approvalTopic}, ownerTopics},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get approval logs: %w", err)
+			}
+			logs = append(logs, found...)
+		}
+	}
+	approved := approvalSpenders(logs)
+
+	known := make([]common.Address, 0)
+	for spender := range knownSpenders() {
+		known = append(known, spender)
+	}
+	sort.Slice(known, func(i, j int) bool { return known[i].Hex() < known[j].Hex() })
+
+	opts := &bind.CallOpts{Context: ctx}
+	var allowances []tokenAllowance
+	for _, t := range tokens {
+		caller, err := abigen.NewPoolTokenCaller(t.Address, client)
+		if err != nil {
+			return nil, err
+		}
+		for _, owner := range owners {
+			spenders := append(append([]common.Address{}, known...), approved[owner]...)
+			checked := map[common.Address]bool{}
+			for _, spender := range spenders {
+				if checked[spender] {
+					continue
+				}
+				checked[spender] = true
+
+				amount, err := caller.Allowance(opts, owner, spender)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get %s allowance: %w", t, err)
+				}
+				if amount.Sign() > 0 {
+					allowances = append(allowances, tokenAllowance{
+						Account: ownerNames[owner],
+						Owner:   owner,
+						Token:   t,
+						Spender: spender,
+						Amount:  amount,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(allowances, func(i, j int) bool { return allowances[i].Account < allowances[j].Account })
+	return allowances, nil
+}
+
+func printAllowances(allowances []tokenAllowance) {
+	known := knownSpenders()
+	tbl := table.New("Account", "Token", "Spender", "Allowance")
+	for _, a := range allowances {
+		tbl.AddRow(a.Account, a.Token, spenderLabel(a.Spender, known), formatAllowance(a.Token, a.Amount))
+	}
+	tbl.Print()
+}
+
+var tokensAllowancesCmd = &cobra.Command{
+	Use:   "allowances",
+	Short: "List the outstanding token allowances of every wallet account",
+	Long: `List the non-zero iFIL, WFIL, GLF and registered token allowances of every wallet
+account. Allowances are checked against the GLIF contracts (Infinity Pool, GLIF+,
+the GLIF Router) and the Sushi router, and against every spender approved in
+Approval logs from the last --lookback epochs.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		lookback, err := cmd.Flags().GetUint64("lookback")
+		if err != nil {
+			logFatal(err)
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		allowances, err := listAllowances(cmd.Context(), walletAccounts(), allowanceTokens(), lookback)
+		if err != nil {
+			logFatal(err)
+		}
+
+		s.Stop()
+
+		if len(allowances) == 0 {
+			fmt.Println("No outstanding allowances found for the accounts in your wallet")
+			return
+		}
+
+		printAllowances(allowances)
+
+		fmt.Println()
+		fmt.Println("Revoke allowances with: glif tokens revoke")
+	},
+}
+
+func init() {
+	tokensCmd.AddCommand(tokensAllowancesCmd)
+	tokensAllowancesCmd.Flags().Uint64("lookback", 2880, "epochs of Approval logs to search for spenders, 0 only checks the known contracts")
+}
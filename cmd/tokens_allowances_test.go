@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRanges(t *testing.T) {
+	assert.Equal(t, [][2]uint64{{7000, 8999}, {9000, 10000}}, logRanges(10000, 3000, 2000))
+	assert.Equal(t, [][2]uint64{{0, 100}}, logRanges(100, 3000, 2000))
+	assert.Equal(t, [][2]uint64{{8000, 9999}, {10000, 10000}}, logRanges(10000, 2000, 2000))
+}
+
+func TestApprovalSpenders(t *testing.T) {
+	owner := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	spenderA := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	spenderB := common.HexToAddress("0x3000000000000000000000000000000000000003")
+
+	approval := func(topic common.Hash, spender common.Address) types.Log {
+		return types.Log{Topics: []common.Hash{topic, common.BytesToHash(owner.Bytes()), common.BytesToHash(spender.Bytes())}}
+	}
+
+	logs := []types.Log{
+		approval(approvalTopic, spenderA),
+		approval(approvalTopic, spenderB),
+		approval(approvalTopic, spenderA),
+		// not an approval
+		approval(common.HexToHash("0x01"), spenderB),
+		{Topics: []common.Hash{approvalTopic}},
+	}
+
+	assert.Equal(t, map[common.Address][]common.Address{owner: {spenderA, spenderB}}, approvalSpenders(logs))
+}
+
+func TestFormatAllowance(t *testing.T) {
+	glf := erc20Token{Symbol: "GLF", Decimals: 18}
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	assert.Equal(t, "unlimited", formatAllowance(glf, maxUint256))
+	assert.Equal(t, "12.5000 GLF", formatAllowance(glf, new(big.Int).Div(fil(125), big.NewInt(10))))
+}
+
+func TestFilterAllowances(t *testing.T) {
+	glf := erc20Token{Symbol: "GLF", Address: common.HexToAddress("0x01")}
+	ifil := erc20Token{Symbol: "iFIL", Address: common.HexToAddress("0x02")}
+	pool := common.HexToAddress("0x03")
+	plus := common.HexToAddress("0x04")
+
+	allowances := []tokenAllowance{
+		{Account: "owner", Token: glf, Spender: plus, Amount: fil(1)},
+		{Account: "owner", Token: ifil, Spender: pool, Amount: fil(1)},
+		{Account: "savings", Token: glf, Spender: pool, Amount: fil(1)},
+	}
+
+	assert.Equal(t, allowances, filterAllowances(allowances, "", nil, nil))
+	assert.Equal(t, allowances[:2], filterAllowances(allowances, "owner", nil, nil))
+	assert.Equal(t, []tokenAllowance{allowances[0], allowances[2]}, filterAllowances(allowances, "", &glf, nil))
+	assert.Equal(t, []tokenAllowance{allowances[2]}, filterAllowances(allowances, "", &glf, &pool))
+	assert.Empty(t, filterAllowances(allowances, "savings", &ifil, nil))
+}
@@ -10,14 +10,13 @@ import (
 	"time"
 
 	"github.com/briandowns/spinner"
-	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/glifio/go-pools/abigen"
 	"github.com/glifio/go-pools/constants"
-	"github.com/glifio/go-pools/deploy"
-	token "github.com/glifio/go-pools/token"
 	"github.com/glifio/go-pools/util"
+	"github.com/rodaine/table"
 	"github.com/spf13/cobra"
 )
 
@@ -53,6 +52,10 @@ func GetFilecoinPriceUSD() (float64, error) {
 var getPriceCmd = &cobra.Command{
 	Use:   "price",
 	Short: "Get the current price of $GLF in FIL from Sushi V3 on FEVM",
+	Long: `Get the current price of $GLF in FIL from Sushi V3 on FEVM.
+
+The price is quoted for swapping 1 GLF and 1 FIL through the best route, picked the
+same way as the quote command across every fee tier and intermediate token.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if PoolsSDK.Query().ChainID().Cmp(big.NewInt(constants.MainnetChainID)) != 0 {
 			logFatalf("Sushi is only available on Filecoin Mainnet")
@@ -63,42 +66,34 @@ var getPriceCmd = &cobra.Command{
 		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 		s.Start()
 
-		client, err := PoolsSDK.Extern().ConnectEthClient()
-		if err != nil {
-			logFatalf("Failed to connect to Ethereum client: %s", err)
-		}
-		defer client.Close()
-
 		// Get the current price of Filecoin in USD
-		filecoinPriceUSD, filecoinUSDPriceErr := GetFilecoinPriceUSD()
-		if err != filecoinUSDPriceErr {
+		filecoinPriceUSD, filecoinUSDPriceErr := getFILPriceUSD()
+		if filecoinUSDPriceErr != nil {
 			log.Printf("Failed to get Filecoin price, skipping GLF/USD price calculation: %s", filecoinUSDPriceErr)
 		}
 
-		// Connect to the Uniswap V3 Pool contract
-		pool, err := abigen.NewUniswapV3PoolCaller(deploy.SushiGLFWFILPool, client)
+		one := new(big.Int).Set(constants.WAD)
+		glfFIL, err := quoteSushiExactInput(ctx, PoolsSDK.Query().GLF(), PoolsSDK.Query().WFIL(), one)
 		if err != nil {
-			logFatalf("Failed to instantiate pool caller %s", err)
+			logFatalf("Failed to quote GLF/FIL %s", err)
 		}
-
-		// Get the current price sqrt from slot0
-		slot0, err := pool.Slot0(&bind.CallOpts{Context: ctx})
+		filGLF, err := quoteSushiExactInput(ctx, PoolsSDK.Query().WFIL(), PoolsSDK.Query().GLF(), one)
 		if err != nil {
-			logFatalf("Failed to get slot0 data %s", err)
+			logFatalf("Failed to quote FIL/GLF %s", err)
 		}
 
 		s.Stop()
 
-		priceGLF := token.GLFToFIL(slot0.SqrtPriceX96)
+		priceGLF := util.ToFIL(glfFIL.AmountOut)
 		priceGLFUSD := new(big.Float).Mul(priceGLF, big.NewFloat(filecoinPriceUSD))
 
 		if filecoinUSDPriceErr == nil {
-			fmt.Printf("Current price of GLF/FIL: 1 GLF ≈ %0.08f FIL ($%0.02f USD)\n", token.GLFToFIL(slot0.SqrtPriceX96), priceGLFUSD)
+			fmt.Printf("Current price of GLF/FIL: 1 GLF ≈ %0.08f FIL ($%0.02f USD)\n", priceGLF, priceGLFUSD)
 		} else {
-			fmt.Printf("Current price of GLF/FIL: 1 GLF ≈ %0.08f FIL\n", token.GLFToFIL(slot0.SqrtPriceX96))
+			fmt.Printf("Current price of GLF/FIL: 1 GLF ≈ %0.08f FIL\n", priceGLF)
 		}
 
-		fmt.Printf("Current price of FIL/GLF: 1 FIL ≈ %0.08f GLF\n", token.FILToGLF(slot0.SqrtPriceX96))
+		fmt.Printf("Current price of FIL/GLF: 1 FIL ≈ %0.08f GLF\n", util.ToFIL(filGLF.AmountOut))
 	},
 }
 
@@ -112,30 +107,45 @@ const (
 var quoteCmd = &cobra.Command{
 	Use:   "quote <path> <amount>",
 	Short: "Get the amount of token1 that would be received for swapping a `amount` of token0 from Sushi V3. Path is either: fil:glf or glf:fil",
-	Args:  cobra.ExactArgs(2),
+	Long: `Get the amount of token1 that would be received for swapping a ` + "`amount`" + ` of token0 from Sushi V3. Path is either: fil:glf or glf:fil
+
+Every Sushi V3 fee tier (0.05%, 0.3% and 1%) is probed, directly and through one
+intermediate token (iFIL, plus any token in sushi.route-tokens), and the route
+returning the most is picked. The price impact of each route, and the liquidity
+of the pools on the best route, are shown.`,
+	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		if PoolsSDK.Query().ChainID().Cmp(big.NewInt(constants.MainnetChainID)) != 0 {
 			logFatalf("Sushi is only available on Filecoin Mainnet")
 		}
 
+		ctx := cmd.Context()
+
 		var tokenIn, tokenOut common.Address
+		var symbolIn, symbolOut string
 		path := QuotePath(args[0])
 		switch path {
 		case QuotePathFILGLF:
-			tokenIn = PoolsSDK.Query().WFIL()
-			tokenOut = PoolsSDK.Query().GLF()
+			tokenIn, tokenOut = PoolsSDK.Query().WFIL(), PoolsSDK.Query().GLF()
+			symbolIn, symbolOut = "FIL", "GLF"
 		case QuotePathGLFFIL:
-			tokenIn = PoolsSDK.Query().GLF()
-			tokenOut = PoolsSDK.Query().WFIL()
+			tokenIn, tokenOut = PoolsSDK.Query().GLF(), PoolsSDK.Query().WFIL()
+			symbolIn, symbolOut = "GLF", "FIL"
+		default:
+			logFatalf("Invalid path %s, expected fil:glf or glf:fil", args[0])
 		}
 
 		amount, err := parseFILAmount(args[1])
 		if err != nil {
 			logFatalf("Failed to parse amount %s", err)
 		}
+		if amount.Sign() <= 0 {
+			logFatal("Amount must be greater than 0")
+		}
 
 		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 		s.Start()
+		defer s.Stop()
 
 		client, err := PoolsSDK.Extern().ConnectEthClient()
 		if err != nil {
@@ -143,59 +153,173 @@ var quoteCmd = &cobra.Command{
 		}
 		defer client.Close()
 
-		quoteParams := abigen.IQuoterV2QuoteExactInputSingleParams{
-			TokenIn:           tokenIn,
-			TokenOut:          tokenOut,
-			AmountIn:          amount,
-			Fee:               big.NewInt(3000),
-			SqrtPriceLimitX96: big.NewInt(0),
-		}
-
-		quoterABI, err := abigen.QuoterV2MetaData.GetAbi()
+		routes := sushiRoutes(tokenIn, tokenOut, sushiRouteTokens(), sushiFeeTiers)
+		quotes, err := quoteSushiRoutes(ctx, client, routes, amount)
 		if err != nil {
-			logFatalf("Failed to get quoter ABI %s", err)
+			logFatal(err)
 		}
-
-		calldata, err := quoterABI.Pack("quoteExactInputSingle", quoteParams)
-		if err != nil {
-			logFatalf("Failed to pack quoteExactInputSingle %s", err)
+		best := quotes[0]
+
+		tokens := map[common.Address]*sushiToken{}
+		for _, q := range quotes {
+			for _, t := range q.Route.Tokens {
+				if _, ok := tokens[t]; !ok {
+					if tokens[t], err = getSushiToken(ctx, client, t); err != nil {
+						logFatal(err)
+					}
+				}
+			}
+		}
+		names := map[common.Address]string{}
+		for addr, t := range tokens {
+			names[addr] = t.Symbol
 		}
 
-		// Step 5: Execute the eth_call
-		callMsg := ethereum.CallMsg{
-			To:   &deploy.SushiQuoterV2,
-			Data: calldata,
+		depths := make([]*sushiPoolDepth, 0, len(best.Pools))
+		for _, pool := range best.Pools {
+			depth, err := getSushiPoolDepth(ctx, client, pool)
+			if err != nil {
+				logFatal(err)
+			}
+			depths = append(depths, depth)
 		}
 
-		result, err := client.CallContract(context.Background(), callMsg, nil)
-		if err != nil {
-			log.Fatalf("Failed to call contract: %v", err)
+		filPriceUSD, filPriceErr := getFILPriceUSD()
+
+		s.Stop()
+
+		generateHeader("ROUTES")
+		tbl := table.New("Route", "Amount out", "Price impact")
+		for _, q := range quotes {
+			tbl.AddRow(q.Route.String(names), fmt.Sprintf("%0.06f %s", util.ToFIL(q.AmountOut), symbolOut), fmt.Sprintf("%0.04f%%", q.PriceImpact))
+		}
+		tbl.Print()
+
+		generateHeader("POOL LIQUIDITY")
+		tbl = table.New("Pool", "Fee", "Liquidity", "Reserves")
+		for _, d := range depths {
+			tbl.AddRow(
+				d.Pool.Hex(),
+				fmt.Sprintf("%0.02f%%", float64(d.Fee)/10000),
+				d.Liquidity.String(),
+				fmt.Sprintf("%s, %s", tokens[d.Token0].format(d.Reserve0), tokens[d.Token1].format(d.Reserve1)),
+			)
+		}
+		tbl.Print()
+		fmt.Println()
+
+		fmt.Printf("for %0.04f %s, you would receive approximately %0.06f %s through %s\n", util.ToFIL(amount), symbolIn, util.ToFIL(best.AmountOut), symbolOut, best.Route.String(names))
+		fmt.Printf("the price impact would be %0.04f%%\n", best.PriceImpact)
+		if filPriceErr == nil {
+			filAmount := amount
+			if path == QuotePathGLFFIL {
+				filAmount = best.AmountOut
+			}
+			usd, _ := new(big.Float).Mul(util.ToFIL(filAmount), big.NewFloat(filPriceUSD)).Float64()
+			fmt.Printf("the swap is worth approximately $%0.02f USD\n", usd)
+		} else {
+			log.Printf("Failed to get Filecoin price, skipping USD value: %s", filPriceErr)
 		}
+	},
+}
+
+// sushiToken is an ERC-20 token on a Sushi route
+type sushiToken struct {
+	Symbol   string
+	Decimals uint8
+}
+
+func (t *sushiToken) format(amount *big.Int) string {
+	unit := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals)), nil))
+	return fmt.Sprintf("%0.04f %s", new(big.Float).Quo(new(big.Float).SetInt(amount), unit), t.Symbol)
+}
+
+func getSushiToken(ctx context.Context, client *ethclient.Client, addr common.Address) (*sushiToken, error) {
+	if addr == PoolsSDK.Query().WFIL() {
+		return &sushiToken{Symbol: "WFIL", Decimals: 18}, nil
+	}
 
-		// Step 6: Decode the return value
-		outputs, err := quoterABI.Unpack("quoteExactInputSingle", result)
+	caller, err := abigen.NewPoolTokenCaller(addr, client)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	symbol, err := caller.Symbol(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get symbol of %s: %v", addr, err)
+	}
+	decimals, err := caller.Decimals(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decimals of %s: %v", addr, err)
+	}
+	return &sushiToken{Symbol: symbol, Decimals: decimals}, nil
+}
+
+// sushiPoolDepth is the liquidity held by a Sushi V3 pool
+type sushiPoolDepth struct {
+	Pool      common.Address
+	Fee       uint32
+	Token0    common.Address
+	Token1    common.Address
+	Liquidity *big.Int
+	Reserve0  *big.Int
+	Reserve1  *big.Int
+}
+
+func getSushiPoolDepth(ctx context.Context, client *ethclient.Client, pool common.Address) (*sushiPoolDepth, error) {
+	caller, err := abigen.NewUniswapV3PoolCaller(pool, client)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+
+	d := &sushiPoolDepth{Pool: pool}
+	fee, err := caller.Fee(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool fee %s", err)
+	}
+	d.Fee = uint32(fee.Uint64())
+	if d.Liquidity, err = caller.Liquidity(opts); err != nil {
+		return nil, fmt.Errorf("failed to get pool liquidity %s", err)
+	}
+	if d.Token0, err = caller.Token0(opts); err != nil {
+		return nil, err
+	}
+	if d.Token1, err = caller.Token1(opts); err != nil {
+		return nil, err
+	}
+
+	for _, r := range []struct {
+		token   common.Address
+		reserve **big.Int
+	}{{d.Token0, &d.Reserve0}, {d.Token1, &d.Reserve1}} {
+		erc20, err := abigen.NewPoolTokenCaller(r.token, client)
 		if err != nil {
-			log.Fatalf("Failed to unpack return value: %v", err)
+			return nil, err
+		}
+		if *r.reserve, err = erc20.BalanceOf(opts, pool); err != nil {
+			return nil, fmt.Errorf("failed to get pool reserves %s", err)
 		}
+	}
 
-		// Extract the estimated output amount
-		// uint256 amountOut,
-		// uint160 sqrtPriceX96After,
-		// uint32 initializedTicksCrossed,
-		// uint256 gasEstimate
-		amountOut := outputs[0].(*big.Int)
-		sqrtPriceX96After := outputs[1].(*big.Int)
+	return d, nil
+}
 
-		s.Stop()
+// quoteSushiExactInput quotes swapping amount of tokenIn for tokenOut on
+// every Sushi V3 route the quote command probes, returning the best one
+func quoteSushiExactInput(ctx context.Context, tokenIn common.Address, tokenOut common.Address, amount *big.Int) (*sushiRouteQuote, error) {
+	client, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum client: %s", err)
+	}
+	defer client.Close()
 
-		switch path {
-		case QuotePathFILGLF:
-			fmt.Printf("for %0.04f FIL, you would receive approximately %0.06f GLF\n", util.ToFIL(amount), util.ToFIL(amountOut))
-		case QuotePathGLFFIL:
-			fmt.Printf("for %0.04f GLF, you would receive approximately %0.06f FIL\n", util.ToFIL(amount), util.ToFIL(amountOut))
-		}
-		fmt.Printf("the price after the swap would be %0.06f GLF/FIL\n", token.GLFToFIL(sqrtPriceX96After))
-	},
+	routes := sushiRoutes(tokenIn, tokenOut, sushiRouteTokens(), sushiFeeTiers)
+	quotes, err := quoteSushiRoutes(ctx, client, routes, amount)
+	if err != nil {
+		return nil, err
+	}
+	return quotes[0], nil
 }
 
 func init() {
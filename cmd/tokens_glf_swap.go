@@ -0,0 +1,326 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
+	"github.com/glifio/go-pools/deploy"
+	"github.com/glifio/go-pools/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// SushiSwapRouter is the Sushi V3 SwapRouter on Filecoin mainnet. It can be
+// overridden with the sushi.swap-router config key, and is checked against
+// the GLF/WFIL pool's factory before every swap
+var SushiSwapRouter = common.HexToAddress("0x1400feFD6F9b897970f00Df6237Ff2B8b27Dc82C")
+
+// sushiSwapRouterABI is the subset of the V3 SwapRouter used to swap
+const sushiSwapRouterABI = `[
+	{"inputs":[],"name":"factory","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"WETH9","outputs":[{"internalType":"address","name":"","type":"address"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"components":[
+		{"internalType":"bytes","name":"path","type":"bytes"},
+		{"internalType":"address","name":"recipient","type":"address"},
+		{"internalType":"uint256","name":"deadline","type":"uint256"},
+		{"internalType":"uint256","name":"amountIn","type":"uint256"},
+		{"internalType":"uint256","name":"amountOutMinimum","type":"uint256"}
+	],"internalType":"struct ISwapRouter.ExactInputParams","name":"params","type":"tuple"}],
+	"name":"exactInput","outputs":[{"internalType":"uint256","name":"amountOut","type":"uint256"}],"stateMutability":"payable","type":"function"}
+]`
+
+// exactInputParams mirrors ISwapRouter.ExactInputParams
+type exactInputParams struct {
+	Path             []byte
+	Recipient        common.Address
+	Deadline         *big.Int
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+// minAmountOut returns the least amount out accepted for a quote, given the
+// maximum slippage in basis points
+func minAmountOut(quote *big.Int, slippageBps int64) *big.Int {
+	min := new(big.Int).Mul(quote, big.NewInt(10000-slippageBps))
+	return min.Div(min, big.NewInt(10000))
+}
+
+// priceDiffPercent returns how much worse the realized amount out was than
+// the quoted amount, in percent. Negative values mean a better fill
+func priceDiffPercent(quoted, realized *big.Int) float64 {
+	if quoted.Sign() == 0 {
+		return 0
+	}
+	diff := new(big.Float).SetInt(new(big.Int).Sub(quoted, realized))
+	diff.Quo(diff, new(big.Float).SetInt(quoted))
+	perc, _ := diff.Mul(diff, big.NewFloat(100)).Float64()
+	return perc
+}
+
+func sushiSwapRouter() common.Address {
+	if r := viper.GetString("sushi.swap-router"); r != "" {
+		return common.HexToAddress(r)
+	}
+	return SushiSwapRouter
+}
+
+// checkSushiSwapRouter makes sure router belongs to the same deployment as the
+// GLF/WFIL pool, and wraps WFIL
+func checkSushiSwapRouter(ctx context.Context, client *ethclient.Client, router *bind.BoundContract) error {
+	opts := &bind.CallOpts{Context: ctx}
+
+	pool, err := abigen.NewUniswapV3PoolCaller(deploy.SushiGLFWFILPool, client)
+	if err != nil {
+		return err
+	}
+	poolFactory, err := pool.Factory(opts)
+	if err != nil {
+		return fmt.Errorf("failed to get pool factory: %w", err)
+	}
+
+	var out []interface{}
+	if err := router.Call(opts, &out, "factory"); err != nil {
+		return fmt.Errorf("failed to get swap router factory: %w", err)
+	}
+	if out[0].(common.Address) != poolFactory {
+		return fmt.Errorf("swap router %s does not belong to the GLF/WFIL pool's factory %s, check sushi.swap-router in your config", sushiSwapRouter(), poolFactory)
+	}
+
+	out = nil
+	if err := router.Call(opts, &out, "WETH9"); err != nil {
+		return fmt.Errorf("failed to get swap router WETH9: %w", err)
+	}
+	if out[0].(common.Address) != PoolsSDK.Query().WFIL() {
+		return fmt.Errorf("swap router %s does not use WFIL", sushiSwapRouter())
+	}
+
+	return nil
+}
+
+var swapCmd = &cobra.Command{
+	Use:   "swap <fil:glf|glf:fil> <amount>",
+	Short: "Swap FIL for GLF or GLF for FIL on Sushi V3",
+	Long: `Swap FIL for GLF or GLF for FIL on Sushi V3.
+
+The swap goes through the route returning the most, picked the same way as the quote
+command across every fee tier and intermediate token. It is quoted first, and reverts if it would receive less than the quote minus
+--max-slippage, or if it is not included before --deadline. FIL is wrapped into WFIL
+before swapping to GLF, and WFIL received for GLF is unwrapped into FIL, unless --wfil
+is passed. The router is approved to spend the input token when needed.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if PoolsSDK.Query().ChainID().Cmp(big.NewInt(constants.MainnetChainID)) != 0 {
+			logFatalf("Sushi is only available on Filecoin Mainnet")
+		}
+
+		path := QuotePath(args[0])
+		var tokenIn, tokenOut common.Address
+		var symbolIn, symbolOut string
+		switch path {
+		case QuotePathFILGLF:
+			tokenIn, tokenOut = PoolsSDK.Query().WFIL(), PoolsSDK.Query().GLF()
+			symbolIn, symbolOut = "FIL", "GLF"
+		case QuotePathGLFFIL:
+			tokenIn, tokenOut = PoolsSDK.Query().GLF(), PoolsSDK.Query().WFIL()
+			symbolIn, symbolOut = "GLF", "FIL"
+		default:
+			logFatalf("Invalid path %s, expected fil:glf or glf:fil", args[0])
+		}
+
+		amount, err := parseFILAmount(args[1])
+		if err != nil {
+			logFatalf("Failed to parse amount %s", err)
+		}
+		if amount.Sign() <= 0 {
+			logFatal("Amount must be greater than 0")
+		}
+
+		maxSlippage, err := cmd.Flags().GetFloat64("max-slippage")
+		if err != nil {
+			logFatal(err)
+		}
+		if maxSlippage < 0 || maxSlippage >= 100 {
+			logFatalf("Invalid --max-slippage %v, must be between 0 and 100", maxSlippage)
+		}
+		deadline, err := cmd.Flags().GetDuration("deadline")
+		if err != nil {
+			logFatal(err)
+		}
+		useWFIL, err := cmd.Flags().GetBool("wfil")
+		if err != nil {
+			logFatal(err)
+		}
+
+		client, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer client.Close()
+
+		routerABI, err := abi.JSON(strings.NewReader(sushiSwapRouterABI))
+		if err != nil {
+			logFatal(err)
+		}
+		routerAddr := sushiSwapRouter()
+		router := bind.NewBoundContract(routerAddr, routerABI, client, client, client)
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		if err := checkSushiSwapRouter(ctx, client, router); err != nil {
+			logFatal(err)
+		}
+
+		best, err := quoteSushiExactInput(ctx, tokenIn, tokenOut, amount)
+		if err != nil {
+			logFatal(err)
+		}
+		quote := best.AmountOut
+
+		names := map[common.Address]string{}
+		for _, t := range best.Route.Tokens {
+			token, err := getSushiToken(ctx, client, t)
+			if err != nil {
+				logFatal(err)
+			}
+			names[t] = token.Symbol
+		}
+
+		s.Stop()
+
+		slippageBps := int64(maxSlippage * 100)
+		minOut := minAmountOut(quote, slippageBps)
+		quotedPrice := new(big.Float).Quo(util.ToFIL(amount), util.ToFIL(quote))
+
+		fmt.Printf("Quote: %0.06f %s for %0.06f %s (1 %s ≈ %0.08f %s)\n", util.ToFIL(quote), symbolOut, util.ToFIL(amount), symbolIn, symbolOut, quotedPrice, symbolIn)
+		fmt.Printf("Route: %s\n", best.Route.String(names))
+		fmt.Printf("Minimum received with %0.02f%% max slippage: %0.06f %s\n", maxSlippage, util.ToFIL(minOut), symbolOut)
+
+		from := cmd.Flag("from").Value.String()
+		auth, _, err := commonGenericAccountSetup(cmd, from)
+		if err != nil {
+			logFatal(err)
+		}
+
+		wfil, err := abigen.NewWFIL(PoolsSDK.Query().WFIL(), client)
+		if err != nil {
+			logFatal(err)
+		}
+		opts := &bind.CallOpts{Context: ctx}
+
+		// wrap FIL into WFIL, topping up any WFIL the account already holds
+		if path == QuotePathFILGLF {
+			wfilBalance, err := wfil.BalanceOf(opts, auth.From)
+			if err != nil {
+				logFatal(err)
+			}
+			if wfilBalance.Cmp(amount) < 0 {
+				if useWFIL {
+					logFatalf("Insufficient WFIL balance %0.06f, pass the amount as FIL without --wfil to wrap it", util.ToFIL(wfilBalance))
+				}
+				wrap := new(big.Int).Sub(amount, wfilBalance)
+				fmt.Printf("Wrapping %0.06f FIL into WFIL...\n", util.ToFIL(wrap))
+				auth.Value = wrap
+				tx, err := wfil.Deposit(auth)
+				auth.Value = nil
+				if err != nil {
+					logFatalf("Failed to wrap FIL %s", err)
+				}
+				waitSwapStep(ctx, s, auth, tx, "wrap FIL")
+			}
+		}
+
+		// approve the router to spend the input token
+		erc20, err := abigen.NewPoolToken(tokenIn, client)
+		if err != nil {
+			logFatal(err)
+		}
+		allowance, err := erc20.Allowance(opts, auth.From, routerAddr)
+		if err != nil {
+			logFatal(err)
+		}
+		if allowance.Cmp(amount) < 0 {
+			fmt.Printf("Approving the Sushi router to spend %0.06f %s...\n", util.ToFIL(amount), symbolIn)
+			tx, err := erc20.Approve(auth, routerAddr, amount)
+			if err != nil {
+				logFatalf("Failed to approve router %s", err)
+			}
+			waitSwapStep(ctx, s, auth, tx, "approve router")
+		}
+
+		out, err := abigen.NewPoolTokenCaller(tokenOut, client)
+		if err != nil {
+			logFatal(err)
+		}
+		balanceBefore, err := out.BalanceOf(opts, auth.From)
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Swapping %0.06f %s for %s...\n", util.ToFIL(amount), symbolIn, symbolOut)
+		tx, err := router.Transact(auth, "exactInput", exactInputParams{
+			Path:             best.Route.encodePath(),
+			Recipient:        auth.From,
+			Deadline:         big.NewInt(time.Now().Add(deadline).Unix()),
+			AmountIn:         amount,
+			AmountOutMinimum: minOut,
+		})
+		if err != nil {
+			logFatalf("Failed to swap %s", err)
+		}
+		waitSwapStep(ctx, s, auth, tx, "swap")
+
+		balanceAfter, err := out.BalanceOf(opts, auth.From)
+		if err != nil {
+			logFatal(err)
+		}
+		received := new(big.Int).Sub(balanceAfter, balanceBefore)
+
+		if path == QuotePathGLFFIL && !useWFIL && received.Sign() > 0 {
+			fmt.Printf("Unwrapping %0.06f WFIL into FIL...\n", util.ToFIL(received))
+			tx, err := wfil.Withdraw(auth, received)
+			if err != nil {
+				logFatalf("Failed to unwrap WFIL %s", err)
+			}
+			waitSwapStep(ctx, s, auth, tx, "unwrap WFIL")
+		}
+
+		realizedPrice := new(big.Float).Quo(util.ToFIL(amount), util.ToFIL(received))
+		fmt.Printf("Received %0.06f %s (1 %s ≈ %0.08f %s)\n", util.ToFIL(received), symbolOut, symbolOut, realizedPrice, symbolIn)
+		fmt.Printf("Realized vs quote: %0.04f%% worse\n", priceDiffPercent(quote, received))
+	},
+}
+
+// waitSwapStep waits for one transaction of a swap, resetting the nonce so the
+// next step picks up the following one. --nonce only applies to the first
+func waitSwapStep(ctx context.Context, s *spinner.Spinner, auth *bind.TransactOpts, tx *types.Transaction, step string) {
+	s.Start()
+	defer s.Stop()
+
+	_, err := PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+	if err != nil {
+		logFatalf("Failed to %s %s", step, err)
+	}
+	auth.Nonce = nil
+}
+
+func init() {
+	glifCmd.AddCommand(swapCmd)
+	swapCmd.Flags().Float64("max-slippage", 0.5, "maximum slippage from the quote in percent")
+	swapCmd.Flags().Duration("deadline", 20*time.Minute, "time after which the swap reverts if not yet included")
+	swapCmd.Flags().Bool("wfil", false, "swap from and to WFIL instead of wrapping and unwrapping FIL")
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinAmountOut(t *testing.T) {
+	assert.Equal(t, fil(100), minAmountOut(fil(100), 0))
+	assert.Equal(t, new(big.Int).Div(fil(995), big.NewInt(10)), minAmountOut(fil(100), 50))
+	assert.Equal(t, fil(99), minAmountOut(fil(100), 100))
+	assert.Zero(t, minAmountOut(big.NewInt(1), 1).Sign())
+}
+
+func TestPriceDiffPercent(t *testing.T) {
+	assert.InDelta(t, 0, priceDiffPercent(fil(100), fil(100)), 1e-9)
+	assert.InDelta(t, 1, priceDiffPercent(fil(100), fil(99)), 1e-9)
+	assert.InDelta(t, -2, priceDiffPercent(fil(100), fil(102)), 1e-9)
+	assert.Equal(t, float64(0), priceDiffPercent(big.NewInt(0), fil(1)))
+}
@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+// erc20Token is an ERC-20 token the tokens commands act on
+type erc20Token struct {
+	Symbol   string
+	Address  common.Address
+	Decimals uint8
+}
+
+func (t erc20Token) String() string {
+	return t.Symbol
+}
+
+// ParseAmount parses a decimal amount of whole tokens into base units
+func (t erc20Token) ParseAmount(amount string) (*big.Int, error) {
+	return parseTokenAmount(amount, t.Decimals)
+}
+
+// ToUnits converts an amount of base units into whole tokens
+func (t erc20Token) ToUnits(amount *big.Int) *big.Float {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals)), nil)
+	return new(big.Float).Quo(new(big.Float).SetInt(amount), new(big.Float).SetInt(unit))
+}
+
+// parseTokenAmount parses a decimal amount of whole tokens into base units,
+// rejecting amounts with more decimal places than the token supports
+func parseTokenAmount(amount string, decimals uint8) (*big.Int, error) {
+	amt, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, errors.New("invalid amount")
+	}
+	if amt.Sign() < 0 {
+		return nil, errors.New("amount must not be negative")
+	}
+
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	amt.Mul(amt, new(big.Rat).SetInt(unit))
+	if !amt.IsInt() {
+		return nil, fmt.Errorf("amount has more than %d decimal places", decimals)
+	}
+	return amt.Num(), nil
+}
+
+// builtinTokenNames are the tokens with their own command group, they cannot
+// be used as registry names
+var builtinTokenNames = []string{"ifil", "glf", "wfil"}
+
+func builtinToken(name string) (erc20Token, bool) {
+	switch strings.ToLower(name) {
+	case "ifil":
+		return erc20Token{Symbol: "iFIL", Address: PoolsSDK.Query().IFIL(), Decimals: 18}, true
+	case "glf":
+		return erc20Token{Symbol: "GLF", Address: PoolsSDK.Query().GLF(), Decimals: 18}, true
+	case "wfil":
+		return erc20Token{Symbol: "wFIL", Address: PoolsSDK.Query().WFIL(), Decimals: 18}, true
+	}
+	return erc20Token{}, false
+}
+
+// validTokenName checks a name can be registered in the token registry
+func validTokenName(name string) error {
+	if name == "" {
+		return errors.New("token name must not be empty")
+	}
+	if common.IsHexAddress(name) {
+		return errors.New("token name must not be an address")
+	}
+	for _, builtin := range builtinTokenNames {
+		if strings.EqualFold(name, builtin) {
+			return fmt.Errorf("%s is a built in token", name)
+		}
+	}
+	return nil
+}
+
+// resolveToken returns a built in token, a token from the registry, or the
+// token at an address, reading its symbol and decimals from the contract
+func resolveToken(ctx context.Context, nameOrAddr string) (erc20Token, error) {
+	if token, ok := builtinToken(nameOrAddr); ok {
+		return token, nil
+	}
+
+	registered, err := util.TokenStore().Get(nameOrAddr)
+	if err == nil {
+		return erc20Token{
+			Symbol:   registered.Symbol,
+			Address:  common.HexToAddress(registered.Address),
+			Decimals: registered.Decimals,
+		}, nil
+	}
+
+	if !common.IsHexAddress(nameOrAddr) {
+		return erc20Token{}, fmt.Errorf("unknown token %s, add it with: glif tokens add <name> <address>", nameOrAddr)
+	}
+
+	return readTokenMetadata(ctx, common.HexToAddress(nameOrAddr))
+}
+
+// readTokenMetadata reads the symbol and decimals of an ERC-20 contract
+func readTokenMetadata(ctx context.Context, addr common.Address) (erc20Token, error) {
+	client, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return erc20Token{}, err
+	}
+	defer client.Close()
+
+	caller, err := abigen.NewPoolTokenCaller(addr, client)
+	if err != nil {
+		return erc20Token{}, err
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	symbol, err := caller.Symbol(opts)
+	if err != nil {
+		return erc20Token{}, fmt.Errorf("failed to get symbol of %s, is it an ERC-20 contract? %w", addr, err)
+	}
+	decimals, err := caller.Decimals(opts)
+	if err != nil {
+		return erc20Token{}, fmt.Errorf("failed to get decimals of %s: %w", addr, err)
+	}
+
+	return erc20Token{Symbol: symbol, Address: addr, Decimals: decimals}, nil
+}
+
+// registeredTokens returns every token in the registry, sorted by name
+func registeredTokens() []erc20Token {
+	ts := util.TokenStore()
+	tokens := make([]erc20Token, 0)
+	for _, name := range ts.Names() {
+		t, _ := ts.Get(name)
+		tokens = append(tokens, erc20Token{Symbol: t.Symbol, Address: common.HexToAddress(t.Address), Decimals: t.Decimals})
+	}
+	return tokens
+}
+
+var erc20Cmd = &cobra.Command{
+	Use:   "erc20",
+	Short: "Commands for interacting with any ERC-20 token, by registry name or address",
+}
+
+var tokensAddCmd = &cobra.Command{
+	Use:   "add <name> <address>",
+	Short: "Add an ERC-20 token to the token registry",
+	Long:  "Add an ERC-20 token to the token registry under name. The token's symbol and decimals are read from the contract. Registered tokens can be used with the erc20 commands by name, and their balances are shown by glif wallet balance.",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+		if err := validTokenName(name); err != nil {
+			logFatal(err)
+		}
+		if _, err := util.TokenStore().Get(name); err == nil {
+			logFatalf("Token %s already exists, remove it first with: glif tokens remove %s", name, name)
+		}
+
+		addr, err := AddressOrAccountNameToEVM(cmd.Context(), args[1])
+		if err != nil {
+			logFatalf("Failed to parse address %s", err)
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		token, err := readTokenMetadata(cmd.Context(), addr)
+		if err != nil {
+			logFatal(err)
+		}
+
+		s.Stop()
+
+		err = util.TokenStore().Set(name, util.Token{
+			Address:  token.Address.Hex(),
+			Symbol:   token.Symbol,
+			Decimals: token.Decimals,
+		})
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Added %s (%s, %d decimals) as %s\n", token.Address, token.Symbol, token.Decimals, name)
+	},
+}
+
+var tokensRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove an ERC-20 token from the token registry",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := util.TokenStore().Delete(args[0]); err != nil {
+			logFatal(err)
+		}
+		fmt.Printf("Removed %s\n", args[0])
+	},
+}
+
+var tokensListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built in tokens and the tokens in the token registry",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tbl := table.New("Name", "Symbol", "Decimals", "Address")
+		for _, name := range builtinTokenNames {
+			t, _ := builtinToken(name)
+			tbl.AddRow(name, t.Symbol, t.Decimals, t.Address.Hex())
+		}
+		ts := util.TokenStore()
+		for _, name := range ts.Names() {
+			t, _ := ts.Get(name)
+			tbl.AddRow(name, t.Symbol, t.Decimals, common.HexToAddress(t.Address).Hex())
+		}
+		tbl.Print()
+	},
+}
+
+func init() {
+	tokensCmd.AddCommand(tokensAddCmd)
+	tokensCmd.AddCommand(tokensRemoveCmd)
+	tokensCmd.AddCommand(tokensListCmd)
+	tokensCmd.AddCommand(erc20Cmd)
+
+	erc20Cmd.PersistentFlags().String("token", "", "registered token name or token address")
+	erc20Cmd.MarkPersistentFlagRequired("token")
+
+	erc20Cmd.AddCommand(createCommand(&allowanceCmd))
+	erc20Cmd.AddCommand(createCommand(&approveCmd))
+	erc20Cmd.AddCommand(createCommand(&transferCmd))
+	erc20Cmd.AddCommand(createCommand(&balanceOfCmd))
+	erc20Cmd.AddCommand(createCommand(&supplyCmd))
+	erc20Cmd.AddCommand(createCommand(&transferFromCmd))
+}
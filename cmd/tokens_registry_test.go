@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTokenAmount(t *testing.T) {
+	amt, err := parseTokenAmount("1.5", 6)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1500000), amt)
+
+	amt, err = parseTokenAmount("0.1", 18)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100000000000000000), amt)
+
+	amt, err = parseTokenAmount("42", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(42), amt)
+
+	_, err = parseTokenAmount("1.0000001", 6)
+	assert.Error(t, err)
+
+	_, err = parseTokenAmount("-1", 18)
+	assert.Error(t, err)
+
+	_, err = parseTokenAmount("one", 18)
+	assert.Error(t, err)
+}
+
+func TestERC20TokenToUnits(t *testing.T) {
+	usdc := erc20Token{Symbol: "USDC", Decimals: 6}
+	units, _ := usdc.ToUnits(big.NewInt(2500000)).Float64()
+	assert.Equal(t, 2.5, units)
+	assert.Equal(t, "USDC", usdc.String())
+
+	glf := erc20Token{Symbol: "GLF", Decimals: 18}
+	units, _ = glf.ToUnits(fil(3)).Float64()
+	assert.Equal(t, 3.0, units)
+}
+
+func TestValidTokenName(t *testing.T) {
+	assert.NoError(t, validTokenName("usdc"))
+	assert.Error(t, validTokenName(""))
+	assert.Error(t, validTokenName("GLF"))
+	assert.Error(t, validTokenName("wfil"))
+	assert.Error(t, validTokenName("0x1000000000000000000000000000000000000001"))
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/spf13/cobra"
+)
+
+// filterAllowances keeps the allowances matching every filter that is set
+func filterAllowances(allowances []tokenAllowance, account string, token *erc20Token, spender *common.Address) []tokenAllowance {
+	var filtered []tokenAllowance
+	for _, a := range allowances {
+		if account != "" && a.Account != account {
+			continue
+		}
+		if token != nil && a.Token.Address != token.Address {
+			continue
+		}
+		if spender != nil && a.Spender != *spender {
+			continue
+		}
+		filtered = append(filtered, a)
+	}
+	return filtered
+}
+
+var tokensRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke the outstanding token allowances of wallet accounts in a batch",
+	Long: `Revoke the outstanding token allowances found by glif tokens allowances, by approving
+each spender for zero. Use --account, --token and --spender to only revoke some of them.
+
+Each revocation is signed by the account that granted the allowance, GLIF_PASSPHRASE
+is used to unlock every account when set.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		lookback, err := cmd.Flags().GetUint64("lookback")
+		if err != nil {
+			logFatal(err)
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			logFatal(err)
+		}
+		account, err := cmd.Flags().GetString("account")
+		if err != nil {
+			logFatal(err)
+		}
+
+		accounts := walletAccounts()
+		if account != "" {
+			addr, ok := accounts[account]
+			if !ok {
+				logFatalf("Account %s not found in your wallet", account)
+			}
+			accounts = map[string]common.Address{account: addr}
+		}
+
+		var tokenFilter *erc20Token
+		if name := cmd.Flag("token").Value.String(); name != "" {
+			t, err := resolveToken(ctx, name)
+			if err != nil {
+				logFatal(err)
+			}
+			tokenFilter = &t
+		}
+
+		var spenderFilter *common.Address
+		if spender := cmd.Flag("spender").Value.String(); spender != "" {
+			addr, err := AddressOrAccountNameToEVM(ctx, spender)
+			if err != nil {
+				logFatalf("Failed to parse spender address %s", err)
+			}
+			spenderFilter = &addr
+		}
+
+		tokens := allowanceTokens()
+		if tokenFilter != nil {
+			tokens = []erc20Token{*tokenFilter}
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		allowances, err := listAllowances(ctx, accounts, tokens, lookback)
+		if err != nil {
+			logFatal(err)
+		}
+		allowances = filterAllowances(allowances, account, tokenFilter, spenderFilter)
+
+		s.Stop()
+
+		if len(allowances) == 0 {
+			fmt.Println("No outstanding allowances to revoke")
+			return
+		}
+
+		printAllowances(allowances)
+
+		if dryRun {
+			return
+		}
+
+		auths := map[common.Address]*bind.TransactOpts{}
+		var failed []string
+		for _, a := range allowances {
+			label := fmt.Sprintf("%s %s for %s", a.Account, a.Token, a.Spender.Hex())
+
+			auth, ok := auths[a.Owner]
+			if !ok {
+				auth, _, err = commonGenericAccountSetup(cmd, a.Owner.Hex())
+				if err != nil {
+					failed = append(failed, fmt.Sprintf("%s: %s", label, err))
+					continue
+				}
+				auths[a.Owner] = auth
+			}
+			// the nonce is fetched per transaction when one account signs several revocations
+			auth.Nonce = nil
+
+			s.Start()
+			tx, err := revokeAllowance(auth, a)
+			if err == nil {
+				_, err = PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+			}
+			s.Stop()
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %s", label, err))
+				continue
+			}
+
+			fmt.Printf("Revoked %s\n", label)
+		}
+
+		if len(failed) > 0 {
+			logFatalf("Failed to revoke %d of %d allowances:\n%s", len(failed), len(allowances), strings.Join(failed, "\n"))
+		}
+
+		fmt.Printf("Revoked %d allowances.\n", len(allowances))
+	},
+}
+
+// revokeAllowance approves the allowance's spender for zero
+func revokeAllowance(auth *bind.TransactOpts, a tokenAllowance) (*types.Transaction, error) {
+	client, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	token, err := abigen.NewPoolTokenTransactor(a.Token.Address, client)
+	if err != nil {
+		return nil, err
+	}
+	return token.Approve(auth, a.Spender, common.Big0)
+}
+
+func init() {
+	tokensCmd.AddCommand(tokensRevokeCmd)
+	tokensRevokeCmd.Flags().String("account", "", "only revoke allowances granted by this wallet account")
+	tokensRevokeCmd.Flags().String("token", "", "only revoke allowances of this token, by name or address")
+	tokensRevokeCmd.Flags().String("spender", "", "only revoke allowances granted to this spender")
+	tokensRevokeCmd.Flags().Uint64("lookback", 2880, "epochs of Approval logs to search for spenders, 0 only checks the known contracts")
+	tokensRevokeCmd.Flags().Bool("dry-run", false, "only show the allowances that would be revoked")
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/glifio/glif/v2/events"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exitRedeem   = "redeem"
+	exitWithdraw = "withdraw"
+)
+
+// exitQuote holds the pool previews an unstake is planned from
+type exitQuote struct {
+	// Assets is the FIL requested
+	Assets *big.Int
+	// Shares is the iFIL previewWithdraw burns for Assets
+	Shares *big.Int
+	// SharesValue is the FIL previewRedeem pays out for Shares
+	SharesValue *big.Int
+	// Balance is the iFIL balance of the account
+	Balance *big.Int
+	// BalanceValue is the FIL previewRedeem pays out for Balance
+	BalanceValue *big.Int
+}
+
+// exitPlan is how an unstake is executed
+type exitPlan struct {
+	Method string
+	// Shares is the iFIL burned, previewed for withdrawals
+	Shares *big.Int
+	// Assets is the FIL received, previewed for redemptions
+	Assets *big.Int
+}
+
+// chooseExit picks the cheaper of redeem and withdraw for an unstake. The
+// previews include the pool's fees and rounding, so when redeeming the iFIL a
+// withdrawal would burn pays out more FIL, redeeming is cheaper. Unstaking at
+// least the whole balance redeems the balance, leaving no iFIL dust behind
+func chooseExit(q exitQuote) exitPlan {
+	if q.Shares.Cmp(q.Balance) >= 0 {
+		return exitPlan{Method: exitRedeem, Shares: q.Balance, Assets: q.BalanceValue}
+	}
+	if q.SharesValue.Cmp(q.Assets) > 0 {
+		return exitPlan{Method: exitRedeem, Shares: q.Shares, Assets: q.SharesValue}
+	}
+	return exitPlan{Method: exitWithdraw, Shares: q.Shares, Assets: q.Assets}
+}
+
+// planExit previews an unstake of amount, in FIL or, with inIFIL, in iFIL.
+// An amount of "all" redeems the whole balance
+func planExit(ctx context.Context, pool *abigen.InfinityPoolV2Caller, amount string, inIFIL bool, balance *big.Int) (exitPlan, error) {
+	opts := &bind.CallOpts{Context: ctx}
+
+	if strings.EqualFold(amount, "all") || inIFIL {
+		shares := balance
+		if !strings.EqualFold(amount, "all") {
+			var err error
+			shares, err = parseFILAmount(amount)
+			if err != nil {
+				return exitPlan{}, err
+			}
+			if shares.Cmp(balance) > 0 {
+				return exitPlan{}, fmt.Errorf("amount %0.09f iFIL exceeds your balance of %0.09f iFIL", util.ToFIL(shares), util.ToFIL(balance))
+			}
+		}
+		assets, err := pool.PreviewRedeem(opts, shares)
+		if err != nil {
+			return exitPlan{}, err
+		}
+		return exitPlan{Method: exitRedeem, Shares: shares, Assets: assets}, nil
+	}
+
+	assets, err := parseFILAmount(amount)
+	if err != nil {
+		return exitPlan{}, err
+	}
+
+	q := exitQuote{Assets: assets, Balance: balance}
+	if q.Shares, err = pool.PreviewWithdraw(opts, assets); err != nil {
+		return exitPlan{}, err
+	}
+	if q.SharesValue, err = pool.PreviewRedeem(opts, q.Shares); err != nil {
+		return exitPlan{}, err
+	}
+	if q.BalanceValue, err = pool.PreviewRedeem(opts, balance); err != nil {
+		return exitPlan{}, err
+	}
+
+	// a request for more than the balance is worth is only allowed within the
+	// pool's rounding of the whole balance
+	if q.Shares.Cmp(balance) > 0 && q.Assets.Cmp(new(big.Int).Add(q.BalanceValue, big.NewInt(1))) > 0 {
+		return exitPlan{}, fmt.Errorf("amount %0.09f FIL exceeds your staked balance worth %0.09f FIL", util.ToFIL(assets), util.ToFIL(q.BalanceValue))
+	}
+
+	return chooseExit(q), nil
+}
+
+var unstakeCmd = &cobra.Command{
+	Use:   "unstake <amount|all>",
+	Short: "Unstake FIL from the Infinity Pool by burning iFIL",
+	Long: `Unstake FIL from the Infinity Pool. The amount is in FIL, or in iFIL with --ifil,
+and all unstakes the whole iFIL balance.
+
+Before sending, the exit reserve is checked for enough liquidity to pay you out,
+the Infinity Pool is approved to spend your iFIL if needed, and the exit is made
+with redeem or withdraw, whichever pays out more FIL for the iFIL burned. The
+exit is recorded in the journal for glif stake position.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+		from := cmd.Flag("from").Value.String()
+		auth, senderAccount, err := commonGenericAccountSetup(cmd, from)
+		if err != nil {
+			logFatal(err)
+		}
+
+		inIFIL, err := cmd.Flags().GetBool("ifil")
+		if err != nil {
+			logFatal(err)
+		}
+
+		receiver := senderAccount.Address
+		if r := cmd.Flag("receiver").Value.String(); r != "" {
+			receiver, err = AddressOrAccountNameToEVM(ctx, r)
+			if err != nil {
+				logFatal(err)
+			}
+			warnUnknownRecipient(r)
+		}
+
+		s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+		s.Start()
+		defer s.Stop()
+
+		client, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatal(err)
+		}
+		defer client.Close()
+
+		poolAddr := PoolsSDK.Query().InfinityPool()
+		pool, err := abigen.NewInfinityPoolV2Caller(poolAddr, client)
+		if err != nil {
+			logFatal(err)
+		}
+		ifil, err := abigen.NewPoolToken(PoolsSDK.Query().IFIL(), client)
+		if err != nil {
+			logFatal(err)
+		}
+
+		opts := &bind.CallOpts{Context: ctx}
+		balance, err := ifil.BalanceOf(opts, senderAccount.Address)
+		if err != nil {
+			logFatal(err)
+		}
+		if balance.Sign() == 0 {
+			logFatal("No iFIL to unstake")
+		}
+
+		plan, err := planExit(ctx, pool, args[0], inIFIL, balance)
+		if err != nil {
+			logFatal(err)
+		}
+		if plan.Shares.Sign() == 0 || plan.Assets.Sign() == 0 {
+			logFatal("Amount must be greater than 0")
+		}
+
+		maxWithdraw, err := pool.MaxWithdraw(opts, senderAccount.Address)
+		if err != nil {
+			logFatal(err)
+		}
+		if plan.Assets.Cmp(maxWithdraw) > 0 {
+			reserve, _, err := PoolsSDK.Query().InfPoolExitReserve(ctx, nil)
+			if err != nil {
+				logFatal(err)
+			}
+			s.Stop()
+			logFatalf("Not enough exit liquidity: the exit reserve holds %0.09f FIL and you can unstake at most %0.09f FIL right now", util.ToFIL(reserve), util.ToFIL(maxWithdraw))
+		}
+
+		allowance, err := ifil.Allowance(opts, senderAccount.Address, poolAddr)
+		if err != nil {
+			logFatal(err)
+		}
+
+		s.Stop()
+
+		if allowance.Cmp(plan.Shares) < 0 {
+			fmt.Printf("Approving the Infinity Pool to spend %0.09f iFIL...\n", util.ToFIL(plan.Shares))
+			tx, err := ifil.Approve(auth, poolAddr, plan.Shares)
+			if err != nil {
+				logFatalf("Failed to approve the Infinity Pool %s", err)
+			}
+			s.Start()
+			if _, err := PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash()); err != nil {
+				logFatalf("Failed to approve the Infinity Pool %s", err)
+			}
+			s.Stop()
+			// --nonce was used by the approval, fetch the next one for the exit
+			auth.Nonce = nil
+		}
+
+		exitevt := journal.RegisterEventType("stake", "exit")
+		evt := &events.StakeExit{
+			Account: senderAccount.Address.String(),
+			Method:  plan.Method,
+			IFIL:    plan.Shares.String(),
+			Amount:  plan.Assets.String(),
+		}
+		defer journal.RecordEvent(exitevt, func() interface{} { return evt })
+
+		fmt.Printf("Unstaking with %s: burning %0.09f iFIL for %0.09f FIL\n", plan.Method, util.ToFIL(plan.Shares), util.ToFIL(plan.Assets))
+
+		s.Start()
+
+		var tx *types.Transaction
+		if plan.Method == exitRedeem {
+			tx, err = PoolsSDK.Act().InfPoolRedeem(ctx, auth, plan.Shares, senderAccount.Address, receiver)
+		} else {
+			tx, err = PoolsSDK.Act().InfPoolWithdraw(ctx, auth, plan.Assets, senderAccount.Address, receiver)
+		}
+		if err != nil {
+			evt.Error = err.Error()
+			logFatal(err)
+		}
+		evt.Tx = tx.Hash().String()
+
+		// transaction landed on chain or errored
+		receipt, err := PoolsSDK.Query().StateWaitReceipt(ctx, tx.Hash())
+		if err != nil {
+			evt.Error = err.Error()
+			logFatal(err)
+		}
+
+		if receipt == nil {
+			evt.Error = "failed to get receipt"
+			logFatal("Failed to get receipt")
+		}
+
+		if receipt.Status == 0 {
+			evt.Error = "transaction failed"
+			logFatal("Transaction failed")
+		}
+
+		// withdrawals burn the previewed iFIL unless the price moved, record
+		// what was actually burned
+		balanceAfter, err := ifil.BalanceOf(opts, senderAccount.Address)
+		if err == nil && balance.Cmp(balanceAfter) > 0 {
+			evt.IFIL = new(big.Int).Sub(balance, balanceAfter).String()
+		}
+
+		s.Stop()
+
+		fmt.Printf("Successfully unstaked %0.09f FIL to %s\n", util.ToFIL(plan.Assets), receiver)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unstakeCmd)
+	unstakeCmd.Flags().String("from", "default", "account holding the iFIL")
+	unstakeCmd.Flags().String("receiver", "", "address or account to receive the FIL, defaults to the from account")
+	unstakeCmd.Flags().Bool("ifil", false, "the amount is in iFIL instead of FIL")
+}
@@ -61,6 +61,9 @@ func logFatalf(format string, args ...interface{}) {
 	Exit(1)
 }
 
+// filAddressRe matches f0, f1, f2, f3 and f4 addresses and their testnet forms
+var filAddressRe = regexp.MustCompile(`^[tf][0-9]`)
+
 func AddressOrAccountNameToNative(ctx context.Context, addr string) (address.Address, error) {
 	lapi, closer, err := PoolsSDK.Extern().ConnectLotusClient()
 	if err != nil {
@@ -68,8 +71,7 @@ func AddressOrAccountNameToNative(ctx context.Context, addr string) (address.Add
 	}
 	defer closer()
 
-	re := regexp.MustCompile(`^[tf][0-9]`)
-	if re.MatchString(addr) {
+	if filAddressRe.MatchString(addr) {
 		// user passed f0, f1, f2, f3, or f4
 		filAddr, err := address.NewFromString(addr)
 		if err != nil {
@@ -96,6 +98,10 @@ func AddressOrAccountNameToNative(ctx context.Context, addr string) (address.Add
 		as := util.AccountsStore()
 		_, fevmAddr, err := as.GetAddrs(addr)
 		if err != nil {
+			var e *util.ErrKeyNotFound
+			if contact, ok := lookupContact(addr); ok && errors.As(err, &e) {
+				return AddressOrAccountNameToNative(ctx, contact.Address)
+			}
 			return address.Undef, err
 		}
 		return fevmAddr, nil
@@ -107,8 +113,7 @@ func AddressOrAccountNameToEVM(ctx context.Context, addr string) (common.Address
 		return common.HexToAddress(addr), nil
 	}
 
-	re := regexp.MustCompile(`^[tf][0-9]`)
-	if re.MatchString(addr) {
+	if filAddressRe.MatchString(addr) {
 		lapi, closer, err := PoolsSDK.Extern().ConnectLotusClient()
 		if err != nil {
 			return common.Address{}, err
@@ -121,11 +126,29 @@ func AddressOrAccountNameToEVM(ctx context.Context, addr string) (common.Address
 	as := util.AccountsStore()
 	evmAddr, _, err := as.GetAddrs(addr)
 	if err != nil {
+		var e *util.ErrKeyNotFound
+		if contact, ok := lookupContact(addr); ok && errors.As(err, &e) {
+			return AddressOrAccountNameToEVM(ctx, contact.Address)
+		}
 		return common.Address{}, err
 	}
 	return evmAddr, nil
 }
 
+// walletAccounts returns the EVM address of every account in the wallet, by name
+func walletAccounts() map[string]common.Address {
+	as := util.AccountsStore()
+	accounts := map[string]common.Address{}
+	for _, name := range as.AccountNames() {
+		addr, _, err := as.GetAddrs(name)
+		if err != nil {
+			continue
+		}
+		accounts[name] = addr
+	}
+	return accounts
+}
+
 func ToMinerID(ctx context.Context, addr string) (address.Address, error) {
 	minerAddr, err := address.NewFromString(addr)
 	if err != nil {
@@ -279,6 +302,17 @@ func commonOwnerOrOperatorSetup(cmd *cobra.Command, from string) (agentAddr comm
 		return common.Address{}, nil, accounts.Account{}, nil, err
 	}
 
+	requesterKey, err = getRequesterKey(as, ks)
+	if err != nil {
+		return common.Address{}, nil, accounts.Account{}, nil, err
+	}
+
+	// sign with the keyring when it holds the key, no passphrase needed
+	if keyringAuth, ok := keyringTransactor(fromAddress); ok {
+		setGasTipCapAndNonce(cmd, keyringAuth)
+		return agentAddr, keyringAuth, account, requesterKey, nil
+	}
+
 	var passphrase string
 	var envSet bool
 	var message string
@@ -292,19 +326,16 @@ func commonOwnerOrOperatorSetup(cmd *cobra.Command, from string) (agentAddr comm
 	if !envSet {
 		err = ks.Unlock(account, "")
 		if err != nil {
-			prompt := &survey.Password{Message: message}
-			survey.AskOne(prompt, &passphrase)
+			passphrase, err = askPassphrase(message, fromAddress)
+			if err != nil {
+				return common.Address{}, nil, accounts.Account{}, nil, err
+			}
 			if passphrase == "" {
 				return common.Address{}, nil, accounts.Account{}, nil, fmt.Errorf("Aborted")
 			}
 		}
 	}
 
-	requesterKey, err = getRequesterKey(as, ks)
-	if err != nil {
-		return common.Address{}, nil, accounts.Account{}, nil, err
-	}
-
 	auth, err = walletutils.NewEthWalletTransactor(wallet, &account, passphrase, big.NewInt(chainID))
 	if err != nil {
 		logFatal(err)
@@ -346,6 +377,12 @@ func commonGenericAccountSetup(cmd *cobra.Command, from string) (auth *bind.Tran
 		return nil, accounts.Account{}, err
 	}
 
+	// sign with the keyring when it holds the key, no passphrase needed
+	if keyringAuth, ok := keyringTransactor(fromAddress); ok {
+		setGasTipCapAndNonce(cmd, keyringAuth)
+		return keyringAuth, account, nil
+	}
+
 	var passphrase string
 	var envSet bool
 	var message string
@@ -354,8 +391,10 @@ func commonGenericAccountSetup(cmd *cobra.Command, from string) (auth *bind.Tran
 	if !envSet {
 		err = ks.Unlock(account, "")
 		if err != nil {
-			prompt := &survey.Password{Message: message}
-			survey.AskOne(prompt, &passphrase)
+			passphrase, err = askPassphrase(message, fromAddress)
+			if err != nil {
+				return nil, accounts.Account{}, err
+			}
 			if passphrase == "" {
 				return nil, accounts.Account{}, fmt.Errorf("Aborted")
 			}
@@ -479,6 +518,20 @@ func parseFILAmount(amount string) (*big.Int, error) {
 	return denoms.ToAtto(amt), nil
 }
 
+// formatFILAmount takes an amount in attoFIL and returns it as a FIL
+// string with the full 18 decimals of precision
+func formatFILAmount(atto *big.Int) string {
+	abs := new(big.Int).Abs(atto)
+	whole, frac := new(big.Int).QuoRem(abs, denoms.WAD, new(big.Int))
+
+	sign := ""
+	if atto.Sign() < 0 {
+		sign = "-"
+	}
+
+	return fmt.Sprintf("%s%s.%018d", sign, whole, frac)
+}
+
 func getAgentAddress() (common.Address, error) {
 	as := util.AgentStore()
 
@@ -599,7 +652,7 @@ func checkUnencryptedPrivateKeys() error {
 			return fmt.Errorf("error checking private key %s: %w", string(key), err)
 		}
 		if pk != "" {
-			return fmt.Errorf("unencrypted keys found in legacy keys.toml after migration. Remove to improve security.")
+			return fmt.Errorf("unencrypted keys found in legacy keys.toml after migration. Wipe it with: glif wallet audit --fix")
 		}
 	}
 
@@ -679,9 +732,14 @@ func confirmBackupExists() error {
 		fmt.Println("The configuration and keys are stored in the following directory:")
 		fmt.Println()
 		fmt.Printf("  %s\n\n", cfgDir)
-		fmt.Println("You can use a tool such as zip or tar to make an archive, and")
+		fmt.Println("The easiest way is to create an encrypted, verified backup with:")
+		fmt.Println()
+		fmt.Println("  glif backup create")
+		fmt.Println()
 		fmt.Println("then copy that file to a safe place. In the event of data loss,")
-		fmt.Println("you can use the backup to restore the files.")
+		fmt.Println("you can restore it with: glif backup restore <archive> --to <dir>")
+		fmt.Println()
+		fmt.Println("You can also use a tool such as zip or tar to make an archive.")
 		fmt.Println()
 		fmt.Println("If you lose your keys and you don't have a backup, then you will")
 		fmt.Println("lose access to the funds in your agent and control of your miners!")
@@ -832,6 +890,9 @@ func ensureNoPlusToken() error {
 	return nil
 }
 
+// errPlusCardNotMinted is returned by getPlusTokenID when agent.toml has no GLIF Card
+var errPlusCardNotMinted = errors.New("glif card not minted yet")
+
 func getPlusTokenID() (int64, error) {
 	agentStore := util.AgentStore()
 
@@ -841,7 +902,7 @@ func getPlusTokenID() (int64, error) {
 	}
 
 	if tokenIDStr == "" {
-		return 0, fmt.Errorf("glif card not minted yet")
+		return 0, errPlusCardNotMinted
 	}
 
 	tokenID, err := strconv.ParseInt(tokenIDStr, 10, 64)
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/rodaine/table"
+	"github.com/spf13/cobra"
+)
+
+const (
+	auditHigh   = "high"
+	auditMedium = "medium"
+	auditInfo   = "info"
+)
+
+// auditFinding is one issue found by wallet audit
+type auditFinding struct {
+	Severity string
+	Subject  string
+	Issue    string
+	Fix      string
+}
+
+// accountNamesByAddress returns the account names saved for each address in accounts.toml
+func accountNamesByAddress(as *util.AccountsStorage) map[common.Address][]string {
+	names := map[common.Address][]string{}
+	for _, name := range as.AccountNames() {
+		addr, err := as.Get(name)
+		if err != nil || !common.IsHexAddress(addr) {
+			continue
+		}
+		a := common.HexToAddress(addr)
+		names[a] = append(names[a], name)
+	}
+	for _, n := range names {
+		sort.Strings(n)
+	}
+	return names
+}
+
+// auditPermissions reports files and directories in the config directory
+// that group or others can access
+func auditPermissions(dir string) ([]auditFinding, []util.LoosePermission, error) {
+	loose, err := util.FindLoosePermissions(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var findings []auditFinding
+	for _, p := range loose {
+		severity := auditMedium
+		rel, _ := filepath.Rel(dir, p.Path)
+		if rel == "keystore" || strings.HasPrefix(rel, "keystore"+string(filepath.Separator)) || rel == "keys.toml" {
+			severity = auditHigh
+		}
+		kind := "file"
+		if p.IsDir {
+			kind = "directory"
+		}
+		findings = append(findings, auditFinding{
+			Severity: severity,
+			Subject:  p.Path,
+			Issue:    fmt.Sprintf("%s mode %04o is accessible by other users", kind, p.Mode),
+			Fix:      fmt.Sprintf("chmod to %04o with --fix", p.Mode&^0077),
+		})
+	}
+	return findings, loose, nil
+}
+
+// auditKeyFiles reports keystore files that are not valid keys, keys
+// encrypted with weak parameters or without a passphrase and keys that no
+// account in accounts.toml refers to
+func auditKeyFiles(keydir string, names map[common.Address][]string) ([]auditFinding, error) {
+	keys, invalid, err := util.ReadKeyFiles(keydir)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []auditFinding
+	for _, path := range invalid {
+		findings = append(findings, auditFinding{
+			Severity: auditMedium,
+			Subject:  path,
+			Issue:    "not an encrypted key file",
+			Fix:      "move the file out of the keystore",
+		})
+	}
+
+	for _, key := range keys {
+		accountNames := names[key.Address]
+		subject := key.Address.Hex()
+		if len(accountNames) > 0 {
+			subject = fmt.Sprintf("%s (%s)", strings.Join(accountNames, ", "), key.Address.Hex())
+		}
+
+		if key.WeakKDF() {
+			findings = append(findings, auditFinding{
+				Severity: auditMedium,
+				Subject:  subject,
+				Issue:    fmt.Sprintf("encrypted with weak %s parameters (cost %d)", key.KDF, key.Cost),
+				Fix:      fmt.Sprintf("re-encrypt with: glif wallet change-passphrase %s", key.Address.Hex()),
+			})
+		}
+
+		empty, err := key.EmptyPassphrase()
+		if err != nil {
+			return nil, err
+		}
+		if empty {
+			findings = append(findings, emptyPassphraseFinding(subject, key.Address, accountNames))
+		}
+
+		if len(accountNames) == 0 {
+			findings = append(findings, auditFinding{
+				Severity: auditInfo,
+				Subject:  key.Address.Hex(),
+				Issue:    "key is not saved under any account name",
+				Fix:      fmt.Sprintf("name it with: glif wallet label-account <name> %s", key.Address.Hex()),
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// emptyPassphraseFinding rates a key without a passphrase by the roles it has,
+// the requester key is meant to have no passphrase
+func emptyPassphraseFinding(subject string, addr common.Address, names []string) auditFinding {
+	severity := auditMedium
+	for _, name := range names {
+		if name == string(util.OwnerKey) {
+			severity = auditHigh
+		}
+	}
+	if len(names) == 1 && names[0] == string(util.RequestKey) {
+		severity = auditInfo
+	}
+
+	finding := auditFinding{
+		Severity: severity,
+		Subject:  subject,
+		Issue:    "key has an empty passphrase",
+		Fix:      fmt.Sprintf("set one with: glif wallet change-passphrase %s", addr.Hex()),
+	}
+	if severity == auditInfo {
+		finding.Issue = "requester key has an empty passphrase, as expected"
+		finding.Fix = ""
+	}
+	return finding
+}
+
+// auditAccounts reports accounts in accounts.toml without a key in the
+// keystore. Agent roles must have a key, other accounts may be read-only labels
+func auditAccounts(as *util.AccountsStorage, hasKey func(common.Address) bool) []auditFinding {
+	names := as.AccountNames()
+	sort.Strings(names)
+
+	var findings []auditFinding
+	for _, name := range names {
+		addr, err := as.Get(name)
+		if err != nil || addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			findings = append(findings, auditFinding{
+				Severity: auditMedium,
+				Subject:  name,
+				Issue:    fmt.Sprintf("invalid address %s in accounts.toml", addr),
+			})
+			continue
+		}
+		if hasKey(common.HexToAddress(addr)) {
+			continue
+		}
+
+		if name == string(util.OwnerKey) || name == string(util.OperatorKey) || name == string(util.RequestKey) {
+			findings = append(findings, auditFinding{
+				Severity: auditHigh,
+				Subject:  name,
+				Issue:    fmt.Sprintf("agent account %s has no key in the keystore", addr),
+				Fix:      "restore the key from a backup: glif backup restore",
+			})
+			continue
+		}
+		findings = append(findings, auditFinding{
+			Severity: auditInfo,
+			Subject:  name,
+			Issue:    fmt.Sprintf("%s has no key in the keystore, it is read-only", addr),
+		})
+	}
+	return findings
+}
+
+// legacyKeysMigrated reports whether every key in the legacy keys.toml is in
+// the encrypted keystore
+func legacyKeysMigrated(legacy *util.KeyStorageLegacy, hasKey func(common.Address) bool) (bool, error) {
+	for _, key := range []util.KeyType{util.OwnerKey, util.OperatorKey, util.RequestKey} {
+		addr, _, err := legacy.GetAddrs(key)
+		if err != nil {
+			var e *util.ErrKeyNotFound
+			if errors.As(err, &e) {
+				continue
+			}
+			return false, err
+		}
+		if !hasKey(addr) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// auditLegacyKeys reports a legacy keys.toml left in the config directory
+func auditLegacyKeys(filename string, legacy *util.KeyStorageLegacy, hasKey func(common.Address) bool) ([]auditFinding, bool, error) {
+	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+
+	migrated, err := legacyKeysMigrated(legacy, hasKey)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var hasKeys bool
+	for _, key := range []util.KeyType{util.OwnerKey, util.OperatorKey, util.RequestKey} {
+		if pk, _ := legacy.Get(string(key)); pk != "" {
+			hasKeys = true
+		}
+	}
+
+	finding := auditFinding{
+		Severity: auditInfo,
+		Subject:  filename,
+		Issue:    "legacy keys file is no longer needed",
+		Fix:      "wipe it with --fix",
+	}
+	if hasKeys {
+		finding.Severity = auditHigh
+		finding.Issue = "legacy keys file holds unencrypted private keys"
+	}
+	if !migrated {
+		finding.Fix = "migrate it first with: glif wallet migrate"
+	}
+
+	return []auditFinding{finding}, migrated, nil
+}
+
+func printAuditFindings(findings []auditFinding) {
+	rank := map[string]int{auditHigh: 0, auditMedium: 1, auditInfo: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank[findings[i].Severity] < rank[findings[j].Severity]
+	})
+
+	tbl := table.New("Severity", "Subject", "Issue", "Fix")
+	for _, f := range findings {
+		tbl.AddRow(f.Severity, f.Subject, f.Issue, f.Fix)
+	}
+	tbl.Print()
+}
+
+var walletAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check the wallet for insecure files and keys",
+	Long: `Check the config directory for files other users can access, keystore keys without a
+passphrase or encrypted with weak parameters, a legacy keys.toml with unencrypted private
+keys, and accounts without a key in the keystore.
+
+With --fix, group and other permissions are removed from every file and directory, and
+the legacy keys.toml is overwritten and removed once every key in it is confirmed to be in
+the keystore. Exits with status 1 when high severity issues remain.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, err := cmd.Flags().GetBool("fix")
+		if err != nil {
+			logFatal(err)
+		}
+		yes, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			logFatal(err)
+		}
+
+		as := util.AccountsStore()
+		ks := util.KeyStore()
+		legacyFile := filepath.Join(cfgDir, "keys.toml")
+
+		fmt.Println("Checking the keystore, this can take a few seconds per key...")
+
+		var findings []auditFinding
+
+		permFindings, loose, err := auditPermissions(cfgDir)
+		if err != nil {
+			logFatal(err)
+		}
+		keyFindings, err := auditKeyFiles(keyStoreDir(), accountNamesByAddress(as))
+		if err != nil {
+			logFatal(err)
+		}
+		legacyFindings, migrated, err := auditLegacyKeys(legacyFile, util.KeyStoreLegacy(), ks.HasAddress)
+		if err != nil {
+			logFatal(err)
+		}
+
+		if fix {
+			for _, p := range loose {
+				if err := util.FixPermission(p); err != nil {
+					logFatal(err)
+				}
+			}
+			if len(loose) > 0 {
+				log.Printf("Fixed the permissions of %d files and directories\n", len(loose))
+			}
+			permFindings = nil
+
+			if len(legacyFindings) > 0 {
+				if !migrated {
+					log.Println("Not wiping keys.toml, some of its keys are not in the keystore. Run: glif wallet migrate")
+				} else {
+					wipe := yes
+					if !yes {
+						survey.AskOne(&survey.Confirm{Message: fmt.Sprintf("Every key in %s is in the keystore. Overwrite and remove it?", legacyFile)}, &wipe)
+					}
+					if wipe {
+						if err := util.WipeFile(legacyFile); err != nil {
+							logFatal(err)
+						}
+						log.Printf("Wiped %s\n", legacyFile)
+						legacyFindings = nil
+					}
+				}
+			}
+		}
+
+		findings = append(findings, permFindings...)
+		findings = append(findings, keyFindings...)
+		findings = append(findings, legacyFindings...)
+		findings = append(findings, auditAccounts(as, ks.HasAddress)...)
+
+		if len(findings) == 0 {
+			fmt.Println("No issues found")
+			return
+		}
+
+		printAuditFindings(findings)
+
+		for _, f := range findings {
+			if f.Severity == auditHigh {
+				Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(walletAuditCmd)
+	walletAuditCmd.Flags().Bool("fix", false, "fix permissions and wipe the legacy keys.toml once migrated")
+	walletAuditCmd.Flags().Bool("yes", false, "wipe the legacy keys.toml without prompting")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/glif/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditAccounts(t *testing.T) {
+	assert.NoError(t, util.NewAccountsStore(filepath.Join(t.TempDir(), "accounts.toml")))
+	as := util.AccountsStore()
+	assert.NoError(t, as.Set("owner", "0x00000000000000000000000000000000000000aa"))
+	assert.NoError(t, as.Set("operator", "0x00000000000000000000000000000000000000bb"))
+	assert.NoError(t, as.Set("exchange", "0x00000000000000000000000000000000000000cc"))
+	assert.NoError(t, as.Set("broken", "nope"))
+
+	hasKey := func(addr common.Address) bool {
+		return addr == common.HexToAddress("0xaa")
+	}
+
+	findings := auditAccounts(as, hasKey)
+	assert.Len(t, findings, 3)
+	bySubject := map[string]auditFinding{}
+	for _, f := range findings {
+		bySubject[f.Subject] = f
+	}
+	assert.Equal(t, auditMedium, bySubject["broken"].Severity)
+	assert.Equal(t, auditInfo, bySubject["exchange"].Severity)
+	assert.Equal(t, auditHigh, bySubject["operator"].Severity)
+}
+
+func TestEmptyPassphraseFinding(t *testing.T) {
+	addr := common.HexToAddress("0xaa")
+	assert.Equal(t, auditHigh, emptyPassphraseFinding("owner", addr, []string{"owner", "spare"}).Severity)
+	assert.Equal(t, auditMedium, emptyPassphraseFinding("operator", addr, []string{"operator"}).Severity)
+	assert.Equal(t, auditMedium, emptyPassphraseFinding(addr.Hex(), addr, nil).Severity)
+
+	requester := emptyPassphraseFinding("request", addr, []string{"request"})
+	assert.Equal(t, auditInfo, requester.Severity)
+	assert.Empty(t, requester.Fix)
+}
+
+func TestAuditLegacyKeys(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "keys.toml")
+	hasNoKey := func(common.Address) bool { return false }
+
+	// no legacy file, nothing to report
+	assert.NoError(t, util.NewKeyStoreLegacy(filename))
+	findings, _, err := auditLegacyKeys(filename, util.KeyStoreLegacy(), hasNoKey)
+	assert.NoError(t, err)
+	assert.Empty(t, findings)
+
+	pk, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	owner := crypto.PubkeyToAddress(pk.PublicKey)
+	content := "owner = '" + common.Bytes2Hex(crypto.FromECDSA(pk)) + "'\noperator = ''\nrequest = ''\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+	assert.NoError(t, util.NewKeyStoreLegacy(filename))
+
+	findings, migrated, err := auditLegacyKeys(filename, util.KeyStoreLegacy(), hasNoKey)
+	assert.NoError(t, err)
+	assert.False(t, migrated)
+	assert.Len(t, findings, 1)
+	assert.Equal(t, auditHigh, findings[0].Severity)
+	assert.Contains(t, findings[0].Fix, "glif wallet migrate")
+
+	findings, migrated, err = auditLegacyKeys(filename, util.KeyStoreLegacy(), func(addr common.Address) bool { return addr == owner })
+	assert.NoError(t, err)
+	assert.True(t, migrated)
+	assert.Equal(t, "wipe it with --fix", findings[0].Fix)
+}
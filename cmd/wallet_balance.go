@@ -6,44 +6,325 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/glifio/glif/v2/util"
-	denoms "github.com/glifio/go-pools/util"
+	"github.com/glifio/go-pools/abigen"
+	"github.com/glifio/go-pools/constants"
 	"github.com/spf13/cobra"
 )
 
-func printBalance(ctx context.Context, lapi *api.FullNodeStruct, as *util.AccountsStorage, name string) {
-	_, addr, err := as.GetAddrs(name)
+// tokenBalance is an account's balance of a registered token
+type tokenBalance struct {
+	Token  erc20Token
+	Amount *big.Int
+}
+
+// accountBalance is every asset held by a wallet account, in base units
+type accountBalance struct {
+	Name    string
+	Address common.Address
+	FIL     *big.Int
+	WFIL    *big.Int
+	IFIL    *big.Int
+	GLF     *big.Int
+	// GLFPlans is the GLF in the account's airdrop plans, of which
+	// GLFRedeemable can be redeemed now
+	GLFPlans      *big.Int
+	GLFRedeemable *big.Int
+	// Pending is the most FIL the account's pending mempool messages can
+	// spend, their value plus their max gas fee
+	Pending *big.Int
+	Tokens  []tokenBalance
+}
+
+// balanceTotals sums the balances of every account
+type balanceTotals struct {
+	FIL       *big.Int
+	WFIL      *big.Int
+	IFIL      *big.Int
+	IFILValue *big.Int
+	GLF       *big.Int
+	GLFPlans  *big.Int
+	Pending   *big.Int
+	// AgentLiquid is the liquid assets of the Agent, nil without an Agent
+	AgentLiquid *big.Int
+	// Value is the FIL, WFIL, iFIL and Agent liquid assets in FIL
+	Value *big.Int
+}
+
+// ifilValue converts iFIL into FIL at price, in wad
+func ifilValue(ifil *big.Int, price *big.Int) *big.Int {
+	return new(big.Int).Div(new(big.Int).Mul(ifil, price), constants.WAD)
+}
+
+// pendingOutflows sums the value and max gas fee of the pending messages of
+// every sender
+func pendingOutflows(msgs []*types.SignedMessage) map[address.Address]*big.Int {
+	outflows := map[address.Address]*big.Int{}
+	for _, msg := range msgs {
+		out := new(big.Int).Mul(msg.Message.GasFeeCap.Int, big.NewInt(msg.Message.GasLimit))
+		out.Add(out, msg.Message.Value.Int)
+		if total, ok := outflows[msg.Message.From]; ok {
+			total.Add(total, out)
+		} else {
+			outflows[msg.Message.From] = out
+		}
+	}
+	return outflows
+}
+
+// sumBalances totals the balances, counting accounts with the same address once
+func sumBalances(balances []accountBalance, price *big.Int, agentLiquid *big.Int) balanceTotals {
+	t := balanceTotals{
+		FIL:         big.NewInt(0),
+		WFIL:        big.NewInt(0),
+		IFIL:        big.NewInt(0),
+		GLF:         big.NewInt(0),
+		GLFPlans:    big.NewInt(0),
+		Pending:     big.NewInt(0),
+		AgentLiquid: agentLiquid,
+	}
+
+	seen := map[common.Address]bool{}
+	for _, b := range balances {
+		if seen[b.Address] {
+			continue
+		}
+		seen[b.Address] = true
+
+		t.FIL.Add(t.FIL, b.FIL)
+		t.WFIL.Add(t.WFIL, b.WFIL)
+		t.IFIL.Add(t.IFIL, b.IFIL)
+		t.GLF.Add(t.GLF, b.GLF)
+		t.GLFPlans.Add(t.GLFPlans, b.GLFPlans)
+		t.Pending.Add(t.Pending, b.Pending)
+	}
+
+	t.IFILValue = ifilValue(t.IFIL, price)
+	t.Value = new(big.Int).Add(t.FIL, t.WFIL)
+	t.Value.Add(t.Value, t.IFILValue)
+	if agentLiquid != nil {
+		t.Value.Add(t.Value, agentLiquid)
+	}
+	return t
+}
+
+// formatBalance formats an amount of base units in whole tokens, with two
+// decimals or, with full, every decimal of the token
+func formatBalance(amount *big.Int, decimals uint8, full bool) string {
+	if !full {
+		f, _ := erc20Token{Decimals: decimals}.ToUnits(amount).Float64()
+		return fmt.Sprintf("%.02f", f)
+	}
+	if decimals == 0 {
+		return amount.String()
+	}
+
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(new(big.Int).Abs(amount), unit, new(big.Int))
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%s.%0*d", sign, whole, int(decimals), frac)
+}
+
+// balanceReader reads the balances of wallet accounts
+type balanceReader struct {
+	lapi     *api.FullNodeStruct
+	wfil     *abigen.PoolTokenCaller
+	ifil     *abigen.PoolTokenCaller
+	glf      *abigen.PoolTokenCaller
+	tokens   []erc20Token
+	client   *ethclient.Client
+	pending  map[address.Address]*big.Int
+	plans    map[common.Address][]vestingPlan
+	callOpts *bind.CallOpts
+}
+
+func (r *balanceReader) read(ctx context.Context, as *util.AccountsStorage, name string) (accountBalance, error) {
+	evmAddr, addr, err := as.GetAddrs(name)
 	if err != nil {
-		fmt.Printf("%s balance: Error %v\n", name, err)
-		return
+		return accountBalance{}, err
+	}
+
+	b := accountBalance{
+		Name:          name,
+		Address:       evmAddr,
+		GLFPlans:      big.NewInt(0),
+		GLFRedeemable: big.NewInt(0),
+		Pending:       big.NewInt(0),
 	}
 
-	bal, err := lapi.WalletBalance(ctx, addr)
+	bal, err := r.lapi.WalletBalance(ctx, addr)
 	if err != nil {
-		fmt.Printf("%s balance: Error %v\n", name, err)
-		return
+		return accountBalance{}, err
+	}
+	b.FIL = bal.Int
+
+	if b.WFIL, err = r.wfil.BalanceOf(r.callOpts, evmAddr); err != nil {
+		return accountBalance{}, fmt.Errorf("failed to get wFIL balance: %w", err)
+	}
+	if b.IFIL, err = r.ifil.BalanceOf(r.callOpts, evmAddr); err != nil {
+		return accountBalance{}, fmt.Errorf("failed to get iFIL balance: %w", err)
+	}
+	if b.GLF, err = r.glf.BalanceOf(r.callOpts, evmAddr); err != nil {
+		return accountBalance{}, fmt.Errorf("failed to get GLF balance: %w", err)
+	}
+
+	for _, p := range r.plans[evmAddr] {
+		b.GLFPlans.Add(b.GLFPlans, p.Plan.Amount)
+		b.GLFRedeemable.Add(b.GLFRedeemable, p.Redeemable)
+	}
+	if pending, ok := r.pending[addr]; ok {
+		b.Pending = pending
+	}
+
+	// registered tokens are only listed when the account holds them
+	for _, token := range r.tokens {
+		caller, err := abigen.NewPoolTokenCaller(token.Address, r.client)
+		if err != nil {
+			return accountBalance{}, err
+		}
+		tbal, err := caller.BalanceOf(r.callOpts, evmAddr)
+		if err != nil {
+			return accountBalance{}, fmt.Errorf("failed to get %s balance: %w", token, err)
+		}
+		if tbal.Sign() > 0 {
+			b.Tokens = append(b.Tokens, tokenBalance{Token: token, Amount: tbal})
+		}
+	}
+
+	return b, nil
+}
+
+func printBalance(b accountBalance, price *big.Int, full bool) {
+	fmt.Printf("%s balance: %s FIL\n", b.Name, formatBalance(b.FIL, 18, full))
+
+	// other assets are only listed when the account holds them
+	if b.WFIL.Sign() > 0 {
+		fmt.Printf("  wFIL: %s wFIL\n", formatBalance(b.WFIL, 18, full))
+	}
+	if b.IFIL.Sign() > 0 {
+		fmt.Printf("  iFIL: %s iFIL (%s FIL)\n", formatBalance(b.IFIL, 18, full), formatBalance(ifilValue(b.IFIL, price), 18, full))
+	}
+	if b.GLF.Sign() > 0 {
+		fmt.Printf("  GLF: %s GLF\n", formatBalance(b.GLF, 18, full))
+	}
+	if b.GLFPlans.Sign() > 0 {
+		locked := new(big.Int).Sub(b.GLFPlans, b.GLFRedeemable)
+		fmt.Printf("  GLF in airdrop plans: %s GLF locked, %s GLF redeemable\n", formatBalance(locked, 18, full), formatBalance(b.GLFRedeemable, 18, full))
+	}
+	for _, t := range b.Tokens {
+		fmt.Printf("  %s: %s %s\n", t.Token, formatBalance(t.Amount, t.Token.Decimals, full), t.Token)
+	}
+	if b.Pending.Sign() > 0 {
+		fmt.Printf("  Pending outflows: up to %s FIL in the mempool\n", formatBalance(b.Pending, 18, full))
+	}
+}
+
+func printBalanceTotals(t balanceTotals, full bool) {
+	fmt.Printf("Totals:\n\n")
+	fmt.Printf("FIL: %s FIL\n", formatBalance(t.FIL, 18, full))
+	fmt.Printf("wFIL: %s wFIL\n", formatBalance(t.WFIL, 18, full))
+	fmt.Printf("iFIL: %s iFIL (%s FIL)\n", formatBalance(t.IFIL, 18, full), formatBalance(t.IFILValue, 18, full))
+	if t.AgentLiquid != nil {
+		fmt.Printf("Agent liquid assets: %s FIL\n", formatBalance(t.AgentLiquid, 18, full))
+	}
+	fmt.Printf("Total value: %s FIL\n", formatBalance(t.Value, 18, full))
+	fmt.Printf("GLF: %s GLF, plus %s GLF in airdrop plans\n", formatBalance(t.GLF, 18, full), formatBalance(t.GLFPlans, 18, full))
+	if t.Pending.Sign() > 0 {
+		fmt.Printf("Pending outflows: up to %s FIL\n", formatBalance(t.Pending, 18, full))
 	}
-	balance := denoms.ToFIL(bal.Int)
-	bf64, _ := balance.Float64()
-	fmt.Printf("%s balance: %.02f FIL\n", name, bf64)
 }
 
 // newCmd represents the new command
 var balCmd = &cobra.Command{
 	Use:   "balance",
 	Short: "Gets the balances associated with your accounts",
+	Long: `Gets the FIL, wFIL, iFIL, GLF and registered token balances of your accounts,
+with the FIL value of iFIL at the current iFIL price, the GLF in airdrop plans,
+and the FIL that pending mempool messages can spend. With an Agent, its liquid
+assets are shown with the Agent accounts. Amounts are shown with two decimals,
+pass --full for every decimal.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := cmd.Context()
 		as := util.AccountsStore()
 
+		full, err := cmd.Flags().GetBool("full")
+		if err != nil {
+			logFatal(err)
+		}
+
 		lapi, closer, err := PoolsSDK.Extern().ConnectLotusClient()
 		if err != nil {
 			logFatalf("Failed to instantiate eth client %s", err)
 		}
 		defer closer()
 
+		client, err := PoolsSDK.Extern().ConnectEthClient()
+		if err != nil {
+			logFatalf("Failed to instantiate eth client %s", err)
+		}
+		defer client.Close()
+
+		query := PoolsSDK.Query()
+		r := &balanceReader{
+			lapi:     lapi,
+			tokens:   registeredTokens(),
+			client:   client,
+			callOpts: &bind.CallOpts{Context: ctx},
+			plans:    map[common.Address][]vestingPlan{},
+		}
+		if r.wfil, err = abigen.NewPoolTokenCaller(query.WFIL(), client); err != nil {
+			logFatal(err)
+		}
+		if r.ifil, err = abigen.NewPoolTokenCaller(query.IFIL(), client); err != nil {
+			logFatal(err)
+		}
+		if r.glf, err = abigen.NewPoolTokenCaller(query.GLF(), client); err != nil {
+			logFatal(err)
+		}
+
+		price, err := query.IFILPrice(ctx, nil)
+		if err != nil {
+			logFatalf("Failed to get iFIL price %s", err)
+		}
+
+		msgs, err := lapi.MpoolPending(ctx, types.EmptyTSK)
+		if err != nil {
+			fmt.Printf("Failed to get pending messages, pending outflows are not shown: %v\n", err)
+		}
+		r.pending = pendingOutflows(msgs)
+
+		plans, err := listVestingPlans(ctx, walletAccounts(), time.Now())
+		if err != nil {
+			fmt.Printf("Failed to get airdrop plans, GLF in plans is not shown: %v\n", err)
+		}
+		for _, p := range plans {
+			r.plans[p.Owner] = append(r.plans[p.Owner], p)
+		}
+
+		var balances []accountBalance
+		printAccount := func(name string) {
+			b, err := r.read(ctx, as, name)
+			if err != nil {
+				fmt.Printf("%s balance: Error %v\n", name, err)
+				return
+			}
+			balances = append(balances, b)
+			printBalance(b, price, full)
+		}
+
+		var agentLiquid *big.Int
 		owner, _ := as.Get(string(util.OwnerKey))
 		operator, _ := as.Get(string(util.OperatorKey))
 		if owner != "" || operator != "" {
@@ -52,8 +333,17 @@ var balCmd = &cobra.Command{
 				string(util.OperatorKey),
 			}
 			fmt.Printf("Agent accounts:\n\n")
+			if agentAddr, err := getAgentAddress(); err == nil {
+				liquid, err := query.AgentLiquidAssets(ctx, agentAddr, nil)
+				if err != nil {
+					fmt.Printf("Agent liquid assets: Error %v\n", err)
+				} else {
+					agentLiquid = liquid
+					fmt.Printf("Agent %s liquid assets: %s FIL\n", agentAddr, formatBalance(liquid, 18, full))
+				}
+			}
 			for _, name := range agentNames {
-				printBalance(ctx, lapi, as, name)
+				printAccount(name)
 			}
 			fmt.Println()
 		}
@@ -72,13 +362,16 @@ var balCmd = &cobra.Command{
 		if len(names) > 0 {
 			fmt.Printf("Regular accounts:\n\n")
 			for _, name := range names {
-				printBalance(ctx, lapi, as, name)
+				printAccount(name)
 			}
 			fmt.Println()
 		}
+
+		printBalanceTotals(sumBalances(balances, price, agentLiquid), full)
 	},
 }
 
 func init() {
 	walletCmd.AddCommand(balCmd)
+	balCmd.Flags().Bool("full", false, "show amounts with every decimal instead of two")
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBalance(t *testing.T) {
+	amount := new(big.Int).Add(fil(12), big.NewInt(345))
+	assert.Equal(t, "12.00", formatBalance(amount, 18, false))
+	assert.Equal(t, "12.000000000000000345", formatBalance(amount, 18, true))
+	assert.Equal(t, "-12.000000000000000345", formatBalance(new(big.Int).Neg(amount), 18, true))
+	assert.Equal(t, "1.005000", formatBalance(big.NewInt(1005000), 6, true))
+	assert.Equal(t, "1.00", formatBalance(big.NewInt(1005000), 6, false))
+	assert.Equal(t, "7", formatBalance(big.NewInt(7), 0, true))
+}
+
+func TestPendingOutflows(t *testing.T) {
+	a, _ := address.NewIDAddress(1)
+	b, _ := address.NewIDAddress(2)
+	msg := func(from address.Address, value int64, feeCap int64, gasLimit int64) *types.SignedMessage {
+		return &types.SignedMessage{Message: types.Message{
+			From:      from,
+			Value:     types.NewInt(uint64(value)),
+			GasFeeCap: types.NewInt(uint64(feeCap)),
+			GasLimit:  gasLimit,
+		}}
+	}
+
+	outflows := pendingOutflows([]*types.SignedMessage{
+		msg(a, 100, 2, 10),
+		msg(a, 0, 3, 10),
+		msg(b, 5, 1, 1),
+	})
+	assert.Len(t, outflows, 2)
+	assert.Equal(t, big.NewInt(150), outflows[a])
+	assert.Equal(t, big.NewInt(6), outflows[b])
+	assert.Empty(t, pendingOutflows(nil))
+}
+
+func TestSumBalances(t *testing.T) {
+	balance := func(addr string, n int64) accountBalance {
+		return accountBalance{
+			Address:  common.HexToAddress(addr),
+			FIL:      fil(n),
+			WFIL:     fil(n),
+			IFIL:     fil(n),
+			GLF:      fil(n),
+			GLFPlans: fil(n),
+			Pending:  fil(n),
+		}
+	}
+	price := new(big.Int).Div(fil(11), big.NewInt(10))
+
+	// the same address under two names is counted once
+	totals := sumBalances([]accountBalance{balance("0x1", 1), balance("0x2", 2), balance("0x1", 1)}, price, fil(10))
+	assert.Equal(t, fil(3), totals.FIL)
+	assert.Equal(t, fil(3), totals.WFIL)
+	assert.Equal(t, fil(3), totals.IFIL)
+	assert.Equal(t, new(big.Int).Div(fil(33), big.NewInt(10)), totals.IFILValue)
+	assert.Equal(t, fil(3), totals.GLF)
+	assert.Equal(t, fil(3), totals.GLFPlans)
+	assert.Equal(t, fil(3), totals.Pending)
+	// 3 FIL + 3 wFIL + 3.3 FIL of iFIL + 10 FIL on the Agent
+	assert.Equal(t, new(big.Int).Div(fil(193), big.NewInt(10)), totals.Value)
+
+	totals = sumBalances(nil, price, nil)
+	assert.Nil(t, totals.AgentLiquid)
+	assert.Zero(t, totals.Value.Sign())
+}
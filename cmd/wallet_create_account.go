@@ -21,6 +21,7 @@ import (
 var createAccountCmd = &cobra.Command{
 	Use:   "create-account [account-name]",
 	Short: "Create a single named account",
+	Long:  "Create a single named account. If the wallet has a recovery phrase, created with create-hd, the account is derived from it unless --random is passed.",
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		as := util.AccountsStore()
@@ -67,14 +68,32 @@ var createAccountCmd = &cobra.Command{
 			}
 		}
 
-		ks := util.KeyStore()
-
-		account, err := ks.NewAccount(passphrase)
+		random, err := cmd.Flags().GetBool("random")
 		if err != nil {
 			logFatal(err)
 		}
 
-		as.Set(name, account.Address.String())
+		if err := recordKeyStoreNetwork(); err != nil {
+			logFatal(err)
+		}
+		if util.HasHDWallet(keyStoreDir()) && !random {
+			w, err := unlockHDWallet()
+			if err != nil {
+				logFatal(err)
+			}
+			if _, err := w.createAccount(name, nextHDIndex(as.DerivationPaths()), passphrase); err != nil {
+				logFatal(err)
+			}
+		} else {
+			ks := util.KeyStore()
+
+			account, err := ks.NewAccount(passphrase)
+			if err != nil {
+				logFatal(err)
+			}
+
+			as.Set(name, account.Address.String())
+		}
 
 		if err := viper.WriteConfig(); err != nil {
 			logFatal(err)
@@ -89,9 +108,13 @@ var createAccountCmd = &cobra.Command{
 		bs.Invalidate()
 
 		log.Printf("%s address: %s (ETH), %s (FIL)\n", name, accountAddr, accountDelAddr)
+		if path, ok := as.DerivationPath(name); ok {
+			log.Printf("Derived from your recovery phrase at %s\n", path)
+		}
 	},
 }
 
 func init() {
 	walletCmd.AddCommand(createAccountCmd)
+	createAccountCmd.Flags().Bool("random", false, "generate an independent random key even if the wallet has a recovery phrase")
 }
@@ -36,6 +36,9 @@ var createAgentAccountsCmd = &cobra.Command{
 		"operator" - a sub-account with reduced permissions to perform routine transactions (eg. payments),
 		             passphrase protection is optional
 		"requester" - used for requesting credentials from the "Agent Data Oracle" (no passphrase)
+
+	If the wallet has a recovery phrase, created with create-hd, the accounts are derived from it
+	unless --random is passed.
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -59,29 +62,55 @@ var createAgentAccountsCmd = &cobra.Command{
 			}
 		}
 
-		ks := util.KeyStore()
+		operatorPassphrase := os.Getenv("GLIF_OPERATOR_PASSPHRASE")
 
-		owner, err := ks.NewAccount(ownerPassphrase)
+		random, err := cmd.Flags().GetBool("random")
 		if err != nil {
 			logFatal(err)
 		}
 
-		operatorPassphrase := os.Getenv("GLIF_OPERATOR_PASSPHRASE")
-		operator, err := ks.NewAccount(operatorPassphrase)
-		if err != nil {
-			logFatal(err)
-		}
+		as := util.AccountsStore()
 
-		requester, err := ks.NewAccount("")
-		if err != nil {
+		if err := recordKeyStoreNetwork(); err != nil {
 			logFatal(err)
 		}
+		if util.HasHDWallet(keyStoreDir()) && !random {
+			w, err := unlockHDWallet()
+			if err != nil {
+				logFatal(err)
+			}
+			passphrases := map[util.KeyType]string{
+				util.OwnerKey:    ownerPassphrase,
+				util.OperatorKey: operatorPassphrase,
+				util.RequestKey:  "",
+			}
+			for _, role := range hdRoleIndexes {
+				if _, err := w.createAccount(string(role.key), role.index, passphrases[role.key]); err != nil {
+					logFatal(err)
+				}
+			}
+		} else {
+			ks := util.KeyStore()
 
-		as := util.AccountsStore()
+			owner, err := ks.NewAccount(ownerPassphrase)
+			if err != nil {
+				logFatal(err)
+			}
 
-		as.Set(string(util.OwnerKey), owner.Address.String())
-		as.Set(string(util.OperatorKey), operator.Address.String())
-		as.Set(string(util.RequestKey), requester.Address.String())
+			operator, err := ks.NewAccount(operatorPassphrase)
+			if err != nil {
+				logFatal(err)
+			}
+
+			requester, err := ks.NewAccount("")
+			if err != nil {
+				logFatal(err)
+			}
+
+			as.Set(string(util.OwnerKey), owner.Address.String())
+			as.Set(string(util.OperatorKey), operator.Address.String())
+			as.Set(string(util.RequestKey), requester.Address.String())
+		}
 
 		if err := viper.WriteConfig(); err != nil {
 			logFatal(err)
@@ -113,4 +142,5 @@ var createAgentAccountsCmd = &cobra.Command{
 
 func init() {
 	walletCmd.AddCommand(createAgentAccountsCmd)
+	createAgentAccountsCmd.Flags().Bool("random", false, "generate independent random keys even if the wallet has a recovery phrase")
 }
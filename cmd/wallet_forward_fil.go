@@ -4,37 +4,65 @@ Copyright © 2023 Glif LTD
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"math/big"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/filecoin-project/go-address"
 	"github.com/glifio/glif/v2/events"
 	"github.com/glifio/go-pools/abigen"
-	"github.com/glifio/go-pools/constants"
-	"github.com/glifio/go-pools/deploy"
 	denoms "github.com/glifio/go-pools/util"
 	"github.com/spf13/cobra"
 )
 
+// forwardFILTx sends value from the auth account to any Filecoin address
+// through the FilForwarder smart contract
+func forwardFILTx(ctx context.Context, auth *bind.TransactOpts, to address.Address, value *big.Int) (*types.Transaction, error) {
+	filForwardAddr := network.FilForwarder
+	if filForwardAddr == (common.Address{}) {
+		return nil, fmt.Errorf("no FilForwarder known for the %s network, set network.fil-forwarder in your config", network.Name)
+	}
+
+	ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+	defer ethClient.Close()
+
+	nonce, err := PoolsSDK.Query().ChainGetNonce(ctx, auth.From)
+	if err != nil {
+		return nil, err
+	}
+
+	// get the FilForwarder contract address
+	filf, err := abigen.NewFilForwarderTransactor(filForwardAddr, ethClient)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := *auth
+	opts.Nonce = nonce
+	opts.Value = value
+
+	return filf.Forward(&opts, to.Bytes())
+}
+
 var forwardFIL = &cobra.Command{
 	Use:   "forward-fil <from> <to> <amount>",
 	Short: "Transfers balances from an account to another address through the FilForwarder smart contract",
 	Args:  cobra.ExactArgs(3),
 	Run: func(cmd *cobra.Command, args []string) {
 		from := args[0]
-		auth, senderAccount, err := commonGenericAccountSetup(cmd, from)
-		if err != nil {
-			logFatal(err)
-		}
-
-		ethClient, err := PoolsSDK.Extern().ConnectEthClient()
+		auth, _, err := commonGenericAccountSetup(cmd, from)
 		if err != nil {
 			logFatal(err)
 		}
-		defer ethClient.Close()
 
 		toStr := args[1]
 
@@ -42,6 +70,7 @@ var forwardFIL = &cobra.Command{
 		if err != nil {
 			logFatal(err)
 		}
+		warnUnknownRecipient(toStr)
 
 		value, err := parseFILAmount(args[2])
 		if err != nil {
@@ -72,39 +101,7 @@ var forwardFIL = &cobra.Command{
 		defer journal.Close()
 		defer journal.RecordEvent(forwardFILevt, func() interface{} { return evt })
 
-		nonce, err := PoolsSDK.Query().ChainGetNonce(cmd.Context(), senderAccount.Address)
-		if err != nil {
-			evt.Error = err.Error()
-			logFatal(err)
-		}
-
-		chainID := PoolsSDK.Query().ChainID()
-
-		var filForwardAddr common.Address
-		switch chainID.Int64() {
-		case constants.MainnetChainID:
-			filForwardAddr = deploy.FilForwarder
-		case constants.CalibnetChainID:
-			filForwardAddr = deploy.TFilForwarder
-		case constants.LocalnetChainID:
-			filForwardAddr = common.HexToAddress(os.Getenv("GLIF_FIL_FORWARDER"))
-		default:
-			err = errors.New("unsupported chain id for forward-fil command")
-			evt.Error = err.Error()
-			logFatal(err)
-		}
-
-		// get the FilForwarder contract address
-		filf, err := abigen.NewFilForwarderTransactor(filForwardAddr, ethClient)
-		if err != nil {
-			evt.Error = err.Error()
-			logFatal(err)
-		}
-
-		auth.Nonce = nonce
-		auth.Value = value
-
-		tx, err := filf.Forward(auth, to.Bytes())
+		tx, err := forwardFILTx(cmd.Context(), auth, to, value)
 		if err != nil {
 			evt.Error = err.Error()
 			logFatal(err)
@@ -0,0 +1,459 @@
+/*
+Copyright © 2023 Glif LTD
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// hdRoleIndexes are the indexes the agent accounts are derived at, named
+// accounts are derived from hdFirstNamedIndex on
+var hdRoleIndexes = []struct {
+	key   util.KeyType
+	index uint32
+}{
+	{util.OwnerKey, 0},
+	{util.OperatorKey, 1},
+	{util.RequestKey, 2},
+}
+
+const hdFirstNamedIndex = 3
+
+func keyStoreDir() string {
+	return fmt.Sprintf("%s/keystore", cfgDir)
+}
+
+// hdBasePathForCoinType returns the base path for the --coin-type flag
+func hdBasePathForCoinType(coinType uint32) (accounts.DerivationPath, error) {
+	if coinType != util.HDCoinTypeEthereum && coinType != util.HDCoinTypeFilecoin {
+		return nil, fmt.Errorf("unsupported coin type %d, use %d (Ethereum) or %d (Filecoin)", coinType, util.HDCoinTypeEthereum, util.HDCoinTypeFilecoin)
+	}
+	return util.HDBasePath(coinType), nil
+}
+
+// hdPathIndex returns the account index, the last element, of a derivation path
+func hdPathIndex(path string) (uint32, error) {
+	parsed, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(parsed) == 0 {
+		return 0, fmt.Errorf("empty derivation path %s", path)
+	}
+	return parsed[len(parsed)-1], nil
+}
+
+// nextHDIndex returns the index the next named account is derived at, after
+// every account recorded in paths
+func nextHDIndex(paths map[string]string) uint32 {
+	next := uint32(hdFirstNamedIndex)
+	for _, path := range paths {
+		index, err := hdPathIndex(path)
+		if err != nil {
+			continue
+		}
+		if index >= next {
+			next = index + 1
+		}
+	}
+	return next
+}
+
+// getRecoveryPhrasePassphrase reads the passphrase the recovery phrase is
+// encrypted with from GLIF_RECOVERY_PASSPHRASE or prompts for it, asking
+// twice when confirm is set
+func getRecoveryPhrasePassphrase(confirm bool) (string, error) {
+	passphrase, envSet := os.LookupEnv("GLIF_RECOVERY_PASSPHRASE")
+	if envSet {
+		return passphrase, nil
+	}
+
+	prompt := &survey.Password{Message: "Recovery phrase passphrase"}
+	survey.AskOne(prompt, &passphrase)
+	if passphrase == "" {
+		return "", fmt.Errorf("aborting, no passphrase entered")
+	}
+
+	if confirm {
+		var confirmPassphrase string
+		confirmPrompt := &survey.Password{Message: "Confirm recovery phrase passphrase"}
+		survey.AskOne(confirmPrompt, &confirmPassphrase)
+		if passphrase != confirmPassphrase {
+			return "", fmt.Errorf("aborting, passphrase confirmation did not match")
+		}
+	}
+
+	return passphrase, nil
+}
+
+// getAccountPassphrase reads the passphrase a new key is encrypted with from
+// env or prompts for it twice
+func getAccountPassphrase(env string, message string) (string, error) {
+	passphrase, envSet := os.LookupEnv(env)
+	if envSet {
+		return passphrase, nil
+	}
+
+	prompt := &survey.Password{Message: message}
+	survey.AskOne(prompt, &passphrase)
+	var confirmPassphrase string
+	confirmPrompt := &survey.Password{Message: "Confirm passphrase"}
+	survey.AskOne(confirmPrompt, &confirmPassphrase)
+	if passphrase != confirmPassphrase {
+		return "", fmt.Errorf("aborting, passphrase confirmation did not match")
+	}
+
+	return passphrase, nil
+}
+
+// hdWallet is an unlocked recovery phrase, ready to derive accounts
+type hdWallet struct {
+	seed []byte
+	base accounts.DerivationPath
+}
+
+// unlockHDWallet decrypts the recovery phrase of the wallet
+func unlockHDWallet() (*hdWallet, error) {
+	passphrase, err := getRecoveryPhrasePassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, base, err := util.LoadHDWallet(keyStoreDir(), passphrase)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := util.MnemonicSeed(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return &hdWallet{seed: seed, base: base}, nil
+}
+
+// address returns the address of the account at index without importing it
+func (w *hdWallet) address(index uint32) (common.Address, error) {
+	key, err := util.DeriveHDKey(w.seed, util.HDAccountPath(w.base, index))
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
+
+// importAccount derives the account at index and imports its key into the
+// keystore. Keys already in the keystore are left untouched
+func (w *hdWallet) importAccount(ks *keystore.KeyStore, index uint32, passphrase string) (accounts.Account, accounts.DerivationPath, error) {
+	path := util.HDAccountPath(w.base, index)
+	key, err := util.DeriveHDKey(w.seed, path)
+	if err != nil {
+		return accounts.Account{}, nil, err
+	}
+
+	account, err := ks.ImportECDSA(key, passphrase)
+	if errors.Is(err, keystore.ErrAccountAlreadyExists) {
+		account, err = ks.Find(accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)})
+	}
+	if err != nil {
+		return accounts.Account{}, nil, err
+	}
+
+	return account, path, nil
+}
+
+// createAccount derives the account at index, saves it under name in
+// accounts.toml and records its derivation path
+func (w *hdWallet) createAccount(name string, index uint32, passphrase string) (accounts.Account, error) {
+	account, path, err := w.importAccount(util.KeyStore(), index, passphrase)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+
+	as := util.AccountsStore()
+	if err := as.Set(name, account.Address.String()); err != nil {
+		return accounts.Account{}, err
+	}
+	if err := as.SetDerivationPath(name, path.String()); err != nil {
+		return accounts.Account{}, err
+	}
+
+	return account, nil
+}
+
+// discoverHDAccounts returns the indexes from start on that used reports as
+// used, stopping after gap unused indexes in a row
+func discoverHDAccounts(w *hdWallet, start uint32, gap int, used func(common.Address) (bool, error)) ([]uint32, error) {
+	var found []uint32
+	for index, unused := start, 0; unused < gap; index++ {
+		addr, err := w.address(index)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := used(addr)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = append(found, index)
+			unused = 0
+		} else {
+			unused++
+		}
+	}
+	return found, nil
+}
+
+// hdAccountUsedOnChain returns a function reporting whether an address has a
+// balance or has sent a transaction
+func hdAccountUsedOnChain(ctx context.Context) (func(common.Address) (bool, error), error) {
+	client, err := PoolsSDK.Extern().ConnectEthClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(addr common.Address) (bool, error) {
+		nonce, err := client.NonceAt(ctx, addr, nil)
+		if err != nil {
+			return false, err
+		}
+		if nonce > 0 {
+			return true, nil
+		}
+		balance, err := client.BalanceAt(ctx, addr, nil)
+		if err != nil {
+			return false, err
+		}
+		return balance.Sign() > 0, nil
+	}, nil
+}
+
+var createHDCmd = &cobra.Command{
+	Use:   "create-hd",
+	Short: "Create a recovery phrase to derive the wallet's accounts from",
+	Long: `Create a BIP-39 recovery phrase and save it, encrypted, in the keystore. Accounts
+created afterwards with create-agent-accounts and create-account are derived from the
+phrase along BIP-44 paths, owner, operator and requester at indexes 0, 1 and 2 and named
+accounts from index 3 on, so the whole wallet can be restored with restore-hd from the
+phrase alone. Accounts that already exist are not covered by the phrase.
+
+Keys are derived under m/44'/60'/0'/0 by default, matching other Ethereum wallets, pass
+--coin-type 461 to derive under the Filecoin path m/44'/461'/0'/0 instead.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if util.HasHDWallet(keyStoreDir()) {
+			logFatal("The wallet already has a recovery phrase")
+		}
+
+		words, err := cmd.Flags().GetInt("words")
+		if err != nil {
+			logFatal(err)
+		}
+		coinType, err := cmd.Flags().GetUint32("coin-type")
+		if err != nil {
+			logFatal(err)
+		}
+		base, err := hdBasePathForCoinType(coinType)
+		if err != nil {
+			logFatal(err)
+		}
+
+		mnemonic, err := util.NewMnemonic(words)
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Println("Your recovery phrase:")
+		fmt.Println()
+		for i, word := range strings.Fields(mnemonic) {
+			fmt.Printf("%2d. %s\n", i+1, word)
+		}
+		fmt.Println()
+		fmt.Println("Write it down and keep it somewhere safe. Anyone with the phrase controls every account derived from it, and it will not be shown again.")
+
+		var written bool
+		survey.AskOne(&survey.Confirm{Message: "Have you written down your recovery phrase?"}, &written)
+		if !written {
+			logFatal("Aborting, the recovery phrase was not saved")
+		}
+
+		passphrase, err := getRecoveryPhrasePassphrase(true)
+		if err != nil {
+			logFatal(err)
+		}
+
+		if err := recordKeyStoreNetwork(); err != nil {
+			logFatal(err)
+		}
+		if err := util.SaveHDWallet(keyStoreDir(), mnemonic, base, passphrase, keystore.StandardScryptN, keystore.StandardScryptP); err != nil {
+			logFatal(err)
+		}
+
+		bs := util.BackupsStore()
+		bs.Invalidate()
+
+		log.Printf("Recovery phrase saved, accounts will be derived under %s\n", base)
+		log.Println("Create your accounts with: glif wallet create-agent-accounts or glif wallet create-account <name>")
+	},
+}
+
+var restoreHDCmd = &cobra.Command{
+	Use:   "restore-hd",
+	Short: "Restore the wallet's accounts from a recovery phrase",
+	Long: `Restore the wallet from a BIP-39 recovery phrase. The owner, operator and requester
+accounts are always restored. Named accounts recorded in accounts.toml are restored under
+their names, and named accounts that have a balance or sent a transaction are discovered
+on chain and restored as hd-<index>, until --gap unused indexes in a row are found. Pass the
+--coin-type the phrase was created with.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if util.HasHDWallet(keyStoreDir()) {
+			logFatal("The wallet already has a recovery phrase")
+		}
+
+		coinType, err := cmd.Flags().GetUint32("coin-type")
+		if err != nil {
+			logFatal(err)
+		}
+		base, err := hdBasePathForCoinType(coinType)
+		if err != nil {
+			logFatal(err)
+		}
+		gap, err := cmd.Flags().GetInt("gap")
+		if err != nil {
+			logFatal(err)
+		}
+
+		var mnemonic string
+		survey.AskOne(&survey.Password{Message: "Recovery phrase"}, &mnemonic)
+		mnemonic, err = util.NormalizeMnemonic(mnemonic)
+		if err != nil {
+			logFatal(err)
+		}
+		seed, err := util.MnemonicSeed(mnemonic)
+		if err != nil {
+			logFatal(err)
+		}
+		w := &hdWallet{seed: seed, base: base}
+
+		passphrase, err := getRecoveryPhrasePassphrase(true)
+		if err != nil {
+			logFatal(err)
+		}
+		if err := recordKeyStoreNetwork(); err != nil {
+			logFatal(err)
+		}
+		if err := util.SaveHDWallet(keyStoreDir(), mnemonic, base, passphrase, keystore.StandardScryptN, keystore.StandardScryptP); err != nil {
+			logFatal(err)
+		}
+
+		as := util.AccountsStore()
+		restore := func(name string, index uint32, passphrase string) {
+			addr, err := w.address(index)
+			if err != nil {
+				logFatal(err)
+			}
+			if existing, err := as.Get(name); err == nil && existing != "" && !strings.EqualFold(existing, addr.Hex()) {
+				log.Printf("Warning: skipping %s, the account exists with a different address %s\n", name, existing)
+				return
+			}
+			if _, err := w.createAccount(name, index, passphrase); err != nil {
+				logFatal(err)
+			}
+			log.Printf("Restored %s: %s (%s)\n", name, addr, util.HDAccountPath(base, index))
+		}
+
+		ownerPassphrase, err := getAccountPassphrase("GLIF_OWNER_PASSPHRASE", "Please type a passphrase to encrypt your owner private key")
+		if err != nil {
+			logFatal(err)
+		}
+		roles := map[util.KeyType]string{
+			util.OwnerKey:    ownerPassphrase,
+			util.OperatorKey: os.Getenv("GLIF_OPERATOR_PASSPHRASE"),
+			util.RequestKey:  "",
+		}
+		paths := as.DerivationPaths()
+		for _, role := range hdRoleIndexes {
+			// rotated agent keys are derived at the index recorded in accounts.toml
+			index := role.index
+			if path, ok := paths[string(role.key)]; ok {
+				if recorded, err := hdPathIndex(path); err == nil {
+					index = recorded
+				}
+			}
+			restore(string(role.key), index, roles[role.key])
+		}
+
+		var namedPassphrase *string
+		getNamedPassphrase := func() string {
+			if namedPassphrase == nil {
+				p, err := getAccountPassphrase("GLIF_PASSPHRASE", "Please type a passphrase to encrypt your named accounts' private keys")
+				if err != nil {
+					logFatal(err)
+				}
+				namedPassphrase = &p
+			}
+			return *namedPassphrase
+		}
+
+		for name, path := range paths {
+			if name == string(util.OwnerKey) || name == string(util.OperatorKey) || name == string(util.RequestKey) {
+				continue
+			}
+			index, err := hdPathIndex(path)
+			if err != nil {
+				logFatalf("Invalid derivation path %s for %s: %s", path, name, err)
+			}
+			if util.HDAccountPath(base, index).String() != path {
+				log.Printf("Warning: skipping %s, %s is not under %s\n", name, path, base)
+				continue
+			}
+			restore(name, index, getNamedPassphrase())
+		}
+
+		if gap > 0 {
+			used, err := hdAccountUsedOnChain(cmd.Context())
+			if err != nil {
+				logFatal(err)
+			}
+			found, err := discoverHDAccounts(w, nextHDIndex(paths), gap, used)
+			if err != nil {
+				logFatal(err)
+			}
+			for _, index := range found {
+				restore(fmt.Sprintf("hd-%d", index), index, getNamedPassphrase())
+			}
+		}
+
+		if err := viper.WriteConfig(); err != nil {
+			logFatal(err)
+		}
+
+		bs := util.BackupsStore()
+		bs.Invalidate()
+
+		log.Println()
+		log.Println("If you have an agent, import it with: glif agent import <agent-addr>")
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(createHDCmd)
+	createHDCmd.Flags().Int("words", 24, "number of words in the recovery phrase, 12 or 24")
+	createHDCmd.Flags().Uint32("coin-type", util.HDCoinTypeEthereum, "BIP-44 coin type to derive accounts under, 60 (Ethereum) or 461 (Filecoin)")
+
+	walletCmd.AddCommand(restoreHDCmd)
+	restoreHDCmd.Flags().Uint32("coin-type", util.HDCoinTypeEthereum, "BIP-44 coin type the recovery phrase was created with")
+	restoreHDCmd.Flags().Int("gap", 20, "unused indexes in a row to scan before named account discovery stops, 0 disables discovery")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/glifio/glif/v2/util"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func testHDWallet(t *testing.T) *hdWallet {
+	seed, err := util.MnemonicSeed(testMnemonic)
+	assert.NoError(t, err)
+	return &hdWallet{seed: seed, base: util.HDBasePath(util.HDCoinTypeEthereum)}
+}
+
+func TestNextHDIndex(t *testing.T) {
+	assert.Equal(t, uint32(3), nextHDIndex(map[string]string{}))
+	assert.Equal(t, uint32(3), nextHDIndex(map[string]string{
+		"owner":    "m/44'/60'/0'/0/0",
+		"operator": "m/44'/60'/0'/0/1",
+	}))
+	assert.Equal(t, uint32(8), nextHDIndex(map[string]string{
+		"owner":   "m/44'/60'/0'/0/0",
+		"savings": "m/44'/60'/0'/0/7",
+		"spend":   "m/44'/60'/0'/0/4",
+		"broken":  "not a path",
+	}))
+}
+
+func TestDiscoverHDAccounts(t *testing.T) {
+	w := testHDWallet(t)
+
+	used := map[common.Address]bool{}
+	for _, index := range []uint32{3, 5, 9} {
+		addr, err := w.address(index)
+		assert.NoError(t, err)
+		used[addr] = true
+	}
+	var checked int
+	isUsed := func(addr common.Address) (bool, error) {
+		checked++
+		return used[addr], nil
+	}
+
+	found, err := discoverHDAccounts(w, 3, 3, isUsed)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{3, 5}, found)
+	assert.Equal(t, 6, checked)
+
+	// a larger gap reaches index 9
+	found, err = discoverHDAccounts(w, 3, 4, isUsed)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{3, 5, 9}, found)
+}
+
+func TestHDWalletCreateAccount(t *testing.T) {
+	dir := t.TempDir()
+	util.NewKeyStore(filepath.Join(dir, "keystore"))
+	assert.NoError(t, util.NewAccountsStore(filepath.Join(dir, "accounts.toml")))
+	as := util.AccountsStore()
+	w := testHDWallet(t)
+
+	account, err := w.createAccount("owner", 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x9858EfFD232B4033E47d90003D41EC34EcaEda94", account.Address.Hex())
+
+	addr, err := as.Get("owner")
+	assert.NoError(t, err)
+	assert.Equal(t, account.Address.Hex(), addr)
+	path, ok := as.DerivationPath("owner")
+	assert.True(t, ok)
+	assert.Equal(t, "m/44'/60'/0'/0/0", path)
+
+	// restoring an account already in the keystore keeps the key
+	again, err := w.createAccount("owner", 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, account.Address, again.Address)
+	assert.Len(t, util.KeyStore().Accounts(), 1)
+
+	// derivation paths survive reloading accounts.toml and are not accounts
+	assert.NoError(t, util.NewAccountsStore(filepath.Join(dir, "accounts.toml")))
+	assert.Equal(t, []string{"owner"}, util.AccountsStore().AccountNames())
+	assert.Equal(t, map[string]string{"owner": "m/44'/60'/0'/0/0"}, util.AccountsStore().DerivationPaths())
+}
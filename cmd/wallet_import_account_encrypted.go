@@ -34,6 +34,9 @@ var importAccountCmd = &cobra.Command{
 			logFatalf("Invalid private key JSON file hex string")
 		}
 
+		if err := recordKeyStoreNetwork(); err != nil {
+			logFatal(err)
+		}
 		account, err := util.KeyStore().Import(pkJSON, passphrase, passphrase)
 		if err != nil {
 			logFatal(err)
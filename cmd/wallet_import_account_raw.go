@@ -100,6 +100,9 @@ var importAccountRawCmd = &cobra.Command{
 			logFatalf("Invalid private key")
 		}
 
+		if err := recordKeyStoreNetwork(); err != nil {
+			logFatal(err)
+		}
 		account, err := util.KeyStore().ImportECDSA(pkECDSA, passphrase)
 		if err != nil {
 			logFatal(err)
@@ -73,6 +73,10 @@ func printAddresses(as *util.AccountsStorage, name string) {
 		}
 		logFatal(err)
 	}
+	if path, ok := as.DerivationPath(name); ok {
+		fmt.Printf("%s: %s (EVM), %s (FIL), derived at %s\n", name, evm, fevm, path)
+		return
+	}
 	fmt.Printf("%s: %s (EVM), %s (FIL)\n", name, evm, fevm)
 }
 
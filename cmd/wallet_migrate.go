@@ -77,6 +77,9 @@ func migrateLegacyKey(key util.KeyType) error {
 	if err != nil {
 		return err
 	}
+	if err := recordKeyStoreNetwork(); err != nil {
+		return err
+	}
 	account, err := ks.ImportECDSA(pk, "")
 	if err != nil {
 		return err
@@ -0,0 +1,99 @@
+/*
+Copyright © 2023 Glif LTD
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var recoverKeyCmd = &cobra.Command{
+	Use:   "recover-key [account-name]",
+	Short: "Recover a private key from the shares created by split-key and import it into the keystore",
+	Long: `Recover a private key from the shares created by glif wallet split-key and import it
+into the keystore under account-name. Shares can be passed with --share, in either the text
+or mnemonic encoding, or typed in when prompted.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		overwrite, err := cmd.Flags().GetBool("overwrite")
+		if err != nil {
+			logFatal(err)
+		}
+
+		shareStrs, err := cmd.Flags().GetStringArray("share")
+		if err != nil {
+			logFatal(err)
+		}
+
+		var shares []util.KeyShare
+		for _, str := range shareStrs {
+			share, err := util.ParseKeyShare(str)
+			if err != nil {
+				logFatal(err)
+			}
+			shares = append(shares, share)
+		}
+
+		// prompt for shares until the threshold recorded in the shares is met
+		for len(shares) == 0 || len(shares) < int(shares[0].Threshold) {
+			msg := "Share"
+			if len(shares) > 0 {
+				msg = fmt.Sprintf("Share %d of %d", len(shares)+1, shares[0].Threshold)
+			}
+
+			var str string
+			prompt := &survey.Password{Message: msg}
+			survey.AskOne(prompt, &str)
+			if strings.TrimSpace(str) == "" {
+				logFatal(util.ErrInsufficientShares)
+			}
+
+			share, err := util.ParseKeyShare(str)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			shares = append(shares, share)
+		}
+
+		key, err := util.RecoverKey(shares)
+		if err != nil {
+			logFatal(err)
+		}
+
+		pk, err := crypto.ToECDSA(key)
+		if err != nil {
+			logFatal(err)
+		}
+
+		name := strings.ToLower(args[0])
+		passphrase, addrToOverwrite, rename, err := validateImportKeyParams(name, overwrite)
+		if err != nil {
+			logFatal(err)
+		}
+
+		if err := recordKeyStoreNetwork(); err != nil {
+			logFatal(err)
+		}
+		account, err := util.KeyStore().ImportECDSA(pk, passphrase)
+		if err != nil {
+			logFatal(err)
+		}
+
+		if err := completeImport(account.Address, name, rename, addrToOverwrite, overwrite); err != nil {
+			logFatal(err)
+		}
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(recoverKeyCmd)
+	recoverKeyCmd.Flags().StringArray("share", []string{}, "a key share, can be passed multiple times")
+	recoverKeyCmd.Flags().Bool("overwrite", false, "overwrite an existing account with the same name")
+}
@@ -62,6 +62,9 @@ var rmAccountCmd = &cobra.Command{
 		if err := as.Delete(name); err != nil {
 			logFatal(err)
 		}
+		if err := as.DeleteDerivationPath(name); err != nil {
+			logFatal(err)
+		}
 
 		if err := viper.WriteConfig(); err != nil {
 			logFatal(err)
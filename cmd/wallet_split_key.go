@@ -0,0 +1,106 @@
+/*
+Copyright © 2023 Glif LTD
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/glif/v2/util"
+	"github.com/spf13/cobra"
+)
+
+var splitKeyCmd = &cobra.Command{
+	Use:   "split-key [account-name]",
+	Short: "(Dangerous) Split a private key into shares for social or offline recovery",
+	Long: `Split a private key into N shares using Shamir secret sharing, any K of which can
+recover the key with: glif wallet recover-key
+
+Each share reveals nothing about the key on its own. Store each share in a separate place,
+for example with different trusted people, so that no single loss or theft compromises the key.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		reallyDo, err := cmd.Flags().GetBool("really-do-it")
+		if err != nil {
+			logFatal(err)
+		}
+		if !reallyDo {
+			logFatal("DANGEROUS COMMAND - any threshold of the shares printed by this command can recover your private key. If you are sure, you must pass --really-do-it to split the key")
+		}
+
+		shares, err := cmd.Flags().GetInt("shares")
+		if err != nil {
+			logFatal(err)
+		}
+		threshold, err := cmd.Flags().GetInt("threshold")
+		if err != nil {
+			logFatal(err)
+		}
+
+		encoding := cmd.Flag("encoding").Value.String()
+		if encoding != "text" && encoding != "mnemonic" {
+			logFatalf("Invalid encoding %s, must be text or mnemonic", encoding)
+		}
+
+		name := args[0]
+		addrStr, err := util.AccountsStore().Get(name)
+		if err != nil {
+			logFatal(err)
+		}
+
+		ks := util.KeyStore()
+		account, err := ks.Find(accounts.Account{Address: common.HexToAddress(addrStr)})
+		if err != nil {
+			logFatal(err)
+		}
+
+		var passphrase string
+		err = ks.Unlock(account, "")
+		if err != nil {
+			prompt := &survey.Password{Message: "Passphrase for account"}
+			survey.AskOne(prompt, &passphrase)
+			if passphrase == "" {
+				fmt.Println("Aborted")
+				return
+			}
+		}
+
+		keyJSON, err := ks.Export(account, passphrase, passphrase)
+		if err != nil {
+			logFatal(err)
+		}
+		key, err := keystore.DecryptKey(keyJSON, passphrase)
+		if err != nil {
+			logFatal(err)
+		}
+
+		keyShares, err := util.SplitKey(crypto.FromECDSA(key.PrivateKey), shares, threshold)
+		if err != nil {
+			logFatal(err)
+		}
+
+		fmt.Printf("Split %s (%s) into %d shares, any %d of which can recover the key:\n\n", name, account.Address, shares, threshold)
+		for _, s := range keyShares {
+			fmt.Printf("Share %d of %d:\n", s.Index, shares)
+			if encoding == "mnemonic" {
+				fmt.Printf("%s\n\n", s.Mnemonic())
+			} else {
+				fmt.Printf("%s\n\n", s)
+			}
+		}
+		fmt.Println("Recover the key with: glif wallet recover-key <account-name>")
+	},
+}
+
+func init() {
+	walletCmd.AddCommand(splitKeyCmd)
+	splitKeyCmd.Flags().Int("shares", 5, "number of shares to create")
+	splitKeyCmd.Flags().Int("threshold", 3, "number of shares required to recover the key")
+	splitKeyCmd.Flags().String("encoding", "text", "share encoding, text (QR friendly) or mnemonic (BIP39 words)")
+	splitKeyCmd.Flags().Bool("really-do-it", false, "really split the key")
+}
@@ -82,11 +82,23 @@ type AgentWithdraw struct {
 	To      string `json:"to"`
 }
 
+type AgentWithdrawPolicy struct {
+	evtCommon
+	AgentID    string   `json:"agent_id"`
+	Amount     string   `json:"amount"`
+	To         string   `json:"to"`
+	Decision   string   `json:"decision"`
+	Violations []string `json:"violations,omitempty"`
+}
+
 type AgentExit struct {
 	evtCommon
-	AgentID string `json:"agent_id"`
-	PoolID  string `json:"pool_id"`
-	Amount  string `json:"amount"`
+	AgentID     string `json:"agent_id"`
+	PoolID      string `json:"pool_id"`
+	Amount      string `json:"amount"`
+	TargetEpoch string `json:"target_epoch,omitempty"`
+	Refund      string `json:"refund,omitempty"`
+	Residual    string `json:"residual,omitempty"`
 }
 
 type WalletFILForward struct {
@@ -102,3 +114,45 @@ type AgentAdmin struct {
 	AgentID         string `json:"agent_id"`
 	NewAdminAddress string `json:"new_admin_address,omitempty"`
 }
+
+type PlusFundGLFVault struct {
+	evtCommon
+	TokenID string `json:"token_id"`
+	Amount  string `json:"amount"`
+}
+
+type PlusClaimCashBack struct {
+	evtCommon
+	TokenID string `json:"token_id"`
+	Amount  string `json:"amount"`
+	To      string `json:"to"`
+}
+
+type PlusTierChange struct {
+	evtCommon
+	TokenID  string `json:"token_id"`
+	FromTier string `json:"from_tier"`
+	ToTier   string `json:"to_tier"`
+}
+
+type AirdropRedeem struct {
+	evtCommon
+	Account string   `json:"account"`
+	PlanIDs []string `json:"plan_ids"`
+	Amount  string   `json:"amount"`
+}
+
+type StakeDeposit struct {
+	evtCommon
+	Account string `json:"account"`
+	Amount  string `json:"amount"`
+	IFIL    string `json:"ifil"`
+}
+
+type StakeExit struct {
+	evtCommon
+	Account string `json:"account"`
+	Method  string `json:"method"`
+	IFIL    string `json:"ifil"`
+	Amount  string `json:"amount"`
+}
@@ -38,7 +38,7 @@ type fsJournal struct {
 // per-file size limit of 1GiB.
 func OpenFSJournal(journalPath string, disabled journal.DisabledEvents) (journal.Journal, error) {
 	dir := filepath.Join(journalPath, "journal")
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to mk directory %s for file journal: %w", dir, err)
 	}
 
@@ -55,13 +55,13 @@ func OpenFSJournal(journalPath string, disabled journal.DisabledEvents) (journal
 	var nfSize int64
 	current := filepath.Join(f.dir, "glif-journal.ndjson")
 	if fi, err := os.Stat(current); err == nil && !fi.IsDir() {
-		nfi, err = os.OpenFile(current, os.O_APPEND|os.O_RDWR, 0644)
+		nfi, err = os.OpenFile(current, os.O_APPEND|os.O_RDWR, 0600)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to open journal file: %w", err)
 		}
 		nfSize = fi.Size()
 	} else {
-		nfi, err = os.Create(current)
+		nfi, err = os.OpenFile(current, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 		if err != nil {
 			return nil, xerrors.Errorf("failed to create journal file: %w", err)
 		}
@@ -164,7 +164,7 @@ func (f *fsJournal) rollJournalFile() error {
 		}
 	}
 
-	nfi, err := os.Create(current)
+	nfi, err := os.OpenFile(current, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return xerrors.Errorf("failed to create journal file: %w", err)
 	}
@@ -29,8 +29,8 @@ func NewAccountsStore(filename string) error {
 }
 
 func (a *AccountsStorage) GetAddrs(key string) (common.Address, address.Address, error) {
-	addr, ok := a.data[key]
-	if !ok || addr == "" {
+	addr, err := a.Get(key)
+	if err != nil || addr == "" {
 		return common.Address{}, address.Address{}, &ErrKeyNotFound{key}
 	}
 	evmAddress := common.HexToAddress(addr)
@@ -42,3 +42,33 @@ func (a *AccountsStorage) GetAddrs(key string) (common.Address, address.Address,
 
 	return evmAddress, delegated, nil
 }
+
+// derivationPathsSection is the table of accounts.toml recording the BIP-44
+// path of the accounts derived from the wallet's recovery phrase
+const derivationPathsSection = "derivation-paths"
+
+// DerivationPath returns the BIP-44 path the account was derived along, if it
+// was derived from the wallet's recovery phrase
+func (a *AccountsStorage) DerivationPath(name string) (string, bool) {
+	path, err := a.GetIn(derivationPathsSection, name)
+	return path, err == nil
+}
+
+// SetDerivationPath records the BIP-44 path the account was derived along
+func (a *AccountsStorage) SetDerivationPath(name string, path string) error {
+	return a.SetIn(derivationPathsSection, name, path)
+}
+
+// DeleteDerivationPath forgets the derivation path of the account, accounts
+// that were not derived are ignored
+func (a *AccountsStorage) DeleteDerivationPath(name string) error {
+	if _, ok := a.DerivationPath(name); !ok {
+		return nil
+	}
+	return a.DeleteIn(derivationPathsSection, name)
+}
+
+// DerivationPaths returns the derivation paths of every derived account, by name
+func (a *AccountsStorage) DerivationPaths() map[string]string {
+	return a.Section(derivationPathsSection)
+}
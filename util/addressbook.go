@@ -0,0 +1,196 @@
+package util
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+// Contact is an external address saved in the address book
+type Contact struct {
+	Address string   `toml:"address"`
+	Note    string   `toml:"note,omitempty"`
+	Tags    []string `toml:"tags,omitempty"`
+}
+
+// HasTag reports whether the contact is tagged with tag, ignoring case
+func (c Contact) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddressBookStorage is the address book of named external addresses, keyed by name
+type AddressBookStorage struct {
+	filename string
+	contacts map[string]Contact
+}
+
+var addressBookStore *AddressBookStorage
+
+func AddressBookStore() *AddressBookStorage {
+	return addressBookStore
+}
+
+func NewAddressBookStore(filename string) error {
+	s := &AddressBookStorage{
+		filename: filename,
+		contacts: map[string]Contact{},
+	}
+
+	if _, err := os.Stat(filename); err == nil {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if err := toml.Unmarshal(data, &s.contacts); err != nil {
+			return fmt.Errorf("failed to unmarshal toml file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	addressBookStore = s
+
+	return nil
+}
+
+// save writes the address book to the file
+func (s *AddressBookStorage) save() error {
+	data, err := toml.Marshal(s.contacts)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(s.filename, data, 0600)
+}
+
+// Get retrieves the contact saved under name
+func (s *AddressBookStorage) Get(name string) (Contact, error) {
+	contact, ok := s.contacts[name]
+	if !ok {
+		return Contact{}, &ErrKeyNotFound{name}
+	}
+	return contact, nil
+}
+
+// Set saves contact under name and saves the address book to the file
+func (s *AddressBookStorage) Set(name string, contact Contact) error {
+	s.contacts[name] = contact
+	return s.save()
+}
+
+// Delete removes the contact saved under name and saves the address book to the file
+func (s *AddressBookStorage) Delete(name string) error {
+	if _, ok := s.contacts[name]; !ok {
+		return &ErrKeyNotFound{name}
+	}
+	delete(s.contacts, name)
+	return s.save()
+}
+
+// Names returns the names of every contact, sorted
+func (s *AddressBookStorage) Names() []string {
+	names := make([]string, 0, len(s.contacts))
+	for name := range s.contacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FindByAddress returns the name of the first contact, by name, saved with
+// address. Addresses are compared as stored, callers normalize them first
+func (s *AddressBookStorage) FindByAddress(address string) (string, bool) {
+	for _, name := range s.Names() {
+		if strings.EqualFold(s.contacts[name].Address, address) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+var addressBookCSVHeader = []string{"name", "address", "note", "tags"}
+
+// WriteCSV writes the address book as CSV with a header row, tags are
+// separated by semicolons
+func (s *AddressBookStorage) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(addressBookCSVHeader); err != nil {
+		return err
+	}
+	for _, name := range s.Names() {
+		c := s.contacts[name]
+		if err := cw.Write([]string{name, c.Address, c.Note, strings.Join(c.Tags, ";")}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadContactsCSV reads contacts in the format written by WriteCSV. The
+// header row is optional and the note and tags columns may be left out
+func ReadContactsCSV(r io.Reader) (map[string]Contact, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	contacts := map[string]Contact{}
+	for i, record := range records {
+		if i == 0 && len(record) >= 2 && strings.EqualFold(record[0], "name") && strings.EqualFold(record[1], "address") {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected at least a name and an address", i+1)
+		}
+
+		name := strings.TrimSpace(record[0])
+		if name == "" {
+			return nil, fmt.Errorf("line %d: name must not be empty", i+1)
+		}
+		if _, ok := contacts[name]; ok {
+			return nil, fmt.Errorf("line %d: duplicate name %s", i+1, name)
+		}
+
+		c := Contact{Address: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			c.Note = record[2]
+		}
+		if len(record) > 3 {
+			c.Tags = ParseTags(record[3])
+		}
+		contacts[name] = c
+	}
+
+	return contacts, nil
+}
+
+// ParseTags splits tags separated by semicolons or commas, trimming and
+// lower casing them and dropping empty and duplicate tags
+func ParseTags(s string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	return tags
+}
@@ -0,0 +1,113 @@
+package util_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/glifio/glif/v2/util"
+)
+
+func TestAddressBookStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "addressbook.toml")
+	if err := util.NewAddressBookStore(filename); err != nil {
+		t.Fatalf("NewAddressBookStore() error: %v", err)
+	}
+
+	exchange := util.Contact{Address: "0x1000000000000000000000000000000000000001", Note: "deposit address", Tags: []string{"cex"}}
+	if err := util.AddressBookStore().Set("exchange", exchange); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := util.AddressBookStore().Set("alice", util.Contact{Address: "f1abc"}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	// reload the address book from the file
+	if err := util.NewAddressBookStore(filename); err != nil {
+		t.Fatalf("NewAddressBookStore() error: %v", err)
+	}
+	ab := util.AddressBookStore()
+
+	contact, err := ab.Get("exchange")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if !reflect.DeepEqual(contact, exchange) {
+		t.Errorf("Get() expected %v, got %v", exchange, contact)
+	}
+	if !contact.HasTag("CEX") {
+		t.Errorf("HasTag() expected the contact to have tag cex")
+	}
+
+	if names := ab.Names(); !reflect.DeepEqual(names, []string{"alice", "exchange"}) {
+		t.Errorf("Names() expected [alice exchange], got %v", names)
+	}
+
+	if name, ok := ab.FindByAddress("0x1000000000000000000000000000000000000001"); !ok || name != "exchange" {
+		t.Errorf("FindByAddress() expected exchange, got %s %v", name, ok)
+	}
+	if _, ok := ab.FindByAddress("f1def"); ok {
+		t.Errorf("FindByAddress() expected no contact for an unknown address")
+	}
+
+	if err := ab.Delete("alice"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if _, err := ab.Get("alice"); err == nil {
+		t.Errorf("Get() expected an error for a deleted contact")
+	}
+	if err := ab.Delete("alice"); err == nil {
+		t.Errorf("Delete() expected an error for a missing contact")
+	}
+}
+
+func TestAddressBookCSV(t *testing.T) {
+	if err := util.NewAddressBookStore(filepath.Join(t.TempDir(), "addressbook.toml")); err != nil {
+		t.Fatalf("NewAddressBookStore() error: %v", err)
+	}
+	ab := util.AddressBookStore()
+	contacts := map[string]util.Contact{
+		"exchange": {Address: "0x1000000000000000000000000000000000000001", Note: "deposit, memo required", Tags: []string{"cex", "hot"}},
+		"alice":    {Address: "f1abc"},
+	}
+	for name, c := range contacts {
+		if err := ab.Set(name, c); err != nil {
+			t.Fatalf("Set() error: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := ab.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() error: %v", err)
+	}
+
+	read, err := util.ReadContactsCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadContactsCSV() error: %v", err)
+	}
+	if !reflect.DeepEqual(read, contacts) {
+		t.Errorf("ReadContactsCSV() expected %v, got %v", contacts, read)
+	}
+
+	// the header and the note and tags columns are optional
+	read, err = util.ReadContactsCSV(strings.NewReader("bob,0x2000000000000000000000000000000000000002\ncarol,f1def,friend, Family ; friends\n"))
+	if err != nil {
+		t.Fatalf("ReadContactsCSV() error: %v", err)
+	}
+	expected := map[string]util.Contact{
+		"bob":   {Address: "0x2000000000000000000000000000000000000002"},
+		"carol": {Address: "f1def", Note: "friend", Tags: []string{"family", "friends"}},
+	}
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("ReadContactsCSV() expected %v, got %v", expected, read)
+	}
+
+	if _, err := util.ReadContactsCSV(strings.NewReader("bob\n")); err == nil {
+		t.Errorf("ReadContactsCSV() expected an error for a row without an address")
+	}
+	if _, err := util.ReadContactsCSV(strings.NewReader("bob,0x1\nbob,0x2\n")); err == nil {
+		t.Errorf("ReadContactsCSV() expected an error for a duplicate name")
+	}
+}
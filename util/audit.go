@@ -0,0 +1,183 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// wipePasses is the number of times WipeFile overwrites a file
+const wipePasses = 3
+
+// LoosePermission is a file or directory that group or others can access
+type LoosePermission struct {
+	Path  string
+	Mode  fs.FileMode
+	IsDir bool
+}
+
+// FindLoosePermissions walks dir and returns every file and directory,
+// including dir, that group or others can access
+func FindLoosePermissions(dir string) ([]LoosePermission, error) {
+	var loose []LoosePermission
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			loose = append(loose, LoosePermission{Path: path, Mode: info.Mode().Perm(), IsDir: d.IsDir()})
+		}
+		return nil
+	})
+	return loose, err
+}
+
+// FixPermission removes the group and other permissions of p
+func FixPermission(p LoosePermission) error {
+	return os.Chmod(p.Path, p.Mode&^0077)
+}
+
+// KeyFile describes an encrypted key file in the keystore
+type KeyFile struct {
+	Path    string
+	Address common.Address
+	KDF     string
+	// Cost is the scrypt N or the pbkdf2 iteration count
+	Cost int
+}
+
+// WeakKDF reports whether the key is encrypted with weaker parameters than
+// the keystore uses for new keys
+func (k KeyFile) WeakKDF() bool {
+	switch k.KDF {
+	case "scrypt":
+		return k.Cost < keystore.StandardScryptN
+	case "pbkdf2":
+		// pbkdf2 is only used by old keystores, flag anything below the
+		// iteration count geth used for it
+		return k.Cost < 262144
+	default:
+		return true
+	}
+}
+
+// EmptyPassphrase reports whether the key decrypts without a passphrase
+func (k KeyFile) EmptyPassphrase() (bool, error) {
+	data, err := os.ReadFile(k.Path)
+	if err != nil {
+		return false, err
+	}
+	_, err = keystore.DecryptKey(data, "")
+	return err == nil, nil
+}
+
+type keyFileJSON struct {
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+}
+
+// ReadKeyFiles reads the key files in the keystore directory keydir. Files
+// that are not encrypted keys are returned in invalid. Dotfiles are skipped
+// like the keystore does
+func ReadKeyFiles(keydir string) (keys []KeyFile, invalid []string, err error) {
+	entries, err := os.ReadDir(keydir)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name[0] == '.' || name[len(name)-1] == '~' {
+			continue
+		}
+		path := filepath.Join(keydir, name)
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		var kf keyFileJSON
+		if err := json.Unmarshal(data, &kf); err != nil || !common.IsHexAddress(kf.Address) || kf.Crypto.CipherText == "" {
+			invalid = append(invalid, path)
+			continue
+		}
+
+		key := KeyFile{
+			Path:    path,
+			Address: common.HexToAddress(kf.Address),
+			KDF:     kf.Crypto.KDF,
+		}
+		var cost interface{}
+		switch kf.Crypto.KDF {
+		case "scrypt":
+			cost = kf.Crypto.KDFParams["n"]
+		case "pbkdf2":
+			cost = kf.Crypto.KDFParams["c"]
+		}
+		if f, ok := cost.(float64); ok {
+			key.Cost = int(f)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, invalid, nil
+}
+
+// WipeFile overwrites a file with random data several times, syncing it to
+// disk after each pass, and removes it. Journaling and copy on write file
+// systems may keep older copies of the data, so this is a best effort
+func WipeFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, info.Size())
+	for i := 0; i < wipePasses; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.WriteAt(buf, 0); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
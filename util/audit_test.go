@@ -0,0 +1,150 @@
+package util_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/glifio/glif/v2/util"
+)
+
+func TestFindLoosePermissions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "keystore"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, mode := range map[string]os.FileMode{"accounts.toml": 0644, "config.toml": 0600, "keystore/key": 0640} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), mode); err != nil {
+			t.Fatal(err)
+		}
+		// WriteFile is subject to the umask
+		if err := os.Chmod(filepath.Join(dir, name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(dir, "keystore"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	loose, err := util.FindLoosePermissions(dir)
+	if err != nil {
+		t.Fatalf("FindLoosePermissions() error: %v", err)
+	}
+	found := map[string]os.FileMode{}
+	for _, p := range loose {
+		rel, _ := filepath.Rel(dir, p.Path)
+		found[rel] = p.Mode
+	}
+	expected := map[string]os.FileMode{"accounts.toml": 0644, "keystore": 0755, "keystore/key": 0640}
+	if len(found) != len(expected) {
+		t.Fatalf("FindLoosePermissions() expected %v, got %v", expected, found)
+	}
+	for name, mode := range expected {
+		if found[name] != mode {
+			t.Errorf("FindLoosePermissions() expected %s with mode %o, got %o", name, mode, found[name])
+		}
+	}
+
+	for _, p := range loose {
+		if err := util.FixPermission(p); err != nil {
+			t.Fatalf("FixPermission() error: %v", err)
+		}
+	}
+	loose, err = util.FindLoosePermissions(dir)
+	if err != nil {
+		t.Fatalf("FindLoosePermissions() error: %v", err)
+	}
+	if len(loose) != 0 {
+		t.Errorf("FindLoosePermissions() expected nothing after fixing, got %v", loose)
+	}
+	info, _ := os.Stat(filepath.Join(dir, "accounts.toml"))
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("FixPermission() expected mode 600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestReadKeyFiles(t *testing.T) {
+	keydir := t.TempDir()
+	ks := keystore.NewKeyStore(keydir, keystore.LightScryptN, keystore.LightScryptP)
+	empty, err := ks.NewAccount("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	protected, err := ks.NewAccount("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(keydir, "notes.txt"), []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(keydir, ".network"), []byte("name = 'mainnet'"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, invalid, err := util.ReadKeyFiles(keydir)
+	if err != nil {
+		t.Fatalf("ReadKeyFiles() error: %v", err)
+	}
+	if len(invalid) != 1 || filepath.Base(invalid[0]) != "notes.txt" {
+		t.Errorf("ReadKeyFiles() expected notes.txt to be invalid, got %v", invalid)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("ReadKeyFiles() expected 2 keys, got %d", len(keys))
+	}
+
+	for _, key := range keys {
+		if key.KDF != "scrypt" || key.Cost != keystore.LightScryptN {
+			t.Errorf("ReadKeyFiles() expected scrypt with N %d, got %s %d", keystore.LightScryptN, key.KDF, key.Cost)
+		}
+		if !key.WeakKDF() {
+			t.Errorf("WeakKDF() expected light scrypt parameters to be weak")
+		}
+
+		isEmpty, err := key.EmptyPassphrase()
+		if err != nil {
+			t.Fatalf("EmptyPassphrase() error: %v", err)
+		}
+		switch key.Address {
+		case empty.Address:
+			if !isEmpty {
+				t.Errorf("EmptyPassphrase() expected true for %s", key.Address)
+			}
+		case protected.Address:
+			if isEmpty {
+				t.Errorf("EmptyPassphrase() expected false for %s", key.Address)
+			}
+		default:
+			t.Errorf("ReadKeyFiles() returned unknown address %s", key.Address)
+		}
+	}
+
+	strong := util.KeyFile{KDF: "scrypt", Cost: keystore.StandardScryptN}
+	if strong.WeakKDF() {
+		t.Errorf("WeakKDF() expected standard scrypt parameters not to be weak")
+	}
+}
+
+func TestWipeFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "keys.toml")
+	if err := os.WriteFile(filename, []byte("owner = 'secret'"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := util.WipeFile(filename); err != nil {
+		t.Fatalf("WipeFile() error: %v", err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("WipeFile() expected the file to be removed, got %v", err)
+	}
+
+	if err := util.WipeFile(filename); err == nil {
+		t.Errorf("WipeFile() expected an error for a missing file")
+	}
+	if err := util.WipeFile(t.TempDir()); err == nil {
+		t.Errorf("WipeFile() expected an error for a directory")
+	}
+}
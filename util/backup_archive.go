@@ -0,0 +1,313 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+const (
+	BackupArchiveVersion = 1
+	backupManifestName   = "manifest.json"
+)
+
+// BackupFiles are the paths, relative to the config directory, that are
+// included in a backup. Directories are included recursively
+var BackupFiles = []string{
+	"keystore",
+	"accounts.toml",
+	"agent.toml",
+	"config.toml",
+}
+
+var ErrBackupChecksum = errors.New("backup checksum mismatch")
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	Version   int               `json:"version"`
+	CreatedAt time.Time         `json:"created_at"`
+	ChainID   int64             `json:"chain_id"`
+	Accounts  map[string]string `json:"accounts"`
+	Files     []BackupFile      `json:"files"`
+}
+
+// BackupFile is a single file in a backup archive
+type BackupFile struct {
+	Path   string      `json:"path"`
+	Mode   fs.FileMode `json:"mode"`
+	Size   int64       `json:"size"`
+	SHA256 string      `json:"sha256"`
+}
+
+// backupEnvelope is the on disk format of a backup archive, the tarball is
+// encrypted with the same scrypt based scheme as the keystore
+type backupEnvelope struct {
+	Version   int                 `json:"version"`
+	CreatedAt time.Time           `json:"created_at"`
+	SHA256    string              `json:"sha256"`
+	Crypto    keystore.CryptoJSON `json:"crypto"`
+}
+
+// Backup is a decrypted backup archive
+type Backup struct {
+	Manifest BackupManifest
+	Files    map[string][]byte
+}
+
+// CreateBackup archives the BackupFiles found in dir and encrypts them with
+// passphrase. Missing files are skipped
+func CreateBackup(dir string, passphrase string, chainID int64, accounts map[string]string, scryptN, scryptP int) ([]byte, error) {
+	manifest := BackupManifest{
+		Version:   BackupArchiveVersion,
+		CreatedAt: time.Now().UTC(),
+		ChainID:   chainID,
+		Accounts:  accounts,
+	}
+	contents := map[string][]byte{}
+
+	for _, name := range BackupFiles {
+		root := filepath.Join(dir, name)
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			sum := sha256.Sum256(data)
+			manifest.Files = append(manifest.Files, BackupFile{
+				Path:   rel,
+				Mode:   info.Mode().Perm(),
+				Size:   int64(len(data)),
+				SHA256: hex.EncodeToString(sum[:]),
+			})
+			contents[rel] = data
+			return nil
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	tarball, err := writeBackupTarball(manifest, contents)
+	if err != nil {
+		return nil, err
+	}
+
+	cj, err := keystore.EncryptDataV3(tarball, []byte(passphrase), scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(tarball)
+	return json.MarshalIndent(backupEnvelope{
+		Version:   BackupArchiveVersion,
+		CreatedAt: manifest.CreatedAt,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Crypto:    cj,
+	}, "", "  ")
+}
+
+// OpenBackup decrypts a backup archive and checks the checksum of the
+// archive and of every file listed in its manifest
+func OpenBackup(archive []byte, passphrase string) (*Backup, error) {
+	var env backupEnvelope
+	if err := json.Unmarshal(archive, &env); err != nil {
+		return nil, fmt.Errorf("not a glif backup archive: %w", err)
+	}
+	if env.Version != BackupArchiveVersion {
+		return nil, fmt.Errorf("unsupported backup archive version %d", env.Version)
+	}
+
+	tarball, err := keystore.DecryptDataV3(env.Crypto, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup: %w", err)
+	}
+
+	sum := sha256.Sum256(tarball)
+	if hex.EncodeToString(sum[:]) != env.SHA256 {
+		return nil, ErrBackupChecksum
+	}
+
+	backup, err := readBackupTarball(tarball)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range backup.Manifest.Files {
+		data, ok := backup.Files[f.Path]
+		if !ok {
+			return nil, fmt.Errorf("%s is listed in the manifest but missing from the archive", f.Path)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != f.SHA256 {
+			return nil, fmt.Errorf("%w: %s", ErrBackupChecksum, f.Path)
+		}
+	}
+	if len(backup.Files) != len(backup.Manifest.Files) {
+		return nil, errors.New("archive contains files that are not listed in the manifest")
+	}
+
+	return backup, nil
+}
+
+// Restore writes the files in the backup into dir, which must not exist or
+// be empty
+func (b *Backup) Restore(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty, restore into a fresh config directory", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	for _, f := range b.Manifest.Files {
+		dst := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, b.Files[f.Path], f.Mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HasKey returns true if the backup contains a keystore file for addr
+func (b *Backup) HasKey(addr string) bool {
+	suffix := "--" + strings.TrimPrefix(strings.ToLower(addr), "0x")
+	for p := range b.Files {
+		if strings.HasPrefix(p, "keystore/") && strings.HasSuffix(strings.ToLower(p), suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeBackupTarball(manifest BackupManifest, contents map[string][]byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	m, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	write := func(name string, mode fs.FileMode, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    int64(mode),
+			Size:    int64(len(data)),
+			ModTime: manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(backupManifestName, 0600, m); err != nil {
+		return nil, err
+	}
+	for _, f := range manifest.Files {
+		if err := write(f.Path, f.Mode, contents[f.Path]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readBackupTarball(tarball []byte) (*Backup, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	backup := &Backup{Files: map[string][]byte{}}
+	foundManifest := false
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == backupManifestName {
+			if err := json.Unmarshal(data, &backup.Manifest); err != nil {
+				return nil, fmt.Errorf("invalid backup manifest: %w", err)
+			}
+			foundManifest = true
+			continue
+		}
+		backup.Files[name] = data
+	}
+
+	if !foundManifest {
+		return nil, errors.New("backup archive has no manifest")
+	}
+
+	return backup, nil
+}
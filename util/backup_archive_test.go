@@ -0,0 +1,100 @@
+package util_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/glifio/glif/v2/util"
+)
+
+const testAddr = "0x4e1B4E7fCfa6a8f4aA0A3E8B0D4B2b2f7E4e0cF1"
+
+func writeTestConfigDir(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"accounts.toml": "owner = '" + testAddr + "'\n",
+		"agent.toml":    "id = '1'\n",
+		"config.toml":   "[daemon]\n",
+		"keystore/UTC--2024-01-01T00-00-00.000000000Z--" + strings.ToLower(testAddr[2:]): "{}",
+		"keys.toml": "owner-key = 'secret'\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func createTestBackup(t *testing.T, dir string) []byte {
+	archive, err := util.CreateBackup(dir, "passphrase", 314159, map[string]string{"owner": testAddr}, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatalf("CreateBackup() error: %v", err)
+	}
+	return archive
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	dir := writeTestConfigDir(t)
+	archive := createTestBackup(t, dir)
+
+	backup, err := util.OpenBackup(archive, "passphrase")
+	if err != nil {
+		t.Fatalf("OpenBackup() error: %v", err)
+	}
+
+	if len(backup.Manifest.Files) != 4 {
+		t.Errorf("expected 4 files in the manifest, got %d", len(backup.Manifest.Files))
+	}
+	if _, ok := backup.Files["keys.toml"]; ok {
+		t.Errorf("keys.toml should not be backed up")
+	}
+	if !backup.HasKey(testAddr) {
+		t.Errorf("expected backup to contain the key for %s", testAddr)
+	}
+	if backup.HasKey("0x0000000000000000000000000000000000000001") {
+		t.Errorf("unexpected key in backup")
+	}
+
+	restoreDir := filepath.Join(t.TempDir(), "restored")
+	if err := backup.Restore(restoreDir); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(restoreDir, "accounts.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "owner = '"+testAddr+"'\n" {
+		t.Errorf("restored accounts.toml does not match: %s", got)
+	}
+
+	if err := backup.Restore(restoreDir); err == nil {
+		t.Errorf("expected restore into a non empty directory to fail")
+	}
+}
+
+func TestBackupWrongPassphrase(t *testing.T) {
+	archive := createTestBackup(t, writeTestConfigDir(t))
+
+	if _, err := util.OpenBackup(archive, "wrong"); err == nil {
+		t.Errorf("expected OpenBackup() with the wrong passphrase to fail")
+	}
+}
+
+func TestBackupTamperedChecksum(t *testing.T) {
+	archive := createTestBackup(t, writeTestConfigDir(t))
+
+	tampered := strings.Replace(string(archive), `"sha256": "`, `"sha256": "00`, 1)
+	_, err := util.OpenBackup([]byte(tampered), "passphrase")
+	if !errors.Is(err, util.ErrBackupChecksum) {
+		t.Errorf("expected checksum error, got %v", err)
+	}
+}
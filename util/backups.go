@@ -33,3 +33,11 @@ func (a *BackupsStorage) Invalidate() {
 	a.Set("modified-at", string(v))
 	a.Set("confirmed-exists", "false")
 }
+
+// ConfirmVerified marks the backup check as satisfied by a verified backup archive
+func (a *BackupsStorage) ConfirmVerified(archive string) {
+	v, _ := time.Now().UTC().MarshalText()
+	a.Set("confirmed-exists", "true")
+	a.Set("confirmed-at", string(v))
+	a.Set("verified-archive", archive)
+}
@@ -0,0 +1,227 @@
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	// HDCoinTypeEthereum is the BIP-44 coin type of Ethereum, keys derived
+	// with it match the accounts of other Ethereum wallets like MetaMask
+	HDCoinTypeEthereum = 60
+	// HDCoinTypeFilecoin is the BIP-44 coin type of Filecoin
+	HDCoinTypeFilecoin = 461
+
+	hdWalletVersion = 1
+	// hdWalletFile holds the encrypted recovery phrase in the keystore
+	// directory. The keystore skips dotfiles when scanning for keys
+	hdWalletFile = ".hdwallet.json"
+)
+
+var (
+	ErrHDWalletNotFound = errors.New("the wallet was not created from a recovery phrase")
+	ErrInvalidMnemonic  = errors.New("invalid recovery phrase")
+	errInvalidHDKey     = errors.New("derived key is invalid, try the next index")
+)
+
+// HDBasePath returns the BIP-44 path m/44'/coinType'/0'/0 that accounts are
+// derived under
+func HDBasePath(coinType uint32) accounts.DerivationPath {
+	return accounts.DerivationPath{
+		0x80000000 + 44,
+		0x80000000 + coinType,
+		0x80000000 + 0,
+		0,
+	}
+}
+
+// HDAccountPath returns the path of the account at index under base
+func HDAccountPath(base accounts.DerivationPath, index uint32) accounts.DerivationPath {
+	path := make(accounts.DerivationPath, len(base), len(base)+1)
+	copy(path, base)
+	return append(path, index)
+}
+
+// NewMnemonic generates a BIP-39 recovery phrase of 12 or 24 words
+func NewMnemonic(words int) (string, error) {
+	var bits int
+	switch words {
+	case 12:
+		bits = 128
+	case 24:
+		bits = 256
+	default:
+		return "", fmt.Errorf("recovery phrases must have 12 or 24 words, not %d", words)
+	}
+
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// NormalizeMnemonic lower cases a recovery phrase and collapses whitespace,
+// returning ErrInvalidMnemonic if the words or the checksum are wrong
+func NormalizeMnemonic(mnemonic string) (string, error) {
+	mnemonic = strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return "", ErrInvalidMnemonic
+	}
+	return mnemonic, nil
+}
+
+// MnemonicSeed returns the BIP-39 seed of a recovery phrase, without a
+// BIP-39 passphrase
+func MnemonicSeed(mnemonic string) ([]byte, error) {
+	mnemonic, err := NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return bip39.NewSeed(mnemonic, ""), nil
+}
+
+// DeriveHDKey derives the secp256k1 private key at path from a BIP-39 seed,
+// following BIP-32
+func DeriveHDKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chainCode := sum[:32], sum[32:]
+	if err := validHDKey(key); err != nil {
+		return nil, err
+	}
+
+	n := crypto.S256().Params().N
+	for _, index := range path {
+		data := make([]byte, 0, 37)
+		if index >= 0x80000000 {
+			data = append(data, 0)
+			data = append(data, key...)
+		} else {
+			priv, err := crypto.ToECDSA(key)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, crypto.CompressPubkey(&priv.PublicKey)...)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		if err := validHDKey(sum[:32]); err != nil {
+			return nil, err
+		}
+		child := new(big.Int).SetBytes(sum[:32])
+		child.Add(child, new(big.Int).SetBytes(key))
+		child.Mod(child, n)
+		if child.Sign() == 0 {
+			return nil, errInvalidHDKey
+		}
+
+		key, chainCode = math.PaddedBigBytes(child, 32), sum[32:]
+	}
+
+	return crypto.ToECDSA(key)
+}
+
+// validHDKey checks a derived key is in the range of the curve order, BIP-32
+// skips to the next index when it is not
+func validHDKey(key []byte) error {
+	k := new(big.Int).SetBytes(key)
+	if k.Sign() == 0 || k.Cmp(crypto.S256().Params().N) >= 0 {
+		return errInvalidHDKey
+	}
+	return nil
+}
+
+// hdWallet is the on disk format of the encrypted recovery phrase
+type hdWallet struct {
+	Version  int                 `json:"version"`
+	BasePath string              `json:"base_path"`
+	Crypto   keystore.CryptoJSON `json:"crypto"`
+}
+
+// HasHDWallet reports whether the keystore in keydir was created from a
+// recovery phrase
+func HasHDWallet(keydir string) bool {
+	_, err := os.Stat(filepath.Join(keydir, hdWalletFile))
+	return err == nil
+}
+
+// SaveHDWallet encrypts the recovery phrase with passphrase and saves it with
+// the base path accounts are derived under to the keystore in keydir
+func SaveHDWallet(keydir string, mnemonic string, base accounts.DerivationPath, passphrase string, scryptN, scryptP int) error {
+	mnemonic, err := NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+
+	cj, err := keystore.EncryptDataV3([]byte(mnemonic), []byte(passphrase), scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(hdWallet{
+		Version:  hdWalletVersion,
+		BasePath: base.String(),
+		Crypto:   cj,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(keydir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(keydir, hdWalletFile), data, 0600)
+}
+
+// LoadHDWallet decrypts the recovery phrase saved in the keystore in keydir
+// and returns it with the base path accounts are derived under
+func LoadHDWallet(keydir string, passphrase string) (string, accounts.DerivationPath, error) {
+	data, err := os.ReadFile(filepath.Join(keydir, hdWalletFile))
+	if os.IsNotExist(err) {
+		return "", nil, ErrHDWalletNotFound
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	var w hdWallet
+	if err := json.Unmarshal(data, &w); err != nil {
+		return "", nil, fmt.Errorf("failed to read %s: %w", hdWalletFile, err)
+	}
+	if w.Version != hdWalletVersion {
+		return "", nil, fmt.Errorf("unsupported recovery phrase file version %d", w.Version)
+	}
+
+	base, err := accounts.ParseDerivationPath(w.BasePath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mnemonic, err := keystore.DecryptDataV3(w.Crypto, passphrase)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return string(mnemonic), base, nil
+}
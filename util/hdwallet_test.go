@@ -0,0 +1,144 @@
+package util_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/glif/v2/util"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestDeriveHDKey(t *testing.T) {
+	// test vector 1 from BIP-32
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+
+	for _, tt := range tests {
+		path, err := accounts.ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatalf("ParseDerivationPath(%s) error: %v", tt.path, err)
+		}
+		key, err := util.DeriveHDKey(seed, path)
+		if err != nil {
+			t.Fatalf("DeriveHDKey(%s) error: %v", tt.path, err)
+		}
+		if got := hex.EncodeToString(crypto.FromECDSA(key)); got != tt.key {
+			t.Errorf("DeriveHDKey(%s) expected %s, got %s", tt.path, tt.key, got)
+		}
+	}
+
+	key, err := util.DeriveHDKey(seed, accounts.DerivationPath{})
+	if err != nil {
+		t.Fatalf("DeriveHDKey(m) error: %v", err)
+	}
+	if got := hex.EncodeToString(crypto.FromECDSA(key)); got != "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35" {
+		t.Errorf("DeriveHDKey(m) expected the master key, got %s", got)
+	}
+}
+
+func TestDeriveHDKeyFromMnemonic(t *testing.T) {
+	seed, err := util.MnemonicSeed(testMnemonic)
+	if err != nil {
+		t.Fatalf("MnemonicSeed() error: %v", err)
+	}
+
+	// the same accounts other Ethereum wallets derive from the test phrase
+	expected := []string{
+		"0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+	}
+	base := util.HDBasePath(util.HDCoinTypeEthereum)
+	for i, addr := range expected {
+		path := util.HDAccountPath(base, uint32(i))
+		key, err := util.DeriveHDKey(seed, path)
+		if err != nil {
+			t.Fatalf("DeriveHDKey(%s) error: %v", path, err)
+		}
+		if got := crypto.PubkeyToAddress(key.PublicKey).Hex(); got != addr {
+			t.Errorf("DeriveHDKey(%s) expected %s, got %s", path, addr, got)
+		}
+	}
+
+	if base.String() != "m/44'/60'/0'/0" {
+		t.Errorf("HDBasePath() expected m/44'/60'/0'/0, got %s", base)
+	}
+	if path := util.HDAccountPath(util.HDBasePath(util.HDCoinTypeFilecoin), 3); path.String() != "m/44'/461'/0'/0/3" {
+		t.Errorf("HDAccountPath() expected m/44'/461'/0'/0/3, got %s", path)
+	}
+}
+
+func TestNormalizeMnemonic(t *testing.T) {
+	mnemonic, err := util.NormalizeMnemonic("  Abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon ABOUT ")
+	if err != nil {
+		t.Fatalf("NormalizeMnemonic() error: %v", err)
+	}
+	if mnemonic != testMnemonic {
+		t.Errorf("NormalizeMnemonic() expected %q, got %q", testMnemonic, mnemonic)
+	}
+
+	// the last word carries the checksum
+	_, err = util.NormalizeMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")
+	if !errors.Is(err, util.ErrInvalidMnemonic) {
+		t.Errorf("NormalizeMnemonic() expected ErrInvalidMnemonic, got %v", err)
+	}
+
+	for _, words := range []int{12, 24} {
+		mnemonic, err := util.NewMnemonic(words)
+		if err != nil {
+			t.Fatalf("NewMnemonic(%d) error: %v", words, err)
+		}
+		if _, err := util.NormalizeMnemonic(mnemonic); err != nil {
+			t.Errorf("NewMnemonic(%d) returned an invalid phrase: %v", words, err)
+		}
+	}
+	if _, err := util.NewMnemonic(18); err == nil {
+		t.Errorf("NewMnemonic(18) expected an error")
+	}
+}
+
+func TestSaveLoadHDWallet(t *testing.T) {
+	keydir := t.TempDir()
+	if util.HasHDWallet(keydir) {
+		t.Fatalf("HasHDWallet() expected false for an empty keystore")
+	}
+	if _, _, err := util.LoadHDWallet(keydir, "passphrase"); !errors.Is(err, util.ErrHDWalletNotFound) {
+		t.Fatalf("LoadHDWallet() expected ErrHDWalletNotFound, got %v", err)
+	}
+
+	base := util.HDBasePath(util.HDCoinTypeFilecoin)
+	if err := util.SaveHDWallet(keydir, testMnemonic, base, "passphrase", keystore.LightScryptN, keystore.LightScryptP); err != nil {
+		t.Fatalf("SaveHDWallet() error: %v", err)
+	}
+	if !util.HasHDWallet(keydir) {
+		t.Errorf("HasHDWallet() expected true after saving")
+	}
+
+	mnemonic, loadedBase, err := util.LoadHDWallet(keydir, "passphrase")
+	if err != nil {
+		t.Fatalf("LoadHDWallet() error: %v", err)
+	}
+	if mnemonic != testMnemonic {
+		t.Errorf("LoadHDWallet() returned the wrong recovery phrase")
+	}
+	if loadedBase.String() != base.String() {
+		t.Errorf("LoadHDWallet() expected base path %s, got %s", base, loadedBase)
+	}
+
+	if _, _, err := util.LoadHDWallet(keydir, "wrong"); err == nil {
+		t.Errorf("LoadHDWallet() expected an error for the wrong passphrase")
+	}
+}
@@ -0,0 +1,433 @@
+package util
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// keyringMaxMessage limits the size of a single request or response
+const keyringMaxMessage = 1 << 20
+
+// ErrKeyringKeyNotFound is returned when the keyring does not hold a key for an address
+var ErrKeyringKeyNotFound = errors.New("key not found in keyring")
+
+// KeyringEntry is an unlocked key held by the keyring and when it expires
+type KeyringEntry struct {
+	Address common.Address `json:"address"`
+	Expires time.Time      `json:"expires"`
+}
+
+type keyringRequest struct {
+	Op      string         `json:"op"`
+	Address common.Address `json:"address,omitempty"`
+	// Key is the hex encoded private key, only sent with add
+	Key string `json:"key,omitempty"`
+	// TTL in seconds, 0 uses the keyring default
+	TTL     int64    `json:"ttl,omitempty"`
+	ChainID *big.Int `json:"chain_id,omitempty"`
+	// Tx is the binary encoded transaction to sign
+	Tx []byte `json:"tx,omitempty"`
+}
+
+type keyringResponse struct {
+	Error string         `json:"error,omitempty"`
+	Tx    []byte         `json:"tx,omitempty"`
+	Keys  []KeyringEntry `json:"keys,omitempty"`
+}
+
+type keyringKey struct {
+	key     *ecdsa.PrivateKey
+	expires time.Time
+}
+
+// Keyring holds unlocked keys in memory and signs transactions with them for
+// clients connecting to its Unix socket, like ssh-agent. Keys are dropped
+// once their TTL expires
+type Keyring struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	keys     map[common.Address]*keyringKey
+	listener net.Listener
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewKeyring creates a keyring that keeps keys for ttl unless they are added
+// with their own TTL
+func NewKeyring(ttl time.Duration) *Keyring {
+	return &Keyring{
+		ttl:  ttl,
+		keys: map[common.Address]*keyringKey{},
+		done: make(chan struct{}),
+	}
+}
+
+// ListenKeyring listens on the Unix socket at path, readable only by the
+// owner. A stale socket left by a keyring that is no longer running is
+// replaced
+func ListenKeyring(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		if _, err := DialKeyring(path).List(); err == nil {
+			return nil, fmt.Errorf("a keyring is already running on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Serve accepts connections on l until Stop is called or a client asks the
+// keyring to stop. Every key is dropped when it returns
+func (k *Keyring) Serve(l net.Listener) error {
+	k.mu.Lock()
+	k.listener = l
+	k.mu.Unlock()
+
+	go k.expireLoop()
+	defer k.RemoveAll()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-k.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go k.handle(conn)
+	}
+}
+
+// Stop closes the listener and drops every key
+func (k *Keyring) Stop() {
+	k.stopOnce.Do(func() {
+		close(k.done)
+		k.mu.Lock()
+		if k.listener != nil {
+			k.listener.Close()
+		}
+		k.mu.Unlock()
+		k.RemoveAll()
+	})
+}
+
+// Add keeps key until ttl expires, or the keyring default TTL when ttl is 0
+func (k *Keyring) Add(key *ecdsa.PrivateKey, ttl time.Duration) common.Address {
+	if ttl <= 0 {
+		ttl = k.ttl
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if old, ok := k.keys[addr]; ok {
+		zeroKey(old.key)
+	}
+	k.keys[addr] = &keyringKey{key: key, expires: time.Now().Add(ttl)}
+	return addr
+}
+
+// Remove drops the key of addr
+func (k *Keyring) Remove(addr common.Address) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	entry, ok := k.keys[addr]
+	if ok {
+		zeroKey(entry.key)
+		delete(k.keys, addr)
+	}
+	return ok
+}
+
+// RemoveAll drops every key
+func (k *Keyring) RemoveAll() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for addr, entry := range k.keys {
+		zeroKey(entry.key)
+		delete(k.keys, addr)
+	}
+}
+
+// List returns the keys held by the keyring sorted by address
+func (k *Keyring) List() []KeyringEntry {
+	k.expire(time.Now())
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	entries := make([]KeyringEntry, 0, len(k.keys))
+	for addr, entry := range k.keys {
+		entries = append(entries, KeyringEntry{Address: addr, Expires: entry.expires})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address.Hex() < entries[j].Address.Hex()
+	})
+	return entries
+}
+
+// SignTx signs tx with the key of addr
+func (k *Keyring) SignTx(addr common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	k.expire(time.Now())
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	entry, ok := k.keys[addr]
+	if !ok {
+		return nil, ErrKeyringKeyNotFound
+	}
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), entry.key)
+}
+
+func (k *Keyring) expire(now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for addr, entry := range k.keys {
+		if !now.Before(entry.expires) {
+			zeroKey(entry.key)
+			delete(k.keys, addr)
+		}
+	}
+}
+
+func (k *Keyring) expireLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.done:
+			return
+		case now := <-ticker.C:
+			k.expire(now)
+		}
+	}
+}
+
+func (k *Keyring) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var req keyringRequest
+	if err := json.NewDecoder(&limitedReader{conn, keyringMaxMessage}).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(keyringResponse{Error: fmt.Sprintf("invalid request: %s", err)})
+		return
+	}
+
+	res := k.do(req)
+	json.NewEncoder(conn).Encode(res)
+
+	if req.Op == "stop" {
+		k.Stop()
+	}
+}
+
+func (k *Keyring) do(req keyringRequest) keyringResponse {
+	switch req.Op {
+	case "add":
+		key, err := crypto.HexToECDSA(req.Key)
+		if err != nil {
+			return keyringResponse{Error: "invalid private key"}
+		}
+		k.Add(key, time.Duration(req.TTL)*time.Second)
+		return keyringResponse{}
+	case "sign":
+		if req.ChainID == nil {
+			return keyringResponse{Error: "missing chain id"}
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(req.Tx); err != nil {
+			return keyringResponse{Error: fmt.Sprintf("invalid transaction: %s", err)}
+		}
+		signed, err := k.SignTx(req.Address, tx, req.ChainID)
+		if err != nil {
+			return keyringResponse{Error: err.Error()}
+		}
+		data, err := signed.MarshalBinary()
+		if err != nil {
+			return keyringResponse{Error: err.Error()}
+		}
+		return keyringResponse{Tx: data}
+	case "list":
+		return keyringResponse{Keys: k.List()}
+	case "remove":
+		if !k.Remove(req.Address) {
+			return keyringResponse{Error: ErrKeyringKeyNotFound.Error()}
+		}
+		return keyringResponse{}
+	case "lock":
+		k.RemoveAll()
+		return keyringResponse{}
+	case "stop":
+		return keyringResponse{}
+	default:
+		return keyringResponse{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+}
+
+// zeroKey overwrites the private scalar of key
+func zeroKey(key *ecdsa.PrivateKey) {
+	if key != nil && key.D != nil {
+		key.D.SetUint64(0)
+	}
+}
+
+type limitedReader struct {
+	conn net.Conn
+	left int
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.left <= 0 {
+		return 0, errors.New("request too large")
+	}
+	if len(p) > r.left {
+		p = p[:r.left]
+	}
+	n, err := r.conn.Read(p)
+	r.left -= n
+	return n, err
+}
+
+// KeyringClient talks to a keyring listening on a Unix socket
+type KeyringClient struct {
+	path    string
+	timeout time.Duration
+}
+
+// DialKeyring returns a client for the keyring listening on path. Every call
+// opens its own connection, so a client is cheap and safe to share
+func DialKeyring(path string) *KeyringClient {
+	return &KeyringClient{path: path, timeout: 10 * time.Second}
+}
+
+func (c *KeyringClient) call(req keyringRequest) (keyringResponse, error) {
+	conn, err := net.DialTimeout("unix", c.path, c.timeout)
+	if err != nil {
+		return keyringResponse{}, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return keyringResponse{}, err
+	}
+	var res keyringResponse
+	if err := json.NewDecoder(&limitedReader{conn, keyringMaxMessage}).Decode(&res); err != nil {
+		return keyringResponse{}, fmt.Errorf("failed to read keyring response: %w", err)
+	}
+	if res.Error != "" {
+		if res.Error == ErrKeyringKeyNotFound.Error() {
+			return res, ErrKeyringKeyNotFound
+		}
+		return res, errors.New(res.Error)
+	}
+	return res, nil
+}
+
+// Add sends key to the keyring, kept for ttl or the keyring default when ttl is 0
+func (c *KeyringClient) Add(key *ecdsa.PrivateKey, ttl time.Duration) error {
+	_, err := c.call(keyringRequest{
+		Op:  "add",
+		Key: common.Bytes2Hex(crypto.FromECDSA(key)),
+		TTL: int64(ttl / time.Second),
+	})
+	return err
+}
+
+// List returns the keys held by the keyring
+func (c *KeyringClient) List() ([]KeyringEntry, error) {
+	res, err := c.call(keyringRequest{Op: "list"})
+	return res.Keys, err
+}
+
+// Has reports whether the keyring holds the key of addr
+func (c *KeyringClient) Has(addr common.Address) (bool, error) {
+	keys, err := c.List()
+	if err != nil {
+		return false, err
+	}
+	for _, k := range keys {
+		if k.Address == addr {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Remove drops the key of addr from the keyring
+func (c *KeyringClient) Remove(addr common.Address) error {
+	_, err := c.call(keyringRequest{Op: "remove", Address: addr})
+	return err
+}
+
+// Lock drops every key from the keyring
+func (c *KeyringClient) Lock() error {
+	_, err := c.call(keyringRequest{Op: "lock"})
+	return err
+}
+
+// Stop drops every key and stops the keyring
+func (c *KeyringClient) Stop() error {
+	_, err := c.call(keyringRequest{Op: "stop"})
+	return err
+}
+
+// SignTx asks the keyring to sign tx with the key of addr
+func (c *KeyringClient) SignTx(addr common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.call(keyringRequest{Op: "sign", Address: addr, ChainID: chainID, Tx: data})
+	if err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Tx); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+// Transactor returns transact options for addr that sign with the keyring
+func (c *KeyringClient) Transactor(addr common.Address, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: addr,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != addr {
+				return nil, bind.ErrNotAuthorized
+			}
+			return c.SignTx(addr, tx, chainID)
+		},
+		Context: context.Background(),
+	}
+}
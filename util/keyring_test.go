@@ -0,0 +1,181 @@
+package util_test
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/glifio/glif/v2/util"
+)
+
+func startKeyring(t *testing.T, ttl time.Duration) (*util.Keyring, *util.KeyringClient, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keyring.sock")
+	l, err := util.ListenKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := util.NewKeyring(ttl)
+	done := make(chan error, 1)
+	go func() { done <- k.Serve(l) }()
+	t.Cleanup(func() {
+		k.Stop()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+	return k, util.DialKeyring(path), path
+}
+
+func TestKeyringSign(t *testing.T) {
+	_, client, path := startKeyring(t, time.Minute)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode = %04o, want 0600", info.Mode().Perm())
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	if has, err := client.Has(addr); err != nil || has {
+		t.Fatalf("Has before add = %v, %v", has, err)
+	}
+	if err := client.Add(key, 0); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := client.Has(addr); err != nil || !has {
+		t.Fatalf("Has after add = %v, %v", has, err)
+	}
+
+	chainID := big.NewInt(314159)
+	to := common.HexToAddress("0x1")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(100),
+	})
+
+	auth := client.Transactor(addr, chainID)
+	signed, err := auth.Signer(addr, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != addr {
+		t.Errorf("signed by %s, want %s", sender, addr)
+	}
+	if signed.Nonce() != 7 || signed.Value().Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("signed transaction does not match the request")
+	}
+
+	if _, err := auth.Signer(to, tx); err == nil {
+		t.Errorf("expected the transactor to refuse signing for another address")
+	}
+
+	other, _ := crypto.GenerateKey()
+	if _, err := client.SignTx(crypto.PubkeyToAddress(other.PublicKey), tx, chainID); !errors.Is(err, util.ErrKeyringKeyNotFound) {
+		t.Errorf("SignTx with unknown key = %v, want ErrKeyringKeyNotFound", err)
+	}
+}
+
+func TestKeyringTTL(t *testing.T) {
+	k, client, _ := startKeyring(t, time.Hour)
+
+	short, _ := crypto.GenerateKey()
+	long, _ := crypto.GenerateKey()
+	if err := client.Add(short, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Add(long, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := client.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("got %d keys, want 2", len(keys))
+	}
+	for _, e := range keys {
+		if e.Address == crypto.PubkeyToAddress(long.PublicKey) && time.Until(e.Expires) < 59*time.Minute {
+			t.Errorf("key added without a TTL expires at %s, want the keyring default", e.Expires)
+		}
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	keys = k.List()
+	if len(keys) != 1 || keys[0].Address != crypto.PubkeyToAddress(long.PublicKey) {
+		t.Fatalf("after the TTL got keys %v, want only the long lived key", keys)
+	}
+}
+
+func TestKeyringRemoveLockStop(t *testing.T) {
+	_, client, path := startKeyring(t, time.Minute)
+
+	a, _ := crypto.GenerateKey()
+	b, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{a, b} {
+		if err := client.Add(key, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := client.Remove(crypto.PubkeyToAddress(a.PublicKey)); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Remove(crypto.PubkeyToAddress(a.PublicKey)); !errors.Is(err, util.ErrKeyringKeyNotFound) {
+		t.Errorf("removing twice = %v, want ErrKeyringKeyNotFound", err)
+	}
+	if keys, _ := client.List(); len(keys) != 1 {
+		t.Errorf("got %d keys after remove, want 1", len(keys))
+	}
+
+	if err := client.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := client.List(); len(keys) != 0 {
+		t.Errorf("got %d keys after lock, want 0", len(keys))
+	}
+
+	if _, err := util.ListenKeyring(path); err == nil {
+		t.Errorf("expected an error listening while a keyring is running")
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := client.List(); err == nil {
+		t.Errorf("expected the keyring to be stopped")
+	}
+
+	// the stale socket is replaced
+	l, err := util.ListenKeyring(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+}
@@ -0,0 +1,212 @@
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	keyShareVersion = 1
+	// KeySharePrefix marks the QR friendly text encoding of a key share. The
+	// prefix and base32 body only use characters from the QR alphanumeric set
+	KeySharePrefix = "GLIFSHARE:"
+
+	keyShareHeaderLen   = 7
+	keyShareChecksumLen = 4
+	keyShareDigestLen   = 8
+)
+
+var (
+	ErrShareChecksum      = errors.New("share checksum mismatch, the share was mistyped or corrupted")
+	ErrInsufficientShares = errors.New("not enough shares to recover the key")
+	ErrSharesInconsistent = errors.New("shares do not reconstruct a valid key, one or more shares may have been tampered with")
+)
+
+var keyShareEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// KeyShare is one share of a private key split with ShamirSplit
+type KeyShare struct {
+	Version   byte
+	Threshold byte
+	Index     byte
+	GroupID   [4]byte
+	Data      []byte
+}
+
+// SplitKey splits key into n shares, any threshold of which can recover it.
+// A digest of the key is shared along with it so that recovery can detect
+// tampered or missing shares
+func SplitKey(key []byte, n, threshold int) ([]KeyShare, error) {
+	digest := sha256.Sum256(key)
+	secret := append(append([]byte{}, key...), digest[:keyShareDigestLen]...)
+
+	parts, err := ShamirSplit(secret, n, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupID [4]byte
+	if _, err := rand.Read(groupID[:]); err != nil {
+		return nil, err
+	}
+
+	shares := make([]KeyShare, 0, n)
+	for x := 1; x <= n; x++ {
+		shares = append(shares, KeyShare{
+			Version:   keyShareVersion,
+			Threshold: byte(threshold),
+			Index:     byte(x),
+			GroupID:   groupID,
+			Data:      parts[byte(x)],
+		})
+	}
+	return shares, nil
+}
+
+// RecoverKey reconstructs a key from shares created by SplitKey
+func RecoverKey(shares []KeyShare) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrInsufficientShares
+	}
+
+	first := shares[0]
+	parts := map[byte][]byte{}
+	for _, s := range shares {
+		if s.Version != first.Version || s.GroupID != first.GroupID || s.Threshold != first.Threshold {
+			return nil, errors.New("shares are from different splits")
+		}
+		if _, ok := parts[s.Index]; ok {
+			return nil, fmt.Errorf("share %d was passed more than once", s.Index)
+		}
+		parts[s.Index] = s.Data
+	}
+
+	if len(parts) < int(first.Threshold) {
+		return nil, fmt.Errorf("%w, %d of %d shares provided", ErrInsufficientShares, len(parts), first.Threshold)
+	}
+
+	secret, err := ShamirCombine(parts)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) <= keyShareDigestLen {
+		return nil, ErrSharesInconsistent
+	}
+
+	key := secret[:len(secret)-keyShareDigestLen]
+	digest := sha256.Sum256(key)
+	if !bytes.Equal(digest[:keyShareDigestLen], secret[len(key):]) {
+		return nil, ErrSharesInconsistent
+	}
+
+	return key, nil
+}
+
+// Bytes returns the binary encoding of the share, with a trailing checksum
+func (s KeyShare) Bytes() []byte {
+	b := []byte{s.Version, s.Threshold, s.Index}
+	b = append(b, s.GroupID[:]...)
+	b = append(b, s.Data...)
+	sum := sha256.Sum256(b)
+	return append(b, sum[:keyShareChecksumLen]...)
+}
+
+// String returns the QR friendly text encoding of the share
+func (s KeyShare) String() string {
+	return KeySharePrefix + keyShareEncoding.EncodeToString(s.Bytes())
+}
+
+// Mnemonic encodes the share as words from the BIP39 english wordlist, 11 bits per word
+func (s KeyShare) Mnemonic() string {
+	b := s.Bytes()
+	words := bip39.GetWordList()
+
+	var out []string
+	var acc, bits uint
+	for _, c := range b {
+		acc = acc<<8 | uint(c)
+		bits += 8
+		for bits >= 11 {
+			bits -= 11
+			out = append(out, words[(acc>>bits)&0x7ff])
+		}
+	}
+	if bits > 0 {
+		out = append(out, words[(acc<<(11-bits))&0x7ff])
+	}
+	return strings.Join(out, " ")
+}
+
+// ParseKeyShare parses a share in either its text or mnemonic encoding
+func ParseKeyShare(str string) (KeyShare, error) {
+	str = strings.TrimSpace(str)
+
+	var b []byte
+	if strings.HasPrefix(strings.ToUpper(str), KeySharePrefix) {
+		var err error
+		b, err = keyShareEncoding.DecodeString(strings.ToUpper(str[len(KeySharePrefix):]))
+		if err != nil {
+			return KeyShare{}, ErrShareChecksum
+		}
+	} else {
+		var acc, bits uint
+		for _, w := range strings.Fields(strings.ToLower(str)) {
+			idx, ok := bip39.GetWordIndex(w)
+			if !ok {
+				return KeyShare{}, fmt.Errorf("%s is not a share word", w)
+			}
+			acc = acc<<11 | uint(idx)
+			bits += 11
+			for bits >= 8 {
+				bits -= 8
+				b = append(b, byte(acc>>bits))
+			}
+		}
+
+		// the padding of the last word can spill into an extra zero byte,
+		// in which case the checksum only matches without it
+		if len(b) > 0 && b[len(b)-1] == 0 {
+			if s, err := parseKeyShareBytes(b[:len(b)-1]); err == nil {
+				return s, nil
+			}
+		}
+	}
+
+	return parseKeyShareBytes(b)
+}
+
+func parseKeyShareBytes(b []byte) (KeyShare, error) {
+	if len(b) <= keyShareHeaderLen+keyShareChecksumLen {
+		return KeyShare{}, ErrShareChecksum
+	}
+
+	body, checksum := b[:len(b)-keyShareChecksumLen], b[len(b)-keyShareChecksumLen:]
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:keyShareChecksumLen], checksum) {
+		return KeyShare{}, ErrShareChecksum
+	}
+
+	s := KeyShare{
+		Version:   body[0],
+		Threshold: body[1],
+		Index:     body[2],
+		Data:      append([]byte{}, body[keyShareHeaderLen:]...),
+	}
+	copy(s.GroupID[:], body[3:keyShareHeaderLen])
+
+	if s.Version != keyShareVersion {
+		return KeyShare{}, fmt.Errorf("unsupported share version %d", s.Version)
+	}
+	if s.Index == 0 || s.Threshold < 2 {
+		return KeyShare{}, errors.New("invalid share header")
+	}
+
+	return s, nil
+}
@@ -0,0 +1,160 @@
+package util_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/glifio/glif/v2/util"
+)
+
+func testKey(t *testing.T) []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestSplitAndRecoverKey(t *testing.T) {
+	key := testKey(t)
+
+	shares, err := util.SplitKey(key, 5, 3)
+	if err != nil {
+		t.Fatalf("SplitKey() error: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("expected 5 shares, got %d", len(shares))
+	}
+
+	// every combination of 3 shares recovers the key
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				got, err := util.RecoverKey([]util.KeyShare{shares[i], shares[j], shares[k]})
+				if err != nil {
+					t.Fatalf("RecoverKey(%d, %d, %d) error: %v", i, j, k, err)
+				}
+				if !bytes.Equal(got, key) {
+					t.Fatalf("RecoverKey(%d, %d, %d) returned the wrong key", i, j, k)
+				}
+			}
+		}
+	}
+
+	got, err := util.RecoverKey(shares)
+	if err != nil {
+		t.Fatalf("RecoverKey() with all shares error: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Fatalf("RecoverKey() with all shares returned the wrong key")
+	}
+}
+
+func TestRecoverKeyInsufficientShares(t *testing.T) {
+	shares, err := util.SplitKey(testKey(t), 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = util.RecoverKey(shares[:2])
+	if !errors.Is(err, util.ErrInsufficientShares) {
+		t.Errorf("expected ErrInsufficientShares, got %v", err)
+	}
+
+	// lying about the threshold does not help, the digest check fails
+	forged := []util.KeyShare{shares[0], shares[1]}
+	for i := range forged {
+		forged[i].Threshold = 2
+	}
+	_, err = util.RecoverKey(forged)
+	if !errors.Is(err, util.ErrSharesInconsistent) {
+		t.Errorf("expected ErrSharesInconsistent, got %v", err)
+	}
+
+	_, err = util.RecoverKey([]util.KeyShare{shares[0], shares[0], shares[1]})
+	if err == nil {
+		t.Errorf("expected duplicate shares to fail")
+	}
+}
+
+func TestRecoverKeyTamperedShare(t *testing.T) {
+	shares, err := util.SplitKey(testKey(t), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a tampered share that is re-encoded with a valid checksum parses, but
+	// does not reconstruct the key
+	tampered := shares[0]
+	tampered.Data = append([]byte{}, tampered.Data...)
+	tampered.Data[0] ^= 0x01
+	parsed, err := util.ParseKeyShare(tampered.String())
+	if err != nil {
+		t.Fatalf("ParseKeyShare() error: %v", err)
+	}
+	_, err = util.RecoverKey([]util.KeyShare{parsed, shares[1]})
+	if !errors.Is(err, util.ErrSharesInconsistent) {
+		t.Errorf("expected ErrSharesInconsistent, got %v", err)
+	}
+
+	// a share corrupted in transit fails its checksum
+	str := []byte(shares[0].String())
+	mid := len(str) / 2
+	if str[mid] == 'A' {
+		str[mid] = 'B'
+	} else {
+		str[mid] = 'A'
+	}
+	_, err = util.ParseKeyShare(string(str))
+	if !errors.Is(err, util.ErrShareChecksum) {
+		t.Errorf("expected ErrShareChecksum, got %v", err)
+	}
+
+	// shares from different splits are rejected
+	other, err := util.SplitKey(testKey(t), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := util.RecoverKey([]util.KeyShare{shares[0], other[1]}); err == nil {
+		t.Errorf("expected shares from different splits to fail")
+	}
+}
+
+func TestKeyShareEncodings(t *testing.T) {
+	key := testKey(t)
+	shares, err := util.SplitKey(key, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromText, err := util.ParseKeyShare(shares[0].String())
+	if err != nil {
+		t.Fatalf("ParseKeyShare(text) error: %v", err)
+	}
+	fromMnemonic, err := util.ParseKeyShare(shares[1].Mnemonic())
+	if err != nil {
+		t.Fatalf("ParseKeyShare(mnemonic) error: %v", err)
+	}
+
+	got, err := util.RecoverKey([]util.KeyShare{fromText, fromMnemonic})
+	if err != nil {
+		t.Fatalf("RecoverKey() error: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Fatalf("RecoverKey() returned the wrong key")
+	}
+
+	// mnemonic encodings of every length round trip
+	for n := 1; n <= 40; n++ {
+		share := util.KeyShare{Version: 1, Threshold: 2, Index: 1, Data: bytes.Repeat([]byte{0}, n)}
+		parsed, err := util.ParseKeyShare(share.Mnemonic())
+		if err != nil {
+			t.Fatalf("ParseKeyShare(mnemonic) with %d data bytes error: %v", n, err)
+		}
+		if !bytes.Equal(parsed.Data, share.Data) {
+			t.Fatalf("mnemonic with %d data bytes did not round trip", n)
+		}
+	}
+}
@@ -1,7 +1,13 @@
 package util
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	toml "github.com/pelletier/go-toml/v2"
 )
 
 type KeyStorage struct {
@@ -21,3 +27,87 @@ func NewKeyStore(keydir string) {
 		keystore.StandardScryptP,
 	)
 }
+
+// keyStoreNetworkFile records the network a keystore was created for. The
+// keystore skips dotfiles when scanning for keys
+const keyStoreNetworkFile = ".network"
+
+type keyStoreNetwork struct {
+	Name    string `toml:"name"`
+	ChainID int64  `toml:"chain-id"`
+}
+
+// ErrKeyStoreNetworkUnknown is returned by CheckKeyStoreNetwork for a
+// keystore that holds keys but has no record of the network they were
+// created for
+var ErrKeyStoreNetworkUnknown = errors.New("the keystore has no record of the network its keys were created for")
+
+// readKeyStoreNetwork returns the network recorded for the keystore in
+// keydir, or nil when there is no record
+func readKeyStoreNetwork(keydir string) (*keyStoreNetwork, error) {
+	filename := filepath.Join(keydir, keyStoreNetworkFile)
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var recorded keyStoreNetwork
+	if err := toml.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("failed to read keystore network from %s: %w", filename, err)
+	}
+	return &recorded, nil
+}
+
+func checkRecordedNetwork(keydir string, recorded *keyStoreNetwork, name string, chainID int64) error {
+	if recorded.ChainID != chainID {
+		return fmt.Errorf("the keystore at %s was created for the %s network (chain ID %d), but glif is using the %s network (chain ID %d)", keydir, recorded.Name, recorded.ChainID, name, chainID)
+	}
+	return nil
+}
+
+// CheckKeyStoreNetwork returns an error when the keystore in keydir was created
+// for a different chain. A keystore without a record passes while it has no
+// keys, and returns ErrKeyStoreNetworkUnknown once it has some, leaving the
+// caller to decide which network they belong to
+func CheckKeyStoreNetwork(keydir string, name string, chainID int64) error {
+	recorded, err := readKeyStoreNetwork(keydir)
+	if err != nil {
+		return err
+	}
+	if recorded != nil {
+		return checkRecordedNetwork(keydir, recorded, name, chainID)
+	}
+
+	keys, _, err := ReadKeyFiles(keydir)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return ErrKeyStoreNetworkUnknown
+	}
+	return nil
+}
+
+// MarkKeyStoreNetwork records that the keystore in keydir holds keys for the
+// given network. It is called when keys are created, and returns an error
+// when the keystore is already recorded for a different chain
+func MarkKeyStoreNetwork(keydir string, name string, chainID int64) error {
+	recorded, err := readKeyStoreNetwork(keydir)
+	if err != nil {
+		return err
+	}
+	if recorded != nil {
+		return checkRecordedNetwork(keydir, recorded, name, chainID)
+	}
+
+	if err := os.MkdirAll(keydir, 0700); err != nil {
+		return err
+	}
+	data, err := toml.Marshal(keyStoreNetwork{Name: name, ChainID: chainID})
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filepath.Join(keydir, keyStoreNetworkFile), data, 0600)
+}
@@ -0,0 +1,50 @@
+package util_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/glifio/glif/v2/util"
+)
+
+func TestCheckKeyStoreNetwork(t *testing.T) {
+	keydir := filepath.Join(t.TempDir(), "keystore")
+
+	// an empty keystore passes without being marked
+	if err := util.CheckKeyStoreNetwork(keydir, "calibnet", 314159); err != nil {
+		t.Fatalf("CheckKeyStoreNetwork() error for an empty keystore: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(keydir, ".network")); !os.IsNotExist(err) {
+		t.Errorf("CheckKeyStoreNetwork() expected to leave an empty keystore unmarked")
+	}
+
+	// keys without a record can't be attributed to a network
+	ks := keystore.NewKeyStore(keydir, keystore.LightScryptN, keystore.LightScryptP)
+	if _, err := ks.NewAccount(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := util.CheckKeyStoreNetwork(keydir, "mainnet", 314); !errors.Is(err, util.ErrKeyStoreNetworkUnknown) {
+		t.Fatalf("CheckKeyStoreNetwork() expected ErrKeyStoreNetworkUnknown, got %v", err)
+	}
+
+	if err := util.MarkKeyStoreNetwork(keydir, "calibnet", 314159); err != nil {
+		t.Fatalf("MarkKeyStoreNetwork() error: %v", err)
+	}
+	if err := util.CheckKeyStoreNetwork(keydir, "calibnet", 314159); err != nil {
+		t.Errorf("CheckKeyStoreNetwork() error for the same network: %v", err)
+	}
+	if err := util.CheckKeyStoreNetwork(keydir, "mainnet", 314); err == nil {
+		t.Errorf("CheckKeyStoreNetwork() expected error for a different network, got nil")
+	}
+
+	// marking again keeps the first record
+	if err := util.MarkKeyStoreNetwork(keydir, "calibnet", 314159); err != nil {
+		t.Errorf("MarkKeyStoreNetwork() error for the same network: %v", err)
+	}
+	if err := util.MarkKeyStoreNetwork(keydir, "mainnet", 314); err == nil {
+		t.Errorf("MarkKeyStoreNetwork() expected error for a different network, got nil")
+	}
+}
@@ -0,0 +1,140 @@
+package util
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Shamir secret sharing over GF(2^8), using the AES reduction polynomial
+// x^8 + x^4 + x^3 + x + 1. Each byte of the secret is shared independently
+// with a random polynomial of degree threshold-1, evaluated at x = 1..n
+
+var (
+	gfExp [510]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// multiply by the generator 0x03
+		x ^= gfMulNoTable(x, 2)
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMulNoTable(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 == 1 {
+			p ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return p
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if b == 0 {
+		panic("division by zero in GF(256)")
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// ShamirSplit splits secret into n shares, any threshold of which can
+// reconstruct it. The returned shares are indexed 1..n
+func ShamirSplit(secret []byte, n, threshold int) (map[byte][]byte, error) {
+	if threshold < 2 {
+		return nil, errors.New("threshold must be at least 2")
+	}
+	if n < threshold {
+		return nil, errors.New("number of shares must be at least the threshold")
+	}
+	if n > 255 {
+		return nil, errors.New("number of shares must be at most 255")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("secret must not be empty")
+	}
+
+	shares := make(map[byte][]byte, n)
+	for x := 1; x <= n; x++ {
+		shares[byte(x)] = make([]byte, len(secret))
+	}
+
+	coeffs := make([]byte, threshold)
+	for i, b := range secret {
+		coeffs[0] = b
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, err
+		}
+		for x := 1; x <= n; x++ {
+			// horner's method
+			var y byte
+			for c := threshold - 1; c >= 0; c-- {
+				y = gfMul(y, byte(x)) ^ coeffs[c]
+			}
+			shares[byte(x)][i] = y
+		}
+	}
+
+	return shares, nil
+}
+
+// ShamirCombine reconstructs the secret from shares using lagrange
+// interpolation at x = 0. It can not tell whether enough shares were
+// passed, callers must check the result against a known digest
+func ShamirCombine(shares map[byte][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+
+	length := -1
+	for x, s := range shares {
+		if x == 0 {
+			return nil, errors.New("invalid share index 0")
+		}
+		if length == -1 {
+			length = len(s)
+		} else if len(s) != length {
+			return nil, fmt.Errorf("share %d has length %d, expected %d", x, len(s), length)
+		}
+	}
+
+	secret := make([]byte, length)
+	for xi, yi := range shares {
+		// lagrange basis polynomial for xi evaluated at 0
+		basis := byte(1)
+		for xj := range shares {
+			if xj == xi {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(xj, xj^xi))
+		}
+		for i := range secret {
+			secret[i] ^= gfMul(yi[i], basis)
+		}
+	}
+
+	return secret, nil
+}
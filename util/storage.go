@@ -1,13 +1,23 @@
 package util
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"os"
+	"sync"
 
 	toml "github.com/pelletier/go-toml/v2"
 )
 
+// StorageVersion is the schema version written to every store. Files
+// written by a newer version are refused instead of being overwritten
+const StorageVersion = 1
+
+// storageVersionKey is the top level key holding the schema version. Files
+// without it were written before stores were versioned
+const storageVersionKey = "schema_version"
+
 type ErrKeyNotFound struct {
 	Key string
 }
@@ -16,11 +26,24 @@ func (e *ErrKeyNotFound) Error() string { return "key not found: " + e.Key }
 
 type StorageData map[string]string
 
+// StorageSections are named tables of key-value pairs saved alongside the
+// top level pairs. Keys in sections are not listed by AccountNames
+type StorageSections map[string]StorageData
+
 // Storage is a structure that holds the filename and a map of key-value pairs.
+//
+// Every change locks the file, reloads it, applies the change and saves it
+// atomically, so several processes, like autopilot and a manual command, can
+// update the same store without losing each other's changes. The previous
+// contents are kept in a .bak file next to it
 type Storage struct {
 	filename string
+	mu       sync.RWMutex
 	data     StorageData
+	sections StorageSections
 	writable bool
+	// version is the schema version of the file as last read
+	version int64
 }
 
 // NewStorage creates a new Storage instance and initializes it with the given filename.
@@ -31,23 +54,28 @@ func NewStorage(filename string, defaultMap map[string]string, writable bool) (*
 		writable: writable,
 	}
 
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		err = s.save()
-		if err != nil {
+	if _, err := os.Stat(filename); err == nil {
+		if err := s.load(); err != nil {
 			return nil, err
 		}
-	} else {
-		err := s.load()
-		if err != nil {
-			return nil, err
+		if len(s.data) > 0 && s.version == StorageVersion {
+			return s, nil
 		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// create the file, fill it with the defaults when it is empty or upgrade
+	// it to the current schema version. Another process may get there first,
+	// update reloads the file before saving
+	err := s.update(func() error {
 		if len(s.data) == 0 {
 			s.data = defaultMap
-			err = s.save()
-			if err != nil {
-				return nil, err
-			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return s, nil
@@ -55,48 +83,130 @@ func NewStorage(filename string, defaultMap map[string]string, writable bool) (*
 
 // load reads the contents of the file and loads the key-value pairs into the data map.
 func (s *Storage) load() error {
-	file, err := os.Open(s.filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	fileContent, err := io.ReadAll(file)
+	fileContent, err := os.ReadFile(s.filename)
 	if err != nil {
 		return err
 	}
+	return s.parse(fileContent)
+}
 
-	var sd StorageData
+// parse replaces the key-value pairs with the contents of a store file
+func (s *Storage) parse(fileContent []byte) error {
+	var raw map[string]interface{}
 
-	if err := toml.Unmarshal(fileContent, &sd); err != nil {
+	if err := toml.Unmarshal(fileContent, &raw); err != nil {
+		if _, statErr := os.Stat(s.filename + ".bak"); statErr == nil {
+			return fmt.Errorf("failed to unmarshal toml file, the previous version is saved in %s.bak: %w", s.filename, err)
+		}
 		return fmt.Errorf("failed to unmarshal toml file: %w", err)
 	}
 
+	var version int64
+	sd := StorageData{}
+	sections := StorageSections{}
+	for key, value := range raw {
+		switch v := value.(type) {
+		case int64:
+			if key != storageVersionKey {
+				return fmt.Errorf("failed to unmarshal toml file: %s is not a string", key)
+			}
+			if v > StorageVersion {
+				return fmt.Errorf("%s has schema version %d, this version of glif supports up to %d. Please upgrade glif", s.filename, v, StorageVersion)
+			}
+			version = v
+		case string:
+			sd[key] = v
+		case map[string]interface{}:
+			section := StorageData{}
+			for k, sv := range v {
+				str, ok := sv.(string)
+				if !ok {
+					return fmt.Errorf("failed to unmarshal toml file: %s.%s is not a string", key, k)
+				}
+				section[k] = str
+			}
+			sections[key] = section
+		default:
+			return fmt.Errorf("failed to unmarshal toml file: %s is not a string", key)
+		}
+	}
+
 	s.data = sd
+	s.sections = sections
+	s.version = version
 
 	return nil
 }
 
-// save writes the current key-value pairs in the data map to the file.
-func (s *Storage) save() error {
+// update applies fn to the latest contents of the file and saves the result.
+// The file stays locked from reloading to saving, so concurrent processes
+// apply their changes one after the other. Stores that are not writable are
+// only changed in memory
+func (s *Storage) update(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.writable {
-		return nil
+		return fn()
 	}
-	keyStore, err := toml.Marshal(s.data)
+
+	unlock, err := lockFile(s.filename + ".lock")
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	err = os.WriteFile(s.filename, keyStore, 0644)
+	prev, err := os.ReadFile(s.filename)
+	if err == nil {
+		if err := s.parse(prev); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+	return s.save(prev)
+}
+
+// save writes the current key-value pairs in the data map to the file,
+// keeping prev, the contents it replaces, in the backup file. Must be
+// called with the file locked
+func (s *Storage) save(prev []byte) error {
+	content := make(map[string]interface{}, len(s.data)+len(s.sections)+1)
+	for k, v := range s.data {
+		content[k] = v
+	}
+	for name, section := range s.sections {
+		if len(section) > 0 {
+			content[name] = section
+		}
+	}
+	content[storageVersionKey] = StorageVersion
+
+	keyStore, err := toml.Marshal(content)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if bytes.Equal(prev, keyStore) {
+		return nil
+	}
+	if len(prev) > 0 {
+		if err := WriteFileAtomic(s.filename+".bak", prev, 0600); err != nil {
+			return err
+		}
+	}
+
+	return WriteFileAtomic(s.filename, keyStore, 0600)
 }
 
 // Get retrieves the value associated with the given key.
 func (s *Storage) Get(key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	value, ok := s.data[key]
 	if !ok {
 		return "", &ErrKeyNotFound{key}
@@ -106,21 +216,33 @@ func (s *Storage) Get(key string) (string, error) {
 
 // Set sets a key-value pair in the data map and saves the data to the file.
 func (s *Storage) Set(key, value string) error {
-	s.data[key] = value
-	return s.save()
+	if key == storageVersionKey {
+		return fmt.Errorf("%s is reserved", key)
+	}
+	return s.update(func() error {
+		if _, ok := s.sections[key]; ok {
+			return fmt.Errorf("%s is reserved", key)
+		}
+		s.data[key] = value
+		return nil
+	})
 }
 
 // Delete removes a key-value pair from the data map and saves the data to the file.
 func (s *Storage) Delete(key string) error {
-	if _, ok := s.data[key]; !ok {
-		return &ErrKeyNotFound{key}
-	}
-	delete(s.data, key)
-	return s.save()
+	return s.update(func() error {
+		if _, ok := s.data[key]; !ok {
+			return &ErrKeyNotFound{key}
+		}
+		delete(s.data, key)
+		return nil
+	})
 }
 
 // AccountNames retrieves a list of all the account names
 func (s *Storage) AccountNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	keys := make([]string, len(s.data))
 
 	i := 0
@@ -130,3 +252,56 @@ func (s *Storage) AccountNames() []string {
 	}
 	return keys
 }
+
+// GetIn retrieves the value associated with key in section
+func (s *Storage) GetIn(section, key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.sections[section][key]
+	if !ok {
+		return "", &ErrKeyNotFound{section + "." + key}
+	}
+	return value, nil
+}
+
+// SetIn sets a key-value pair in section and saves the data to the file.
+func (s *Storage) SetIn(section, key, value string) error {
+	if section == storageVersionKey {
+		return fmt.Errorf("%s is reserved", section)
+	}
+	return s.update(func() error {
+		if _, ok := s.data[section]; ok {
+			return fmt.Errorf("%s is already used as a key", section)
+		}
+		if s.sections == nil {
+			s.sections = StorageSections{}
+		}
+		if s.sections[section] == nil {
+			s.sections[section] = StorageData{}
+		}
+		s.sections[section][key] = value
+		return nil
+	})
+}
+
+// DeleteIn removes a key-value pair from section and saves the data to the file.
+func (s *Storage) DeleteIn(section, key string) error {
+	return s.update(func() error {
+		if _, ok := s.sections[section][key]; !ok {
+			return &ErrKeyNotFound{section + "." + key}
+		}
+		delete(s.sections[section], key)
+		return nil
+	})
+}
+
+// Section returns a copy of the key-value pairs in section
+func (s *Storage) Section(section string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	values := make(map[string]string, len(s.sections[section]))
+	for k, v := range s.sections[section] {
+		values[k] = v
+	}
+	return values
+}
@@ -1,7 +1,14 @@
 package util_test
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/glifio/glif/v2/util"
@@ -14,6 +21,13 @@ var defaultMap = map[string]string{
 	"key2": "",
 }
 
+// removeStorage removes a store file with its lock and backup files
+func removeStorage(filename string) {
+	os.Remove(filename)
+	os.Remove(filename + ".lock")
+	os.Remove(filename + ".bak")
+}
+
 func TestNewStorage(t *testing.T) {
 	_, err := util.NewStorage(testFilename, defaultMap, true)
 	if err != nil {
@@ -21,7 +35,7 @@ func TestNewStorage(t *testing.T) {
 	}
 
 	// Cleanup
-	os.Remove(testFilename)
+	removeStorage(testFilename)
 }
 
 func TestSetNonExistentKey(t *testing.T) {
@@ -81,7 +95,7 @@ func TestGetSetDelete(t *testing.T) {
 	}
 
 	// Cleanup
-	os.Remove(testFilename)
+	removeStorage(testFilename)
 }
 
 func TestNonexistentKey(t *testing.T) {
@@ -101,5 +115,273 @@ func TestNonexistentKey(t *testing.T) {
 	}
 
 	// Cleanup
-	os.Remove(testFilename)
+	removeStorage(testFilename)
+}
+
+func TestSections(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "accounts.toml")
+	store, err := util.NewStorage(filename, map[string]string{}, true)
+	if err != nil {
+		t.Fatalf("NewStorage() error: %v", err)
+	}
+
+	if err := store.Set("owner", "0x01"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := store.SetIn("paths", "owner", "m/44'/60'/0'/0/0"); err != nil {
+		t.Fatalf("SetIn() error: %v", err)
+	}
+	if err := store.Set("paths", "0x02"); err == nil {
+		t.Errorf("Set() expected an error for a key used as a section")
+	}
+	if err := store.SetIn("owner", "key", "value"); err == nil {
+		t.Errorf("SetIn() expected an error for a section used as a key")
+	}
+
+	// reload the storage from the file
+	store, err = util.NewStorage(filename, map[string]string{}, true)
+	if err != nil {
+		t.Fatalf("NewStorage() error: %v", err)
+	}
+
+	value, err := store.GetIn("paths", "owner")
+	if err != nil {
+		t.Fatalf("GetIn() error: %v", err)
+	}
+	if value != "m/44'/60'/0'/0/0" {
+		t.Errorf("GetIn() expected m/44'/60'/0'/0/0, got %s", value)
+	}
+	if names := store.AccountNames(); !reflect.DeepEqual(names, []string{"owner"}) {
+		t.Errorf("AccountNames() expected [owner], got %v", names)
+	}
+	if section := store.Section("paths"); !reflect.DeepEqual(section, map[string]string{"owner": "m/44'/60'/0'/0/0"}) {
+		t.Errorf("Section() expected the owner path, got %v", section)
+	}
+
+	if err := store.DeleteIn("paths", "owner"); err != nil {
+		t.Fatalf("DeleteIn() error: %v", err)
+	}
+	if _, err := store.GetIn("paths", "owner"); err == nil {
+		t.Errorf("GetIn() expected error, got nil")
+	}
+	if err := store.DeleteIn("paths", "owner"); err == nil {
+		t.Errorf("DeleteIn() expected error, got nil")
+	}
+}
+
+func TestStorageSaveVersionAndBackup(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "agent.toml")
+
+	// a store written before stores were versioned
+	if err := os.WriteFile(filename, []byte("id = \"1\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := util.NewStorage(filename, defaultMap, true)
+	if err != nil {
+		t.Fatalf("NewStorage() error: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), fmt.Sprintf("schema_version = %d", util.StorageVersion)) {
+		t.Errorf("expected the store to be upgraded to the current schema version, got:\n%s", data)
+	}
+	if names := store.AccountNames(); !reflect.DeepEqual(names, []string{"id"}) {
+		t.Errorf("AccountNames() expected [id], got %v", names)
+	}
+	if err := store.Set("schema_version", "2"); err == nil {
+		t.Errorf("Set() expected an error for the schema version key")
+	}
+
+	if err := store.Set("id", "2"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	backup, err := os.ReadFile(filename + ".bak")
+	if err != nil {
+		t.Fatalf("expected a backup of the previous version: %v", err)
+	}
+	if !strings.Contains(string(backup), "id = '1'") {
+		t.Errorf("backup expected the previous id, got:\n%s", backup)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("store mode = %04o, want 0600", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+
+	// a store written by a newer version is refused
+	newer := filepath.Join(dir, "newer.toml")
+	content := fmt.Sprintf("schema_version = %d\nid = '1'\n", util.StorageVersion+1)
+	if err := os.WriteFile(newer, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := util.NewStorage(newer, defaultMap, true); err == nil {
+		t.Errorf("NewStorage() expected an error for a newer schema version")
+	}
+	if data, _ := os.ReadFile(newer); string(data) != content {
+		t.Errorf("store with a newer schema version was overwritten")
+	}
+}
+
+// TestStorageConcurrentInstances updates one file from several stores, as
+// separate commands would, and reads from a shared store while it is updated
+func TestStorageConcurrentInstances(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "agent.toml")
+	shared, err := util.NewStorage(filename, map[string]string{}, true)
+	if err != nil {
+		t.Fatalf("NewStorage() error: %v", err)
+	}
+
+	const writers, writes = 4, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*2)
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			store, err := util.NewStorage(filename, map[string]string{}, true)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for i := 0; i < writes; i++ {
+				if err := store.Set(fmt.Sprintf("w%d-%d", w, i), strconv.Itoa(i)); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				if err := shared.SetIn("shared", fmt.Sprintf("w%d-%d", w, i), strconv.Itoa(i)); err != nil {
+					errs <- err
+					return
+				}
+				shared.AccountNames()
+				shared.Section("shared")
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	store, err := util.NewStorage(filename, map[string]string{}, true)
+	if err != nil {
+		t.Fatalf("NewStorage() error: %v", err)
+	}
+	if n := len(store.AccountNames()); n != writers*writes {
+		t.Errorf("expected %d keys, got %d", writers*writes, n)
+	}
+	if n := len(store.Section("shared")); n != writers*writes {
+		t.Errorf("expected %d keys in the shared section, got %d", writers*writes, n)
+	}
+}
+
+// TestStorageConcurrentProcesses runs copies of the test binary that each
+// set their own keys in the same store. Without locking, a process saving
+// what it loaded earlier drops the keys other processes set in between
+func TestStorageConcurrentProcesses(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "backups.toml")
+	const procs, writes = 6, 30
+
+	cmds := make([]*exec.Cmd, procs)
+	outputs := make([]*strings.Builder, procs)
+	for p := 0; p < procs; p++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestStorageWriterProcess$")
+		cmd.Env = append(os.Environ(),
+			"GLIF_STORAGE_TEST_FILE="+filename,
+			"GLIF_STORAGE_TEST_WRITER="+strconv.Itoa(p),
+			"GLIF_STORAGE_TEST_WRITES="+strconv.Itoa(writes),
+		)
+		outputs[p] = &strings.Builder{}
+		cmd.Stdout = outputs[p]
+		cmd.Stderr = outputs[p]
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[p] = cmd
+	}
+	var failed bool
+	for p, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("writer %d failed: %v\n%s", p, err, outputs[p])
+			failed = true
+		}
+	}
+	if failed {
+		t.FailNow()
+	}
+
+	store, err := util.NewStorage(filename, map[string]string{}, true)
+	if err != nil {
+		t.Fatalf("NewStorage() error: %v", err)
+	}
+	for p := 0; p < procs; p++ {
+		for i := 0; i < writes; i++ {
+			key := fmt.Sprintf("p%d-%d", p, i)
+			if i%3 == 2 {
+				// every third key is deleted again
+				if _, err := store.Get(key); err == nil {
+					t.Errorf("expected %s to be deleted", key)
+				}
+				continue
+			}
+			value, err := store.Get(key)
+			if err != nil {
+				t.Errorf("Get(%s) error: %v", key, err)
+				continue
+			}
+			if value != strconv.Itoa(i) {
+				t.Errorf("Get(%s) expected %d, got %s", key, i, value)
+			}
+		}
+	}
+}
+
+// TestStorageWriterProcess is run by TestStorageConcurrentProcesses
+func TestStorageWriterProcess(t *testing.T) {
+	filename := os.Getenv("GLIF_STORAGE_TEST_FILE")
+	if filename == "" {
+		t.Skip("run by TestStorageConcurrentProcesses")
+	}
+	writer := os.Getenv("GLIF_STORAGE_TEST_WRITER")
+	writes, err := strconv.Atoi(os.Getenv("GLIF_STORAGE_TEST_WRITES"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := util.NewStorage(filename, map[string]string{}, true)
+	if err != nil {
+		t.Fatalf("NewStorage() error: %v", err)
+	}
+	for i := 0; i < writes; i++ {
+		key := fmt.Sprintf("p%s-%d", writer, i)
+		if err := store.Set(key, strconv.Itoa(i)); err != nil {
+			t.Fatalf("Set() error: %v", err)
+		}
+		if i%3 == 2 {
+			if err := store.Delete(key); err != nil {
+				t.Fatalf("Delete() error: %v", err)
+			}
+		}
+	}
 }
@@ -0,0 +1,95 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	toml "github.com/pelletier/go-toml/v2"
+)
+
+// Token is an ERC-20 contract registered by name
+type Token struct {
+	Address  string `toml:"address"`
+	Symbol   string `toml:"symbol"`
+	Decimals uint8  `toml:"decimals"`
+}
+
+// TokenStorage is the registry of ERC-20 tokens added by the user, keyed by name
+type TokenStorage struct {
+	filename string
+	tokens   map[string]Token
+}
+
+var tokenStore *TokenStorage
+
+func TokenStore() *TokenStorage {
+	return tokenStore
+}
+
+func NewTokenStore(filename string) error {
+	s := &TokenStorage{
+		filename: filename,
+		tokens:   map[string]Token{},
+	}
+
+	if _, err := os.Stat(filename); err == nil {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		if err := toml.Unmarshal(data, &s.tokens); err != nil {
+			return fmt.Errorf("failed to unmarshal toml file: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	tokenStore = s
+
+	return nil
+}
+
+// save writes the registry to the file
+func (s *TokenStorage) save() error {
+	data, err := toml.Marshal(s.tokens)
+	if err != nil {
+		return err
+	}
+
+	return WriteFileAtomic(s.filename, data, 0600)
+}
+
+// Get retrieves the token registered under name
+func (s *TokenStorage) Get(name string) (Token, error) {
+	token, ok := s.tokens[name]
+	if !ok {
+		return Token{}, &ErrKeyNotFound{name}
+	}
+	return token, nil
+}
+
+// Set registers token under name and saves the registry to the file
+func (s *TokenStorage) Set(name string, token Token) error {
+	s.tokens[name] = token
+	return s.save()
+}
+
+// Delete removes the token registered under name and saves the registry to the file
+func (s *TokenStorage) Delete(name string) error {
+	if _, ok := s.tokens[name]; !ok {
+		return &ErrKeyNotFound{name}
+	}
+	delete(s.tokens, name)
+	return s.save()
+}
+
+// Names returns the names of every registered token, sorted
+func (s *TokenStorage) Names() []string {
+	names := make([]string, 0, len(s.tokens))
+	for name := range s.tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,51 @@
+package util_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/glifio/glif/v2/util"
+)
+
+func TestTokenStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tokens.toml")
+	if err := util.NewTokenStore(filename); err != nil {
+		t.Fatalf("NewTokenStore() error: %v", err)
+	}
+
+	usdc := util.Token{Address: "0x1000000000000000000000000000000000000001", Symbol: "USDC", Decimals: 6}
+	if err := util.TokenStore().Set("usdc", usdc); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := util.TokenStore().Set("abc", util.Token{Address: "0x2000000000000000000000000000000000000002", Symbol: "ABC", Decimals: 18}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	// reload the registry from the file
+	if err := util.NewTokenStore(filename); err != nil {
+		t.Fatalf("NewTokenStore() error: %v", err)
+	}
+
+	token, err := util.TokenStore().Get("usdc")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if token != usdc {
+		t.Errorf("Get() expected %v, got %v", usdc, token)
+	}
+
+	if names := util.TokenStore().Names(); !reflect.DeepEqual(names, []string{"abc", "usdc"}) {
+		t.Errorf("Names() expected [abc usdc], got %v", names)
+	}
+
+	if err := util.TokenStore().Delete("usdc"); err != nil {
+		t.Errorf("Delete() error: %v", err)
+	}
+	if _, err := util.TokenStore().Get("usdc"); err == nil {
+		t.Errorf("Get() expected error, got nil")
+	}
+	if err := util.TokenStore().Delete("usdc"); err == nil {
+		t.Errorf("Delete() expected error, got nil")
+	}
+}